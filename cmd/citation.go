@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcphost/pkg/history"
+	"github.com/mark3labs/mcphost/pkg/rag"
+)
+
+// citationInstruction 追加到系统提示之后，要求模型标注回答所依据的资料
+const citationInstruction = `当回答用到了带编号的参考资料或工具结果（形如 [n] 开头的内容）时，` +
+	`请在相应的句子后用 [n] 标注来源，多个来源写作 [1][3]。不要编造不存在的编号，没有用到资料时不需要标注。`
+
+// withCitationInstruction 在系统提示后追加引用说明
+func withCitationInstruction(systemPrompt string) string {
+	if systemPrompt == "" {
+		return citationInstruction
+	}
+	return systemPrompt + "\n\n" + citationInstruction
+}
+
+// nextCitationIndex 返回本轮对话中下一个可用的引用编号
+func nextCitationIndex(messages []history.HistoryMessage) int {
+	next := 1
+	for _, s := range history.TurnSources(messages) {
+		if s.Index >= next {
+			next = s.Index + 1
+		}
+	}
+	return next
+}
+
+// localCitationPattern 匹配工具结果中每条内容开头的本地编号，例如 rag__search 结果中的 "[1] 来源: ..."
+var localCitationPattern = regexp.MustCompile(`(?m)^\[(\d+)\]`)
+
+// citeToolResult 为成功的工具结果分配从 first 开始的引用编号，并改写结果使模型能看到这些编号。
+// 结果的 _meta 中带有来源列表时（例如内置的 rag__search），每条来源单独编号，
+// 结果文本中的本地编号被替换为本轮的全局编号；否则整个结果作为一个来源。
+func citeToolResult(toolName string, result *mcp.CallToolResult, first int) []history.Source {
+	if result.IsError || len(result.Content) == 0 {
+		return nil
+	}
+
+	if sources := rag.SourcesFromMeta(result.Meta); len(sources) > 0 {
+		renumber := make(map[int]int, len(sources))
+		for i := range sources {
+			renumber[sources[i].Index] = first + i
+			sources[i].Index = first + i
+		}
+		for i, item := range result.Content {
+			text, ok := item.(mcp.TextContent)
+			if !ok {
+				continue
+			}
+			text.Text = localCitationPattern.ReplaceAllStringFunc(text.Text, func(m string) string {
+				n, _ := strconv.Atoi(m[1 : len(m)-1])
+				if global, ok := renumber[n]; ok {
+					return fmt.Sprintf("[%d]", global)
+				}
+				return m
+			})
+			result.Content[i] = text
+		}
+		return sources
+	}
+
+	label := mcp.NewTextContent(fmt.Sprintf("[%d] 工具 %s 的结果：", first, toolName))
+	result.Content = append([]mcp.Content{label}, result.Content...)
+	return []history.Source{{Index: first, Path: toolName}}
+}
+
+// formatSources 把回答引用的来源格式化为 "Sources" 脚注
+func formatSources(sources []history.Source) string {
+	if len(sources) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Sources:\n")
+	for _, s := range sources {
+		sb.WriteString(fmt.Sprintf("  [%d] %s\n", s.Index, s.Location()))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
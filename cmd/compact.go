@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/mark3labs/mcphost/pkg/history"
+	"github.com/mark3labs/mcphost/pkg/llm"
+	"github.com/mark3labs/mcphost/pkg/session"
+)
+
+const (
+	// summaryPrefix 标记由压缩生成的摘要消息
+	summaryPrefix = "[对话摘要]"
+	// maxTranscriptBlockChars 是写入摘要原文时单个工具结果保留的最大字符数
+	maxTranscriptBlockChars = 2000
+	// maxTranscriptSourceChars 是写入摘要原文时单条检索结果保留的最大字符数
+	maxTranscriptSourceChars = 500
+)
+
+// summaryInstruction 是要求模型生成摘要的指令
+const summaryInstruction = `请将下面的对话记录压缩为一份简洁的摘要，供后续对话在上下文有限的情况下继续使用。
+摘要必须保留：
+1. 用户的目标、需求和约束条件；
+2. 已经做出的关键决定及其理由；
+3. 工具调用得到的重要发现（具体数据、文件路径、标识符、结论等）；
+4. 尚未解决的问题和下一步计划；
+5. 回答中引用过的编号及其来源（例如“[2] docs/leave.md:L3-5：年假需提前三天申请”）。
+只输出摘要本身，不要编造对话中没有的内容。`
+
+// totalTokens 估算消息列表的 token 总数
+func totalTokens(messages []history.HistoryMessage) int {
+	total := 0
+	for i := range messages {
+		total += messages[i].Tokens()
+	}
+	return total
+}
+
+// compactSplit 选择压缩的分界点：分界点之后的消息原样保留，之前的消息被摘要替换。
+// 分界点总在用户消息处，保证工具调用和结果不会被拆开；
+// 在满足 keepTokens 的前提下尽量多保留最近的消息，至少保留最后一轮用户输入。
+func compactSplit(messages []history.HistoryMessage, keepTokens int) int {
+	split := 0
+	kept := 0
+	for i := len(messages) - 1; i > 0; i-- {
+		kept += messages[i].Tokens()
+		if messages[i].Role != "user" {
+			continue
+		}
+		if split != 0 && kept > keepTokens {
+			break
+		}
+		split = i
+	}
+	return split
+}
+
+// compactMessages 用模型生成的摘要替换较早的消息，返回压缩后的消息列表。
+// keepTokens 控制原样保留的最近消息量，budget 限制每次摘要请求的原文长度。
+// 只依赖 llm.Provider.CreateMessage，因此适用于所有 provider。
+func compactMessages(
+	ctx context.Context,
+	provider llm.Provider,
+	messages []history.HistoryMessage,
+	budget int,
+	keepTokens int,
+) ([]history.HistoryMessage, error) {
+	split := compactSplit(messages, keepTokens)
+	if split == 0 {
+		return messages, nil
+	}
+
+	// 原文过长时分段滚动摘要：每段都带上前一段的摘要
+	chunkLimit := budget / 2
+	var summary string
+	var chunk strings.Builder
+	flush := func() error {
+		if chunk.Len() == 0 {
+			return nil
+		}
+		var err error
+		summary, err = summarizeTranscript(ctx, provider, summary, chunk.String())
+		chunk.Reset()
+		return err
+	}
+	for _, msg := range messages[:split] {
+		text := transcriptOf(msg)
+		if chunk.Len() > 0 && history.EstimateTokens(chunk.String()+text) > chunkLimit {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		chunk.WriteString(text)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	compacted := []history.HistoryMessage{
+		{
+			Role: "user",
+			Content: []history.ContentBlock{{
+				Type: "text",
+				Text: summaryPrefix + "\n以下是此前对话的摘要：\n\n" + summary,
+			}},
+		},
+		{
+			Role: "assistant",
+			Content: []history.ContentBlock{{
+				Type: "text",
+				Text: "好的，我会基于以上摘要继续对话。",
+			}},
+		},
+	}
+	return append(compacted, messages[split:]...), nil
+}
+
+// summarizeTranscript 请求模型为一段对话记录生成摘要，previous 为之前已有的摘要
+func summarizeTranscript(
+	ctx context.Context,
+	provider llm.Provider,
+	previous string,
+	transcript string,
+) (string, error) {
+	var prompt strings.Builder
+	prompt.WriteString(summaryInstruction)
+	if previous != "" {
+		prompt.WriteString("\n\n已有摘要（请与新的对话记录合并）：\n")
+		prompt.WriteString(previous)
+	}
+	prompt.WriteString("\n\n对话记录：\n")
+	prompt.WriteString(transcript)
+
+	request := []llm.Message{&history.HistoryMessage{
+		Role:    "user",
+		Content: []history.ContentBlock{{Type: "text", Text: prompt.String()}},
+	}}
+	message, err := provider.CreateMessage(ctx, "", request, nil)
+	if err != nil {
+		return "", fmt.Errorf("生成对话摘要失败: %w", err)
+	}
+
+	summary := strings.TrimSpace(message.GetContent())
+	if summary == "" {
+		return "", fmt.Errorf("生成对话摘要失败: 模型返回了空内容")
+	}
+	return summary, nil
+}
+
+// transcriptOf 将一条消息转换为摘要用的纯文本记录
+func transcriptOf(msg history.HistoryMessage) string {
+	var sb strings.Builder
+	for _, block := range msg.Content {
+		switch block.Type {
+		case "text":
+			role := "用户"
+			if msg.Role == "assistant" {
+				role = "助手"
+			}
+			sb.WriteString(fmt.Sprintf("%s: %s\n", role, block.Text))
+		case "tool_use":
+			sb.WriteString(fmt.Sprintf("助手调用工具 %s，参数: %s\n", block.Name, string(block.Input)))
+		case "tool_result":
+			text := block.Text
+			if text == "" && block.Content != nil {
+				if data, err := json.Marshal(block.Content); err == nil {
+					text = string(data)
+				}
+			}
+			sb.WriteString(fmt.Sprintf("工具结果: %s\n", truncateTranscript(text, maxTranscriptBlockChars)))
+			for _, src := range block.Sources {
+				sb.WriteString(fmt.Sprintf("工具结果的引用编号 %s\n", sourceLabel(src)))
+			}
+		case history.SourceBlockType:
+			// 检索到的内容保留编号和来源，摘要之后回答中的 [n] 仍能对应到出处
+			text := truncateTranscript(strings.TrimSpace(block.Text), maxTranscriptSourceChars)
+			if block.Source == nil {
+				sb.WriteString(fmt.Sprintf("检索结果: %s\n", text))
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("检索结果 %s\n%s\n", sourceLabel(*block.Source), text))
+		}
+	}
+	return sb.String()
+}
+
+// sourceLabel 返回摘要原文中来源的写法，例如 "[2] 来源: docs/leave.md:L3-5（ID leave-1）"
+func sourceLabel(src history.Source) string {
+	label := fmt.Sprintf("[%d] 来源: %s", src.Index, src.Location())
+	if src.ID != "" {
+		label += fmt.Sprintf("（ID %s）", src.ID)
+	}
+	return label
+}
+
+// truncateTranscript 将摘要原文中的 text 截断到最多 limit 个字符，截断时追加截断标记
+func truncateTranscript(text string, limit int) string {
+	if runes := []rune(text); len(runes) > limit {
+		return string(runes[:limit]) + truncatedMarker
+	}
+	return text
+}
+
+// compactHistory 压缩当前会话的消息历史并记录到会话文件，返回是否发生了压缩
+func compactHistory(
+	ctx context.Context,
+	provider llm.Provider,
+	store *session.Store,
+	sess *session.Session,
+	messages *[]history.HistoryMessage,
+	budget int,
+	keepTokens int,
+) (bool, error) {
+	if compactSplit(*messages, keepTokens) == 0 {
+		return false, nil
+	}
+
+	var compacted []history.HistoryMessage
+	var err error
+	action := func() {
+		compacted, err = compactMessages(ctx, provider, *messages, budget, keepTokens)
+	}
+	runWithSpinner("正在压缩对话历史...", action)
+	if err != nil {
+		return false, err
+	}
+
+	log.Info("对话历史已压缩",
+		"before", len(*messages), "after", len(compacted),
+		"tokens_before", totalTokens(*messages), "tokens_after", totalTokens(compacted))
+	*messages = compacted
+	if err := store.Compact(sess, compacted); err != nil {
+		log.Error("保存压缩后的会话失败", "id", sess.ID, "error", err)
+	}
+	return true, nil
+}
+
+// handleCompactCommand 处理 /compact 命令，立即压缩除最后一轮外的全部对话历史
+func handleCompactCommand(
+	ctx context.Context,
+	provider llm.Provider,
+	store *session.Store,
+	sess *session.Session,
+	messages *[]history.HistoryMessage,
+	budget int,
+) {
+	compacted, err := compactHistory(ctx, provider, store, sess, messages, budget, 0)
+	if err != nil {
+		fmt.Printf("\n%s\n\n", errorStyle.Render(fmt.Sprintf("压缩对话失败: %v", err)))
+		return
+	}
+	if !compacted {
+		fmt.Printf("\n%s\n\n", promptStyle.Render("对话历史较短，无需压缩。"))
+		return
+	}
+	fmt.Printf("\n%s\n\n", promptStyle.Render(
+		fmt.Sprintf("对话历史已压缩为 %d 条消息。", len(*messages))))
+}
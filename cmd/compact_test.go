@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcphost/pkg/history"
+)
+
+func TestTranscriptOf(t *testing.T) {
+	long := strings.Repeat("年", maxTranscriptSourceChars+10)
+	tests := []struct {
+		name string
+		msg  history.HistoryMessage
+		want string
+	}{
+		{
+			name: "检索结果保留编号、来源和 ID",
+			msg: history.HistoryMessage{Role: "user", Content: []history.ContentBlock{
+				{Type: "text", Text: "年假怎么申请？"},
+				history.NewSourceBlock(history.Source{Index: 1, ID: "leave-1", Path: "docs/leave.md", Offset: "L3-5"}, "年假需提前三天申请\n"),
+				history.NewSourceBlock(history.Source{Index: 2, Path: "docs/faq.md", Offset: "请假"}, "病假需要证明"),
+			}},
+			want: "用户: 年假怎么申请？\n" +
+				"检索结果 [1] 来源: docs/leave.md:L3-5（ID leave-1）\n年假需提前三天申请\n" +
+				"检索结果 [2] 来源: docs/faq.md#请假\n病假需要证明\n",
+		},
+		{
+			name: "过长的检索结果被截断",
+			msg: history.HistoryMessage{Role: "user", Content: []history.ContentBlock{
+				history.NewSourceBlock(history.Source{Index: 3, Path: "docs/long.md"}, long),
+			}},
+			want: "检索结果 [3] 来源: docs/long.md\n" + long[:len("年")*maxTranscriptSourceChars] + truncatedMarker + "\n",
+		},
+		{
+			name: "工具结果附带引用编号",
+			msg: history.HistoryMessage{Role: "tool", Content: []history.ContentBlock{{
+				Type: "tool_result", ToolUseID: "t1", Text: "[4] 来源: docs/api.md\n接口限流",
+				Sources: []history.Source{{Index: 4, Path: "docs/api.md"}},
+			}}},
+			want: "工具结果: [4] 来源: docs/api.md\n接口限流\n工具结果的引用编号 [4] 来源: docs/api.md\n",
+		},
+		{
+			name: "助手回复和工具调用",
+			msg: history.HistoryMessage{Role: "assistant", Content: []history.ContentBlock{
+				{Type: "text", Text: "需要提前三天 [1]"},
+				{Type: "tool_use", Name: "rag__search", Input: []byte(`{"query":"病假"}`)},
+			}},
+			want: "助手: 需要提前三天 [1]\n助手调用工具 rag__search，参数: {\"query\":\"病假\"}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transcriptOf(tt.msg); got != tt.want {
+				t.Fatalf("transcriptOf = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
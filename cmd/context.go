@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	"github.com/charmbracelet/log"
+	"github.com/mark3labs/mcphost/pkg/history"
+	"github.com/mark3labs/mcphost/pkg/llm"
+)
+
+// 上下文裁剪策略
+const (
+	pruneStrategyTokens  = "tokens"  // 按 token 预算裁剪
+	pruneStrategyWindow  = "window"  // 按消息条数裁剪
+	pruneStrategyCompact = "compact" // 超出 token 预算时用模型生成的摘要替换较早的消息
+)
+
+const (
+	// minContextBudget 是预留系统提示词、工具和输出后历史消息至少可用的 token 数
+	minContextBudget = 512
+	// truncatedMarker 追加在被截断的内容块末尾
+	truncatedMarker = "\n...[内容过长，已截断]"
+)
+
+// contextBudget 计算 --model 指定的模型中历史消息可用的 token 预算
+func contextBudget(systemPrompt string, tools []llm.Tool) int {
+	return modelContextBudget(modelFlag, systemPrompt, tools)
+}
+
+// modelContextBudget 计算 model 中历史消息可用的 token 预算：
+// 模型上下文窗口减去系统提示词、工具定义和输出长度所占的部分。
+func modelContextBudget(model, systemPrompt string, tools []llm.Tool) int {
+	window := contextTokens
+	if window <= 0 {
+		window = llm.ContextWindow(model)
+	}
+
+	// 小上下文模型（如本地 Ollama 模型）不可能预留完整的输出长度
+	reserved := llm.DefaultMaxTokens
+	if reserved > window/4 {
+		reserved = window / 4
+	}
+	reserved += history.EstimateTokens(systemPrompt)
+	if len(tools) > 0 {
+		if data, err := json.Marshal(tools); err == nil {
+			reserved += history.EstimateTokens(string(data))
+		}
+	}
+
+	budget := window - reserved
+	if budget < minContextBudget {
+		log.Debug("上下文预算过小，使用最小值",
+			"window", window, "reserved", reserved, "min", minContextBudget)
+		budget = minContextBudget
+	}
+	return budget
+}
+
+// prepareContext 按 --prune-strategy 选择的策略裁剪即将发送给模型的消息，不修改原始历史。
+// compact 策略在每轮对话开始前压缩历史，这里仍按 token 预算兜底，
+// 以防同一轮中的工具结果超出预算。
+func prepareContext(messages []history.HistoryMessage, budget int) []history.HistoryMessage {
+	if pruneStrategy == pruneStrategyWindow {
+		return pruneMessages(messages)
+	}
+	return pruneByTokens(messages, budget)
+}
+
+// pruneByTokens 将消息裁剪到 token 预算以内：
+// 先从最早的消息开始截断过长的内容块，仍超出预算时再从最早的一轮开始整轮丢弃，
+// 最后移除失去配对的工具调用和结果。当前一轮（最新的用户消息及其后的工具调用和结果）始终保留，
+// 工具循环中途裁剪时模型仍能看到用户的问题和已有的工具结果。
+func pruneByTokens(messages []history.HistoryMessage, budget int) []history.HistoryMessage {
+	if len(messages) == 0 {
+		return messages
+	}
+
+	sizes := make([]int, len(messages))
+	total := 0
+	for i := range messages {
+		sizes[i] = messages[i].Tokens()
+		total += sizes[i]
+	}
+	if total <= budget {
+		return messages
+	}
+
+	// 复制一份，截断时不影响原始历史（会话文件中保留完整内容）
+	pruned := make([]history.HistoryMessage, len(messages))
+	copy(pruned, messages)
+
+	current := currentTurnStart(pruned)
+
+	// 第一步：截断过长的内容块，单个内容块最多占预算的四分之一
+	blockLimit := budget / 4
+	for i := 0; i < len(pruned) && total > budget; i++ {
+		var blocks []history.ContentBlock
+		for j, block := range pruned[i].Content {
+			// 当前一轮中的用户输入和模型回复不截断，只截断工具结果
+			if i >= current && block.Type != "tool_result" {
+				continue
+			}
+			if block.Type != "text" && block.Type != "tool_result" {
+				continue
+			}
+			before := block.Tokens()
+			if before <= blockLimit {
+				continue
+			}
+			if blocks == nil {
+				blocks = make([]history.ContentBlock, len(pruned[i].Content))
+				copy(blocks, pruned[i].Content)
+			}
+			blocks[j] = truncateBlock(block, blockLimit)
+			after := blocks[j].Tokens()
+			total -= before - after
+			sizes[i] -= before - after
+		}
+		if blocks != nil {
+			pruned[i].Content = blocks
+		}
+	}
+
+	// 第二步：从最早的一轮开始整轮丢弃。保留的上下文总是从用户消息开始，
+	// 部分 provider 不接受以助手或工具消息开头的对话
+	start := 0
+	for start < current && total > budget {
+		total -= sizes[start]
+		start++
+		for start < current && pruned[start].Role != "user" {
+			total -= sizes[start]
+			start++
+		}
+	}
+	if total > budget {
+		log.Warn("当前一轮对话超出上下文预算", "tokens", total, "budget", budget)
+	}
+	if start > 0 {
+		log.Debug("按 token 预算裁剪上下文",
+			"dropped", start, "kept", len(pruned)-start, "tokens", total, "budget", budget)
+	}
+
+	return removeOrphanToolBlocks(pruned[start:])
+}
+
+// currentTurnStart 返回当前一轮开始的位置，即最新一条用户消息的下标，没有用户消息时返回 0
+func currentTurnStart(messages []history.HistoryMessage) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return i
+		}
+	}
+	return 0
+}
+
+// truncateBlock 将文本或工具结果块截断到约 limit 个 token
+func truncateBlock(block history.ContentBlock, limit int) history.ContentBlock {
+	text := block.Text
+	if text == "" && block.Content != nil {
+		if data, err := json.Marshal(block.Content); err == nil {
+			text = string(data)
+		}
+	}
+
+	runes := []rune(text)
+	// 按比例估算需要保留的字符数，逐步缩短直到满足限制
+	keep := len(runes) * limit / (history.EstimateTokens(text) + 1)
+	for keep > 0 && history.EstimateTokens(string(runes[:keep])) > limit {
+		keep = keep * 9 / 10
+	}
+	truncated := string(runes[:keep]) + truncatedMarker
+
+	block.Text = truncated
+	if block.Type == "tool_result" {
+		// 部分 provider 直接发送 Content，需要同步替换
+		block.Content = []history.ContentBlock{{Type: "text", Text: truncated}}
+	}
+	return block
+}
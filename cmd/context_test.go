@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcphost/pkg/history"
+)
+
+func textMessage(role, text string) history.HistoryMessage {
+	return history.HistoryMessage{Role: role, Content: []history.ContentBlock{{Type: "text", Text: text}}}
+}
+
+func toolUseMessage(id string) history.HistoryMessage {
+	return history.HistoryMessage{Role: "assistant", Content: []history.ContentBlock{{
+		Type: "tool_use", ID: id, Name: "search", Input: json.RawMessage(`{}`),
+	}}}
+}
+
+func toolResultMessage(id, text string) history.HistoryMessage {
+	return history.HistoryMessage{Role: "tool", Content: []history.ContentBlock{{
+		Type: "tool_result", ToolUseID: id, Text: text,
+		Content: []history.ContentBlock{{Type: "text", Text: text}},
+	}}}
+}
+
+// describe 把消息概括为“角色:内容”，用于对比裁剪结果
+func describe(messages []history.HistoryMessage) string {
+	var parts []string
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			switch block.Type {
+			case "text":
+				parts = append(parts, msg.Role+":"+block.Text)
+			case "tool_use":
+				parts = append(parts, msg.Role+":调用 "+block.ID)
+			case "tool_result":
+				parts = append(parts, msg.Role+":结果 "+block.ToolUseID)
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestPruneByTokensKeepsCurrentTurn(t *testing.T) {
+	large := strings.Repeat("结果", 500)
+	tests := []struct {
+		name     string
+		messages []history.HistoryMessage
+		budget   int
+		want     string
+	}{
+		{
+			name: "截断工具结果后放得下时保留较早的一轮",
+			messages: []history.HistoryMessage{
+				textMessage("user", "旧问题"),
+				textMessage("assistant", "旧回答"),
+				textMessage("user", "新问题"),
+				toolUseMessage("t1"),
+				toolResultMessage("t1", large),
+			},
+			budget: 100,
+			want:   "user:旧问题 assistant:旧回答 user:新问题 assistant:调用 t1 tool:结果 t1",
+		},
+		{
+			// 截断后当前一轮仍超出预算：丢弃全部较早的对话，当前一轮完整保留
+			name: "预算小于当前一轮的工具结果",
+			messages: []history.HistoryMessage{
+				textMessage("user", "旧问题"),
+				toolUseMessage("old"),
+				toolResultMessage("old", "旧结果"),
+				textMessage("assistant", "旧回答"),
+				textMessage("user", "新问题"),
+				toolUseMessage("t1"),
+				toolResultMessage("t1", large),
+				toolUseMessage("t2"),
+				toolResultMessage("t2", large),
+			},
+			budget: 100,
+			want:   "user:新问题 assistant:调用 t1 tool:结果 t1 assistant:调用 t2 tool:结果 t2",
+		},
+		{
+			name: "没有超出预算时不裁剪",
+			messages: []history.HistoryMessage{
+				textMessage("user", "旧问题"),
+				textMessage("assistant", "旧回答"),
+				textMessage("user", "新问题"),
+			},
+			budget: 1000,
+			want:   "user:旧问题 assistant:旧回答 user:新问题",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pruneByTokens(tt.messages, tt.budget)
+			if describe(got) != tt.want {
+				t.Fatalf("裁剪结果 = %q，期望 %q", describe(got), tt.want)
+			}
+			// 当前一轮中的工具结果被截断，原始历史不受影响
+			for _, msg := range got {
+				for _, block := range msg.Content {
+					if block.Type == "tool_result" && block.Text == large {
+						t.Fatalf("工具结果 %s 没有被截断", block.ToolUseID)
+					}
+					if block.Type == "tool_result" && !strings.HasSuffix(block.Text, truncatedMarker) {
+						t.Fatalf("工具结果 %s 缺少截断标记", block.ToolUseID)
+					}
+				}
+			}
+			for _, msg := range tt.messages {
+				for _, block := range msg.Content {
+					if block.Type == "tool_result" && block.ToolUseID != "old" && block.Text != large {
+						t.Fatal("裁剪修改了原始历史")
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPruneByTokensDropsWholeTurns(t *testing.T) {
+	// 第一轮包含工具调用，丢弃时整轮丢弃，不会留下以助手或工具消息开头的上下文
+	first := []history.HistoryMessage{
+		textMessage("user", "一"),
+		toolUseMessage("a"),
+		toolResultMessage("a", "结果一"),
+		textMessage("assistant", "答一"),
+	}
+	second := []history.HistoryMessage{
+		textMessage("user", "二"),
+		textMessage("assistant", "答二"),
+	}
+	current := []history.HistoryMessage{textMessage("user", "三")}
+
+	var messages []history.HistoryMessage
+	messages = append(messages, first...)
+	messages = append(messages, second...)
+	messages = append(messages, current...)
+
+	tests := []struct {
+		name   string
+		budget int
+		want   string
+	}{
+		{"只丢弃第一轮", totalTokens(messages) - 1, "user:二 assistant:答二 user:三"},
+		{"放得下后两轮", totalTokens(second) + totalTokens(current), "user:二 assistant:答二 user:三"},
+		{"只放得下当前一轮", totalTokens(second) + totalTokens(current) - 1, "user:三"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describe(pruneByTokens(messages, tt.budget)); got != tt.want {
+				t.Fatalf("裁剪结果 = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mark3labs/mcphost/pkg/ingest"
+	"github.com/mark3labs/mcphost/pkg/rag"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ingestChunkSize    int    // 分块的最大字符数
+	ingestChunkOverlap int    // 相邻分块重叠的字符数
+	ingestCollection   string // 写入的集合
+	ingestEmbedding    string // 覆盖配置中的嵌入模型
+)
+
+// ingestCmd 把本地文件切分后写入 local 知识库
+var ingestCmd = &cobra.Command{
+	Use:   "ingest <path|glob>...",
+	Short: "将文件切分后写入本地知识库",
+	Long: `读取文件或目录，按类型切分后生成向量，写入 local 后端的知识库。
+
+支持的文件类型：
+  Markdown（.md）     按标题切分，分块带上所属章节的标题路径
+  纯文本（.txt）      按段落、句子切分
+  问答（.json/.jsonl）每条问答一个分块，对问题生成向量，命中时返回答案
+  HTML（.html）       提取正文后按标题切分
+  代码（.go/.py 等）  按行切分，优先在空行处断开
+
+目录会被递归遍历，只读取支持的文件。同一文件再次入库时，内容未变的分块直接保留，
+已不存在的旧分块被删除；与其他文档内容完全相同的分块会被跳过。
+
+示例：
+  mcphost ingest docs/
+  mcphost ingest "notes/*.md" --chunk-size 500 --chunk-overlap 50
+  mcphost ingest faq.jsonl --collection faq`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return runIngest(context.Background(), args)
+	},
+}
+
+func init() {
+	ingestCmd.Flags().IntVar(&ingestChunkSize, "chunk-size", ingest.DefaultChunkSize, "每个分块的最大字符数")
+	ingestCmd.Flags().IntVar(&ingestChunkOverlap, "chunk-overlap", ingest.DefaultChunkOverlap, "相邻分块重叠的字符数")
+	ingestCmd.Flags().StringVar(&ingestCollection, "collection", "", "写入的集合（默认使用配置中的集合）")
+	ingestCmd.Flags().StringVar(&ingestEmbedding, "embedding", "", "嵌入模型，覆盖配置中的 rag.embedding")
+	rootCmd.AddCommand(ingestCmd)
+}
+
+func runIngest(ctx context.Context, args []string) error {
+	configureLogging()
+
+	files, err := expandIngestPaths(args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("没有找到支持的文件")
+	}
+
+	backend, err := openIngestBackend(ctx)
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+
+	opts := ingest.Options{ChunkSize: ingestChunkSize, ChunkOverlap: ingestChunkOverlap}
+	var total rag.IngestStats
+	failed := 0
+	for _, file := range files {
+		stats, err := backend.IngestFile(ctx, ingestCollection, file, opts)
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "✗ %s: %v\n", file, err)
+			continue
+		}
+		fmt.Printf("✓ %s: %s\n", file, stats)
+		total.Added += stats.Added
+		total.Unchanged += stats.Unchanged
+		total.Removed += stats.Removed
+		total.Duplicates += stats.Duplicates
+	}
+
+	fmt.Printf("\n共处理 %d 个文件：%s\n", len(files)-failed, total)
+	if failed > 0 {
+		return fmt.Errorf("%d 个文件入库失败", failed)
+	}
+	return nil
+}
+
+// openIngestBackend 按配置打开 local 知识库；未配置 rag 时使用 local 后端的默认设置
+func openIngestBackend(ctx context.Context) (*rag.LocalBackend, error) {
+	mcpConfig, err := loadMCPConfig()
+	if err != nil {
+		return nil, fmt.Errorf("加载 MCP 配置失败: %v", err)
+	}
+
+	cfg := rag.Config{Backend: rag.BackendLocal}
+	if mcpConfig.RAG != nil {
+		cfg = *mcpConfig.RAG
+	}
+	if cfg.Backend != rag.BackendLocal {
+		return nil, errors.New("ingest 命令只支持 local 后端；gRPC 后端请使用 rag__ingest 工具由服务端读取文件")
+	}
+	if ingestEmbedding != "" {
+		cfg.Embedding = ingestEmbedding
+	}
+	if cfg, err = resolveRAGConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	embedder, err := createEmbedder(ctx, cfg.Embedding)
+	if err != nil {
+		return nil, err
+	}
+	return rag.OpenLocal(cfg, embedder)
+}
+
+// expandIngestPaths 展开通配符并递归遍历目录，返回去重排序后的文件列表。
+// 直接指定的文件即使类型不受支持也会保留，以便报告错误；目录中只收集支持的文件。
+func expandIngestPaths(args []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, arg := range args {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("无效的路径模式 %q: %w", arg, err)
+		}
+		if matches == nil {
+			if _, err := os.Stat(arg); err != nil {
+				return nil, fmt.Errorf("路径不存在: %s", arg)
+			}
+			matches = []string{arg}
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				add(match)
+				continue
+			}
+			err = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.IsDir() {
+					// 跳过 .git 等隐藏目录
+					if path != match && len(d.Name()) > 1 && d.Name()[0] == '.' {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if ingest.Supported(path) {
+					add(path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("遍历目录 %s 失败: %w", match, err)
+			}
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// 服务器的工具、提示模板或资源列表变化时发送的通知
+const (
+	methodToolsListChanged     = "notifications/tools/list_changed"
+	methodPromptsListChanged   = "notifications/prompts/list_changed"
+	methodResourcesListChanged = "notifications/resources/list_changed"
+)
+
+// watchListChanges 处理服务器运行中发出的 list_changed 通知：工具列表变化时重新获取并更新
+// toolRegistry，下一次调用模型时即使用新的工具；提示模板列表变化时更新 /server:prompt 命令。
+// 资源列表由 /resources 实时获取，只提示用户。
+func watchListChanges(mcpClients map[string]mcpclient.MCPClient) {
+	for serverName, client := range mcpClients {
+		refresher := &listRefresher{
+			server:  serverName,
+			client:  client,
+			pending: make(map[string]bool),
+		}
+		client.OnNotification(refresher.handle)
+	}
+}
+
+// listRefresher 按收到的通知重新获取一个服务器的列表。通知在客户端的读取 goroutine 中处理，
+// 不能在其中等待服务器响应，因此在后台获取；获取期间收到的同类通知合并为一次。
+type listRefresher struct {
+	server string
+	client mcpclient.MCPClient
+
+	mu      sync.Mutex
+	pending map[string]bool // 等待处理的通知方法
+	running bool
+}
+
+// handle 记录需要刷新的列表，并在没有刷新任务时启动一个
+func (r *listRefresher) handle(notification mcp.JSONRPCNotification) {
+	switch notification.Method {
+	case methodToolsListChanged, methodPromptsListChanged:
+	case methodResourcesListChanged:
+		log.Info("MCP 服务器的资源列表已更新，可用 /resources 查看", "server", r.server)
+		return
+	default:
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[notification.Method] = true
+	if !r.running {
+		r.running = true
+		go r.run()
+	}
+}
+
+// run 处理等待中的通知，直到没有新的通知
+func (r *listRefresher) run() {
+	for {
+		r.mu.Lock()
+		pending := r.pending
+		if len(pending) == 0 {
+			r.running = false
+			r.mu.Unlock()
+			return
+		}
+		r.pending = make(map[string]bool)
+		r.mu.Unlock()
+
+		if pending[methodToolsListChanged] {
+			r.refreshTools()
+		}
+		if pending[methodPromptsListChanged] {
+			r.refreshPrompts()
+		}
+	}
+}
+
+// refreshTools 重新获取工具列表并更新 toolRegistry，记录新增和移除的工具
+func (r *listRefresher) refreshTools() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	result, err := r.client.ListTools(ctx, mcp.ListToolsRequest{})
+	cancel()
+	if err != nil {
+		log.Error("更新工具列表失败", "server", r.server, "error", err)
+		return
+	}
+
+	old := make(map[string]bool)
+	toolRegistry.RLock()
+	for _, tool := range toolRegistry.m[r.server] {
+		old[tool.Name] = true
+	}
+	toolRegistry.RUnlock()
+
+	tools := mcpToolsToAnthropicTools(r.server, result.Tools)
+	setServerTools(r.server, tools)
+
+	var added, removed []string
+	for _, tool := range tools {
+		if old[tool.Name] {
+			delete(old, tool.Name)
+		} else {
+			added = append(added, tool.Name)
+		}
+	}
+	for name := range old {
+		removed = append(removed, name)
+	}
+	sort.Strings(removed)
+	keyvals := []interface{}{"server", r.server, "count", len(tools)}
+	if len(added) > 0 {
+		keyvals = append(keyvals, "added", strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		keyvals = append(keyvals, "removed", strings.Join(removed, ", "))
+	}
+	log.Info("MCP 服务器的工具列表已更新，下一次请求将使用新的工具", keyvals...)
+}
+
+// refreshPrompts 重新获取提示模板列表
+func (r *listRefresher) refreshPrompts() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	result, err := r.client.ListPrompts(ctx, mcp.ListPromptsRequest{})
+	cancel()
+	if err != nil {
+		log.Error("更新提示模板列表失败", "server", r.server, "error", err)
+		return
+	}
+	setServerPrompts(r.server, result.Prompts)
+	log.Info("MCP 服务器的提示模板列表已更新", "server", r.server, "count", len(result.Prompts))
+}
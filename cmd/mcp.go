@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/huh/spinner"
@@ -18,14 +21,22 @@ import (
 	mcpclient "github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcphost/pkg/history"
+	"github.com/mark3labs/mcphost/pkg/inprocess"
 	"github.com/mark3labs/mcphost/pkg/llm"
+	"github.com/mark3labs/mcphost/pkg/mcpconn"
+	"github.com/mark3labs/mcphost/pkg/rag"
+	"github.com/mark3labs/mcphost/pkg/schedule"
 )
 
 const (
 	transportStdio = "stdio"
 	transportSSE   = "sse"
+	transportHTTP  = "http" // Streamable HTTP
 )
 
+// toolNameSeparator 分隔命名空间工具名中的服务器名和原始工具名
+const toolNameSeparator = "__"
+
 var (
 	// Tokyo Night 主题配色（使用 ANSI 色码）
 	tokyoPurple = lipgloss.Color("99")  // 紫色 #9d7cd8
@@ -73,6 +84,10 @@ var (
 // MCPConfig 定义了 MCP 服务器配置结构体
 type MCPConfig struct {
 	MCPServers map[string]ServerConfigWrapper `json:"mcpServers"`
+	RAG        *rag.Config                    `json:"rag,omitempty"`       // 内置 RAG 工具配置，未配置时不启用
+	Tasks      *TaskConfig                    `json:"tasks,omitempty"`     // 后台任务的执行配置
+	Schedules  map[string]schedule.Config     `json:"schedules,omitempty"` // 定时计划，按名称索引，由任务执行进程运行
+	Sampling   *SamplingConfig                `json:"sampling,omitempty"`  // MCP 服务器请求使用模型时的处理方式
 }
 
 // ServerConfig 接口，表示服务器配置的统一接口
@@ -82,9 +97,10 @@ type ServerConfig interface {
 
 // STDIOServerConfig 表示本地命令行执行的服务器配置
 type STDIOServerConfig struct {
-	Command string            `json:"command"`       // 执行的命令
-	Args    []string          `json:"args"`          // 命令参数
-	Env     map[string]string `json:"env,omitempty"` // 可选的环境变量
+	Type    string            `json:"type,omitempty"` // 可选，"stdio"
+	Command string            `json:"command"`        // 执行的命令
+	Args    []string          `json:"args"`           // 命令参数
+	Env     map[string]string `json:"env,omitempty"`  // 可选的环境变量
 }
 
 // GetType 返回 STDIOServerConfig 的类型标识
@@ -94,6 +110,7 @@ func (s STDIOServerConfig) GetType() string {
 
 // SSEServerConfig 表示 SSE 协议的远程服务器配置
 type SSEServerConfig struct {
+	Type    string   `json:"type,omitempty"`    // 可选，"sse"
 	Url     string   `json:"url"`               // SSE 服务器的 URL
 	Headers []string `json:"headers,omitempty"` // 可选的请求头
 }
@@ -103,41 +120,83 @@ func (s SSEServerConfig) GetType() string {
 	return transportSSE
 }
 
-// ServerConfigWrapper 是一个包装类型，用于支持动态解析两种类型的配置
+// HTTPServerConfig 表示 Streamable HTTP 协议的远程服务器配置
+type HTTPServerConfig struct {
+	Type    string   `json:"type"`              // "http"，省略时仅在 url 的路径以 /mcp 结尾时推断为 HTTP
+	Url     string   `json:"url"`               // MCP 端点的 URL，例如 https://example.com/mcp
+	Headers []string `json:"headers,omitempty"` // 可选的请求头，格式同 SSE
+}
+
+// GetType 返回 HTTPServerConfig 的类型标识
+func (s HTTPServerConfig) GetType() string {
+	return transportHTTP
+}
+
+// ServerConfigWrapper 是一个包装类型，用于支持动态解析不同类型的配置
 type ServerConfigWrapper struct {
-	Config ServerConfig // 实际存储的是接口类型，可以是 SSE 或 STDIO
+	Config ServerConfig // 实际存储的是接口类型，可以是 STDIO、SSE 或 HTTP
 }
 
-// UnmarshalJSON 自定义反序列化逻辑，根据字段判断是哪个配置类型
+// UnmarshalJSON 自定义反序列化逻辑：优先使用 type 字段，没有 type 时由 inferTransport 推断
 func (w *ServerConfigWrapper) UnmarshalJSON(data []byte) error {
 	var typeField struct {
-		Url string `json:"url"`
+		Type string `json:"type"`
+		Url  string `json:"url"`
 	}
 
-	// 先尝试解析是否存在 url 字段
+	// 先解析 type 和 url 字段
 	if err := json.Unmarshal(data, &typeField); err != nil {
 		return err
 	}
 
-	if typeField.Url != "" {
-		// 存在 url 字段 -> SSE 类型
+	serverType := typeField.Type
+	if serverType == "" {
+		serverType = inferTransport(typeField.Url)
+	}
+
+	switch serverType {
+	case transportStdio:
+		var stdio STDIOServerConfig
+		if err := json.Unmarshal(data, &stdio); err != nil {
+			return err
+		}
+		w.Config = stdio
+	case transportSSE:
 		var sse SSEServerConfig
 		if err := json.Unmarshal(data, &sse); err != nil {
 			return err
 		}
 		w.Config = sse
-	} else {
-		// 否则为 STDIO 类型
-		var stdio STDIOServerConfig
-		if err := json.Unmarshal(data, &stdio); err != nil {
+	case transportHTTP:
+		var httpConfig HTTPServerConfig
+		if err := json.Unmarshal(data, &httpConfig); err != nil {
 			return err
 		}
-		w.Config = stdio
+		// 写回配置时带上推断出的类型，避免之后按其他规则推断
+		httpConfig.Type = transportHTTP
+		w.Config = httpConfig
+	default:
+		return fmt.Errorf("不支持的 MCP 服务器类型: %q（可选 stdio、sse、http）", typeField.Type)
+	}
+	if serverType != transportStdio && typeField.Url == "" {
+		return fmt.Errorf("%s 类型的 MCP 服务器需要 url", serverType)
 	}
 
 	return nil
 }
 
+// inferTransport 推断没有 type 字段的服务器的传输方式：没有 url 为 STDIO；
+// url 的路径以 /mcp 结尾时为 Streamable HTTP（规范建议的端点路径），其余与旧格式一样视为 SSE
+func inferTransport(rawURL string) string {
+	if rawURL == "" {
+		return transportStdio
+	}
+	if u, err := url.Parse(rawURL); err == nil && path.Base(strings.TrimSuffix(u.Path, "/")) == "mcp" {
+		return transportHTTP
+	}
+	return transportSSE
+}
+
 // MarshalJSON 将包装的 Config 接口序列化为 JSON
 func (w ServerConfigWrapper) MarshalJSON() ([]byte, error) {
 	return json.Marshal(w.Config)
@@ -152,7 +211,7 @@ func mcpToolsToAnthropicTools(
 
 	for i, tool := range mcpTools {
 		// 工具名添加命名空间前缀，避免冲突
-		namespacedName := fmt.Sprintf("%s__%s", serverName, tool.Name)
+		namespacedName := namespacedToolName(serverName, tool.Name)
 
 		// 构造新的工具对象
 		anthropicTools[i] = llm.Tool{
@@ -169,6 +228,20 @@ func mcpToolsToAnthropicTools(
 	return anthropicTools
 }
 
+// namespacedToolName 返回带服务器命名空间前缀的工具名，格式为 server__tool
+func namespacedToolName(serverName, toolName string) string {
+	return serverName + toolNameSeparator + toolName
+}
+
+// splitToolName 将 server__tool 格式的工具名拆分为服务器名和原始工具名
+func splitToolName(name string) (serverName, toolName string, ok bool) {
+	parts := strings.SplitN(name, toolNameSeparator, 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
 // 加载 MCP 配置文件（优先使用 configFile，否则默认从 ~/.mcp.json 加载）
 func loadMCPConfig() (*MCPConfig, error) {
 	var configPath string
@@ -219,83 +292,269 @@ func loadMCPConfig() (*MCPConfig, error) {
 	return &config, nil
 }
 
-// 根据配置创建所有 MCP 客户端（支持 SSE 和 STDIO 类型）
+// 根据配置创建所有 MCP 客户端（支持 SSE、Streamable HTTP 和 STDIO 类型），
+// 使用进程共用的 sampler 和 ragReranker。
 func createMCPClients(config *MCPConfig) (map[string]mcpclient.MCPClient, error) {
+	return createMCPClientsWith(config, sampler, ragReranker)
+}
+
+// createMCPClientsWith 根据配置创建所有 MCP 客户端，服务器的 sampling 请求交给 sampling 处理，
+// 内置 RAG 工具用 reranker 重排。每个客户端由 mcpconn.Supervisor 监视，连接断开或服务器崩溃后自动重新连接。
+func createMCPClientsWith(
+	config *MCPConfig,
+	sampling *samplingHandler,
+	reranker rag.Reranker,
+) (map[string]mcpclient.MCPClient, error) {
+	if err := config.Sampling.Validate(); err != nil {
+		return nil, err
+	}
 	clients := make(map[string]mcpclient.MCPClient)
 
 	for name, server := range config.MCPServers {
-		var client mcpclient.MCPClient
-		var err error
-
-		if server.Config.GetType() == transportSSE {
-			// 处理 SSE 类型的服务
-			sseConfig := server.Config.(SSEServerConfig)
-			options := []mcpclient.ClientOption{}
-
-			if sseConfig.Headers != nil {
-				headers := make(map[string]string)
-				// 解析 headers（例如 "Authorization: Bearer xxx"）
-				for _, header := range sseConfig.Headers {
-					parts := strings.SplitN(header, ":", 2)
-					if len(parts) == 2 {
-						key := strings.TrimSpace(parts[0])
-						value := strings.TrimSpace(parts[1])
-						headers[key] = value
-					}
-				}
-				options = append(options, mcpclient.WithHeaders(headers))
-			}
-
-			// 创建 SSE 客户端并启动
-			client, err = mcpclient.NewSSEMCPClient(sseConfig.Url, options...)
-			if err == nil {
-				err = client.(*mcpclient.SSEMCPClient).Start(context.Background())
-			}
-		} else {
-			// 处理 STDIO 类型的服务（本地子进程）
-			stdioConfig := server.Config.(STDIOServerConfig)
-			var env []string
-			for k, v := range stdioConfig.Env {
-				env = append(env, fmt.Sprintf("%s=%s", k, v))
-			}
-			client, err = mcpclient.NewStdioMCPClient(stdioConfig.Command, env, stdioConfig.Args...)
-		}
-
+		log.Info("正在初始化服务...", "name", name)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		client, initResult, err := connectMCPServer(ctx, name, server, sampling, config.Sampling)
+		cancel()
 		if err != nil {
 			// 出现错误则关闭所有已创建的客户端并返回
 			for _, c := range clients {
 				c.Close()
 			}
-			return nil, fmt.Errorf("创建 MCP 客户端失败（%s）: %w", name, err)
+			return nil, err
 		}
+		setServerCapabilities(name, initResult.Capabilities)
 
-		// 初始化客户端
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+		// 加入返回列表
+		clients[name] = newServerSupervisor(name, server, sampling, config.Sampling, client)
+	}
 
-		log.Info("正在初始化服务...", "name", name)
-		initRequest := mcp.InitializeRequest{}
-		initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
-		initRequest.Params.ClientInfo = mcp.Implementation{
-			Name:    "mcphost",
-			Version: "0.1.0",
+	// 内置 RAG 工具以进程内 MCP 服务器的形式加入，工具名为 rag__search 和 rag__ingest
+	if config.RAG != nil {
+		if _, exists := clients[rag.ServerName]; exists {
+			closeMCPClients(clients)
+			return nil, fmt.Errorf("MCP 服务器名称 %q 与内置 RAG 工具冲突", rag.ServerName)
+		}
+		client, err := createRAGClient(*config.RAG, reranker)
+		if err != nil {
+			closeMCPClients(clients)
+			return nil, err
+		}
+		clients[rag.ServerName] = client
+	}
+
+	return clients, nil
+}
+
+// connectMCPServer 创建、启动并初始化一个 MCP 客户端
+func connectMCPServer(
+	ctx context.Context,
+	name string,
+	server ServerConfigWrapper,
+	sampling *samplingHandler,
+	samplingConfig *SamplingConfig,
+) (mcpclient.MCPClient, *mcp.InitializeResult, error) {
+	var client mcpclient.MCPClient
+	var err error
+	// 能处理服务器请求的客户端才声明 sampling 能力
+	samplingEnabled := false
+
+	switch serverConfig := server.Config.(type) {
+	case SSEServerConfig:
+		// 处理 SSE 类型的服务
+		options := []mcpclient.ClientOption{}
+		if serverConfig.Headers != nil {
+			options = append(options, mcpclient.WithHeaders(parseHeaders(serverConfig.Headers)))
 		}
-		initRequest.Params.Capabilities = mcp.ClientCapabilities{}
 
-		_, err = client.Initialize(ctx, initRequest)
+		// 创建 SSE 客户端并启动
+		client, err = mcpclient.NewSSEMCPClient(serverConfig.Url, options...)
+		if err == nil {
+			err = client.(*mcpclient.SSEMCPClient).Start(context.Background())
+		}
+	case HTTPServerConfig:
+		// 处理 Streamable HTTP 类型的服务
+		transport := mcpconn.NewStreamableHTTPTransport(serverConfig.Url,
+			mcpconn.WithHeaders(parseHeaders(serverConfig.Headers)))
+		conn := mcpconn.NewClient(transport)
+		if sampling.Enabled() {
+			conn.OnRequest(methodCreateMessage, sampling.handler(name, samplingConfig))
+			samplingEnabled = true
+		}
+		client = conn
+		err = conn.Start(context.Background())
+	case STDIOServerConfig:
+		// 处理 STDIO 类型的服务（本地子进程）
+		var env []string
+		for k, v := range serverConfig.Env {
+			env = append(env, fmt.Sprintf("%s=%s", k, v))
+		}
+		conn := mcpconn.NewClient(mcpconn.NewStdioTransport(serverConfig.Command, env, serverConfig.Args...))
+		if sampling.Enabled() {
+			conn.OnRequest(methodCreateMessage, sampling.handler(name, samplingConfig))
+			samplingEnabled = true
+		}
+		client = conn
+		err = conn.Start(context.Background())
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建 MCP 客户端失败（%s）: %w", name, err)
+	}
+
+	// 初始化客户端
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{
+		Name:    "mcphost",
+		Version: "0.1.0",
+	}
+	initRequest.Params.Capabilities = mcp.ClientCapabilities{}
+	if samplingEnabled {
+		initRequest.Params.Capabilities.Sampling = &struct{}{}
+	}
+
+	initResult, err := client.Initialize(ctx, initRequest)
+	if err != nil {
+		client.Close()
+		return nil, nil, fmt.Errorf("初始化 MCP 客户端失败（%s）: %w", name, err)
+	}
+	return client, initResult, nil
+}
+
+// newServerSupervisor 监视已初始化的客户端。重新连接后刷新服务器的能力、工具和提示模板，
+// 并重新订阅之前订阅的资源。
+func newServerSupervisor(
+	name string,
+	server ServerConfigWrapper,
+	sampling *samplingHandler,
+	samplingConfig *SamplingConfig,
+	client mcpclient.MCPClient,
+) *mcpconn.Supervisor {
+	var supervisor *mcpconn.Supervisor
+	connect := func(ctx context.Context) (mcpclient.MCPClient, *mcp.InitializeResult, error) {
+		return connectMCPServer(ctx, name, server, sampling, samplingConfig)
+	}
+	supervisor = mcpconn.NewSupervisor(name, client, connect, mcpconn.SupervisorOptions{
+		OnReconnect: func(result *mcp.InitializeResult) {
+			setServerCapabilities(name, result.Capabilities)
+			ctx := context.Background()
+			clients := map[string]mcpclient.MCPClient{name: supervisor}
+			loadTools(ctx, clients)
+			loadPrompts(ctx, clients)
+			resourceSubscriptions.resubscribe(ctx, name, supervisor)
+		},
+	})
+	return supervisor
+}
+
+// parseHeaders 解析 "Key: Value" 形式的请求头配置（例如 "Authorization: Bearer xxx"），忽略格式不正确的项
+func parseHeaders(lines []string) map[string]string {
+	headers := make(map[string]string)
+	for _, header := range lines {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) == 2 {
+			key := strings.TrimSpace(parts[0])
+			value := strings.TrimSpace(parts[1])
+			headers[key] = value
+		}
+	}
+	return headers
+}
+
+// resolveRAGConfig 补全 RAG 配置中的默认值：local 后端未指定目录时使用数据目录下的 rag
+func resolveRAGConfig(cfg rag.Config) (rag.Config, error) {
+	if cfg.Backend == rag.BackendLocal && cfg.Path == "" {
+		dir, err := getDataDir()
 		if err != nil {
-			client.Close()
-			for _, c := range clients {
-				c.Close()
+			return cfg, err
+		}
+		cfg.Path = filepath.Join(dir, "rag")
+	}
+	return cfg, nil
+}
+
+// sharedRAGBackend 让内置 RAG 工具和 --rag 自动检索共用同一个后端实例，
+// 避免 local 后端的同一个存储文件在进程内被打开两次而各自持有不一致的数据
+var sharedRAGBackend struct {
+	sync.Mutex
+	backend rag.Backend
+	refs    int
+}
+
+// openRAGBackend 按配置打开知识库后端，返回的 close 函数在最后一个使用者关闭时才真正关闭后端
+func openRAGBackend(cfg rag.Config) (rag.Backend, func() error, error) {
+	shared := &sharedRAGBackend
+	shared.Lock()
+	defer shared.Unlock()
+
+	if shared.backend == nil {
+		cfg, err := resolveRAGConfig(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		var embedder llm.Embedder
+		if cfg.Backend == rag.BackendLocal {
+			if embedder, err = createEmbedder(context.Background(), cfg.Embedding); err != nil {
+				return nil, nil, err
 			}
-			return nil, fmt.Errorf("初始化 MCP 客户端失败（%s）: %w", name, err)
 		}
+		backend, err := rag.Open(cfg, embedder)
+		if err != nil {
+			return nil, nil, err
+		}
+		shared.backend = backend
+	}
+	shared.refs++
 
-		// 加入返回列表
-		clients[name] = client
+	var once sync.Once
+	closeFn := func() error {
+		var err error
+		once.Do(func() {
+			shared.Lock()
+			defer shared.Unlock()
+			shared.refs--
+			if shared.refs == 0 {
+				err = shared.backend.Close()
+				shared.backend = nil
+			}
+		})
+		return err
 	}
+	return shared.backend, closeFn, nil
+}
 
-	return clients, nil
+// createRAGClient 打开配置的知识库后端，并返回提供内置工具的进程内 MCP 客户端
+func createRAGClient(cfg rag.Config, reranker rag.Reranker) (mcpclient.MCPClient, error) {
+	backend, closeBackend, err := openRAGBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	searcher, err := rag.NewSearcher(backend, cfg, reranker)
+	if err != nil {
+		closeBackend()
+		return nil, err
+	}
+	client, err := inprocess.NewClient(rag.NewServer(searcher), inprocess.WithOnClose(closeBackend))
+	if err != nil {
+		closeBackend()
+		return nil, err
+	}
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{
+		Name:    "mcphost",
+		Version: "0.1.0",
+	}
+	initResult, err := client.Initialize(context.Background(), initRequest)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("初始化内置 RAG 工具失败: %w", err)
+	}
+	setServerCapabilities(rag.ServerName, initResult.Capabilities)
+
+	cfg, _ = resolveRAGConfig(cfg)
+	log.Info("已启用内置 RAG 工具", "backend", cfg.Describe())
+	return client, nil
 }
 
 // 处理用户输入的命令（以 "/" 开头）
@@ -314,6 +573,9 @@ func handleSlashCommand(
 	case "/tools":
 		handleToolsCommand(mcpClients)
 		return true, nil
+	case "/resources":
+		handleResourcesCommand(mcpClients)
+		return true, nil
 	case "/help":
 		handleHelpCommand()
 		return true, nil
@@ -321,7 +583,7 @@ func handleSlashCommand(
 		handleHistoryCommand(messages.([]history.HistoryMessage))
 		return true, nil
 	case "/servers":
-		handleServersCommand(mcpConfig)
+		handleServersCommand(mcpConfig, mcpClients)
 		return true, nil
 	case "/quit":
 		fmt.Println("\nGoodbye!")
@@ -347,11 +609,26 @@ func handleHelpCommand() {
 	markdown.WriteString("你可以使用以下命令：\n\n")
 	markdown.WriteString("- **/help**: 显示此帮助信息\n")
 	markdown.WriteString("- **/tools**: 列出所有可用工具\n")
-	markdown.WriteString("- **/servers**: 列出已配置的 MCP 服务器\n")
+	markdown.WriteString("- **/resources**: 列出 MCP 服务器提供的资源；在提问中用 @server:uri 引用资源内容\n")
+	markdown.WriteString("- **/servers**: 列出已配置的 MCP 服务器及其连接状态\n")
 	markdown.WriteString("- **/history**: 显示会话历史记录\n")
+	markdown.WriteString("- **/compact**: 用模型生成的摘要压缩较早的对话历史\n")
+	markdown.WriteString("- **/sessions**: 列出已保存的会话\n")
+	markdown.WriteString("- **/resume [id]**: 恢复指定会话（省略 id 时恢复最近的会话）\n")
+	markdown.WriteString("- **/bg <prompt>**: 把提问交给后台执行，完成后在这里提示\n")
+	markdown.WriteString("- **/tasks**: 列出后台任务\n")
+	markdown.WriteString("- **/task <id> [pull|logs|cancel]**: 查看任务结果；pull 把结果加入当前对话，logs 显示执行过程，cancel 取消任务\n")
 	markdown.WriteString("- **/quit**: 退出程序\n")
 	markdown.WriteString("\n你也可以随时按下 Ctrl+C 退出程序。\n")
 
+	if prompts := promptCommandHelp(); len(prompts) > 0 {
+		markdown.WriteString("\n## MCP 提示模板\n\n")
+		markdown.WriteString("以下命令由 MCP 服务器提供，缺少的参数会通过表单填写：\n\n")
+		for _, line := range prompts {
+			markdown.WriteString(line + "\n")
+		}
+	}
+
 	markdown.WriteString("\n## 支持的模型\n\n")
 	markdown.WriteString("通过 --model 或 -m 参数指定模型，例如：\n\n")
 	markdown.WriteString("- **Anthropic Claude**: `anthropic:claude-3-5-sonnet-latest`\n")
@@ -372,7 +649,7 @@ func handleHelpCommand() {
 }
 
 // 处理 `servers` 命令，展示所有配置的服务信息
-func handleServersCommand(config *MCPConfig) {
+func handleServersCommand(config *MCPConfig, mcpClients map[string]mcpclient.MCPClient) {
 	// 更新渲染器，如果失败则打印错误信息并退出
 	if err := updateRenderer(); err != nil {
 		fmt.Printf(
@@ -384,43 +661,45 @@ func handleServersCommand(config *MCPConfig) {
 
 	var markdown strings.Builder // 构造 markdown 文本
 	action := func() {
-		if len(config.MCPServers) == 0 {
+		if config.RAG != nil {
+			// 内置 RAG 工具
+			ragConfig, _ := resolveRAGConfig(*config.RAG)
+			markdown.WriteString(fmt.Sprintf("# %s\n\n", rag.ServerName))
+			markdown.WriteString("*Built-in RAG*\n")
+			markdown.WriteString(fmt.Sprintf("`%s`\n\n", ragConfig.Describe()))
+		}
+		if len(config.MCPServers) == 0 && config.RAG == nil {
 			markdown.WriteString("No servers configured.\n")
 		} else {
 			for name, server := range config.MCPServers {
 				markdown.WriteString(fmt.Sprintf("# %s\n\n", name))
 
-				if server.Config.GetType() == transportSSE {
+				switch serverConfig := server.Config.(type) {
+				case SSEServerConfig:
 					// SSE 类型服务器配置
-					sseConfig := server.Config.(SSEServerConfig)
-					markdown.WriteString("*Url*\n")
-					markdown.WriteString(fmt.Sprintf("`%s`\n\n", sseConfig.Url))
-					markdown.WriteString("*headers*\n")
-					if sseConfig.Headers != nil {
-						for _, header := range sseConfig.Headers {
-							// 仅显示 header 的键名，值隐藏
-							parts := strings.SplitN(header, ":", 2)
-							if len(parts) == 2 {
-								key := strings.TrimSpace(parts[0])
-								markdown.WriteString("`" + key + ": [REDACTED]`\n")
-							}
-						}
-					} else {
-						markdown.WriteString("*None*\n")
-					}
-				} else {
+					writeRemoteServerMarkdown(&markdown, transportSSE, serverConfig.Url, serverConfig.Headers)
+				case HTTPServerConfig:
+					// Streamable HTTP 类型服务器配置
+					writeRemoteServerMarkdown(&markdown, transportHTTP, serverConfig.Url, serverConfig.Headers)
+				case STDIOServerConfig:
 					// STDIO 类型服务器配置
-					stdioConfig := server.Config.(STDIOServerConfig)
 					markdown.WriteString("*Command*\n")
-					markdown.WriteString(fmt.Sprintf("`%s`\n\n", stdioConfig.Command))
+					markdown.WriteString(fmt.Sprintf("`%s`\n\n", serverConfig.Command))
 
 					markdown.WriteString("*Arguments*\n")
-					if len(stdioConfig.Args) > 0 {
-						markdown.WriteString(fmt.Sprintf("`%s`\n", strings.Join(stdioConfig.Args, " ")))
+					if len(serverConfig.Args) > 0 {
+						markdown.WriteString(fmt.Sprintf("`%s`\n", strings.Join(serverConfig.Args, " ")))
 					} else {
 						markdown.WriteString("*None*\n")
 					}
 				}
+				writeServerStatusMarkdown(&markdown, mcpClients[name])
+				// 服务器通过 sampling 使用的模型用量
+				if usage, ok := sampler.Usage(name); ok {
+					markdown.WriteString("\n*Sampling*\n")
+					markdown.WriteString(fmt.Sprintf("`%d 次请求，输入 %d / 输出 %d tokens`\n",
+						usage.Requests, usage.InputTokens, usage.OutputTokens))
+				}
 				markdown.WriteString("\n") // 每个服务器之间加空行
 			}
 		}
@@ -451,6 +730,46 @@ func handleServersCommand(config *MCPConfig) {
 	fmt.Print("\n" + containerStyle.Render(rendered) + "\n")
 }
 
+// writeServerStatusMarkdown 输出服务器的连接状态（up、degraded、restarting）、最近的错误和重新连接次数
+func writeServerStatusMarkdown(markdown *strings.Builder, client mcpclient.MCPClient) {
+	markdown.WriteString("\n*Status*\n")
+	supervisor, ok := client.(*mcpconn.Supervisor)
+	if !ok {
+		markdown.WriteString("`未连接`\n")
+		return
+	}
+	status := supervisor.Status()
+	line := fmt.Sprintf("%s（%s 起）", status.State, status.Since.Format("15:04:05"))
+	if status.Restarts > 0 {
+		line += fmt.Sprintf("，已重新连接 %d 次", status.Restarts)
+	}
+	markdown.WriteString(fmt.Sprintf("`%s`\n", line))
+	if status.LastError != nil {
+		markdown.WriteString(fmt.Sprintf("`%s`\n", status.LastError))
+	}
+}
+
+// writeRemoteServerMarkdown 输出远程服务器的类型、地址和请求头，请求头只显示键名
+func writeRemoteServerMarkdown(markdown *strings.Builder, serverType, url string, headers []string) {
+	markdown.WriteString("*Type*\n")
+	markdown.WriteString(fmt.Sprintf("`%s`\n\n", serverType))
+	markdown.WriteString("*Url*\n")
+	markdown.WriteString(fmt.Sprintf("`%s`\n\n", url))
+	markdown.WriteString("*headers*\n")
+	if headers == nil {
+		markdown.WriteString("*None*\n")
+		return
+	}
+	for _, header := range headers {
+		// 仅显示 header 的键名，值隐藏
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) == 2 {
+			key := strings.TrimSpace(parts[0])
+			markdown.WriteString("`" + key + ": [REDACTED]`\n")
+		}
+	}
+}
+
 // 处理 `tools` 命令，展示所有服务器支持的工具
 func handleToolsCommand(mcpClients map[string]mcpclient.MCPClient) {
 	width := getTerminalWidth() // 获取终端宽度
@@ -592,6 +911,23 @@ func displayMessageHistory(messages []history.HistoryMessage) {
 			case "text": // 普通文本消息
 				markdown.WriteString("### Text\n")        // 子标题
 				markdown.WriteString(block.Text + "\n\n") // 添加文本内容
+				// 回复中引用的来源
+				if len(block.Sources) > 0 {
+					markdown.WriteString("**Sources:**\n\n")
+					for _, src := range block.Sources {
+						markdown.WriteString(fmt.Sprintf("- [%d] `%s`\n", src.Index, src.Location()))
+					}
+					markdown.WriteString("\n")
+				}
+
+			case history.SourceBlockType: // 检索到的可引用内容
+				if block.Source != nil {
+					markdown.WriteString(fmt.Sprintf("### Source [%d]\n", block.Source.Index))
+					markdown.WriteString(fmt.Sprintf("`%s`\n\n", block.Source.Location()))
+				}
+				markdown.WriteString("```\n")
+				markdown.WriteString(block.Text)
+				markdown.WriteString("\n```\n\n")
 
 			case "tool_use": // 工具调用消息
 				markdown.WriteString("### Tool Use\n")
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestServerConfigWrapperUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want ServerConfig
+	}{
+		{
+			name: "没有 type 和 url 时为 stdio",
+			json: `{"command": "npx", "args": ["-y", "server"], "env": {"TOKEN": "x"}}`,
+			want: STDIOServerConfig{Command: "npx", Args: []string{"-y", "server"}, Env: map[string]string{"TOKEN": "x"}},
+		},
+		{
+			name: "没有 type 时 url 按旧格式视为 sse",
+			json: `{"url": "http://localhost:8000/sse", "headers": ["Authorization: Bearer x"]}`,
+			want: SSEServerConfig{Url: "http://localhost:8000/sse", Headers: []string{"Authorization: Bearer x"}},
+		},
+		{
+			name: "没有 type 时其他路径也视为 sse",
+			json: `{"url": "https://example.com/events"}`,
+			want: SSEServerConfig{Url: "https://example.com/events"},
+		},
+		{
+			name: "没有 type 时路径以 /mcp 结尾为 http",
+			json: `{"url": "https://example.com/mcp"}`,
+			want: HTTPServerConfig{Type: transportHTTP, Url: "https://example.com/mcp"},
+		},
+		{
+			name: "路径以 /mcp/ 结尾并带查询参数",
+			json: `{"url": "http://127.0.0.1:3000/api/mcp/?key=1"}`,
+			want: HTTPServerConfig{Type: transportHTTP, Url: "http://127.0.0.1:3000/api/mcp/?key=1"},
+		},
+		{
+			name: "只有主机名是 mcp 时不视为 http",
+			json: `{"url": "http://mcp"}`,
+			want: SSEServerConfig{Url: "http://mcp"},
+		},
+		{
+			name: "显式 stdio",
+			json: `{"type": "stdio", "command": "server"}`,
+			want: STDIOServerConfig{Type: transportStdio, Command: "server"},
+		},
+		{
+			name: "显式 sse 优先于推断",
+			json: `{"type": "sse", "url": "https://example.com/mcp"}`,
+			want: SSEServerConfig{Type: transportSSE, Url: "https://example.com/mcp"},
+		},
+		{
+			name: "显式 http",
+			json: `{"type": "http", "url": "https://example.com/rpc", "headers": ["X-Key: 1"]}`,
+			want: HTTPServerConfig{Type: transportHTTP, Url: "https://example.com/rpc", Headers: []string{"X-Key: 1"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w ServerConfigWrapper
+			if err := json.Unmarshal([]byte(tt.json), &w); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(w.Config, tt.want) {
+				t.Fatalf("解析结果 = %#v，期望 %#v", w.Config, tt.want)
+			}
+
+			// 写回配置后再次读取，类型不变
+			data, err := json.Marshal(w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var again ServerConfigWrapper
+			if err := json.Unmarshal(data, &again); err != nil {
+				t.Fatal(err)
+			}
+			if again.Config.GetType() != tt.want.GetType() {
+				t.Fatalf("写回后类型 = %s，期望 %s（%s）", again.Config.GetType(), tt.want.GetType(), data)
+			}
+		})
+	}
+}
+
+func TestServerConfigWrapperUnmarshalErrors(t *testing.T) {
+	for _, data := range []string{
+		`{"type": "websocket", "url": "ws://localhost"}`,
+		`{"type": "sse"}`,
+		`{"type": "http", "command": "server"}`,
+		`{"url": 1}`,
+		`[]`,
+	} {
+		var w ServerConfigWrapper
+		if err := json.Unmarshal([]byte(data), &w); err == nil {
+			t.Errorf("解析 %s 应该失败，得到 %#v", data, w.Config)
+		}
+	}
+}
+
+func TestMCPConfigServers(t *testing.T) {
+	data := `{"mcpServers": {
+		"local": {"command": "server"},
+		"legacy": {"url": "http://localhost:8000/sse"},
+		"remote": {"url": "https://example.com/mcp"}
+	}}`
+	var config MCPConfig
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"local": transportStdio, "legacy": transportSSE, "remote": transportHTTP}
+	for name, typ := range want {
+		if got := config.MCPServers[name].Config.GetType(); got != typ {
+			t.Errorf("%s 的类型 = %s，期望 %s", name, got, typ)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcphost/pkg/history"
+	"github.com/mark3labs/mcphost/pkg/llm"
+	"github.com/mark3labs/mcphost/pkg/session"
+	"golang.org/x/term"
+)
+
+// 非交互模式的输出格式
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+var (
+	promptFlag   string // 通过 -p/--prompt 传入的单次 prompt
+	outputFormat string // 非交互模式的输出格式：text 或 json
+	quietMode    bool   // 非交互模式：不显示加载动画和对话过程，stdout 只输出最终结果
+)
+
+// oneShotResult 是 --output json 时输出的结果
+type oneShotResult struct {
+	Model     string           `json:"model"`
+	Session   string           `json:"session,omitempty"`
+	Response  string           `json:"response"`
+	Sources   []history.Source `json:"sources,omitempty"` // 回复中引用的来源
+	ToolCalls []oneShotToolUse `json:"tool_calls,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// oneShotToolUse 记录一次工具调用及其结果
+type oneShotToolUse struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Result    string          `json:"result,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+}
+
+// readOneShotPrompt 读取 -p 参数和管道输入的内容，两者同时存在时拼接在一起。
+// 返回空字符串表示进入交互模式。
+func readOneShotPrompt() (string, error) {
+	switch outputFormat {
+	case outputText, outputJSON:
+	default:
+		return "", fmt.Errorf("不支持的输出格式: %s（可选 text 或 json）", outputFormat)
+	}
+
+	prompt := strings.TrimSpace(promptFlag)
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("读取标准输入失败: %w", err)
+		}
+		if input := strings.TrimSpace(string(data)); input != "" {
+			if prompt != "" {
+				prompt += "\n\n" + input
+			} else {
+				prompt = input
+			}
+		}
+		// 标准输入不是终端时无法显示交互表单
+		if prompt == "" {
+			return "", errors.New("未提供 prompt，请使用 -p 参数或通过标准输入传入")
+		}
+	}
+	return prompt, nil
+}
+
+// runOneShot 以非交互模式执行单个 prompt（包含完整的工具调用循环），
+// 只把最终回复输出到 stdout。模型或工具出错时返回错误，使进程以非零状态码退出。
+func runOneShot(
+	ctx context.Context,
+	provider llm.Provider,
+	mcpClients map[string]mcpclient.MCPClient,
+	tools []llm.Tool,
+	systemPrompt string,
+	store *session.Store,
+	sess *session.Session,
+	messages []history.HistoryMessage,
+	prompt string,
+) error {
+	budget := contextBudget(systemPrompt, tools)
+	if pruneStrategy == pruneStrategyCompact &&
+		totalTokens(messages)+history.EstimateTokens(prompt) > budget {
+		if _, err := compactHistory(ctx, provider, store, sess, &messages, budget, budget/2); err != nil {
+			log.Warn("自动压缩对话失败，将按 token 预算裁剪", "error", err)
+		}
+	}
+
+	start := len(messages)
+	runErr := runPrompt(ctx, provider, mcpClients, tools, budget, prompt, &messages, nil)
+
+	// 只有显式指定 --session / --continue 时才保存，避免脚本调用产生大量会话文件
+	if sessionID != "" || continueSession {
+		if err := store.Append(sess, messages[start:]...); err != nil {
+			log.Error("保存会话失败", "id", sess.ID, "error", err)
+		}
+	}
+
+	result := collectOneShotResult(messages[start:])
+	result.Model = modelFlag
+	if sessionID != "" || continueSession {
+		result.Session = sess.ID
+	}
+
+	err := runErr
+	if err == nil {
+		for _, call := range result.ToolCalls {
+			if call.IsError {
+				err = fmt.Errorf("工具 %s 调用失败: %s", call.Name, call.Result)
+				break
+			}
+		}
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	if outputFormat == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(result); encErr != nil {
+			return fmt.Errorf("输出结果失败: %w", encErr)
+		}
+	} else if result.Response != "" {
+		fmt.Println(result.Response)
+		if footer := formatSources(result.Sources); footer != "" {
+			fmt.Printf("\n%s\n", footer)
+		}
+	}
+	return err
+}
+
+// collectOneShotResult 从本轮新增的消息中提取最终回复和工具调用记录
+func collectOneShotResult(messages []history.HistoryMessage) oneShotResult {
+	var result oneShotResult
+	calls := make(map[string]int)
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			switch block.Type {
+			case "tool_use":
+				calls[block.ID] = len(result.ToolCalls)
+				result.ToolCalls = append(result.ToolCalls, oneShotToolUse{
+					Name:      block.Name,
+					Arguments: block.Input,
+				})
+			case "tool_result":
+				if i, ok := calls[block.ToolUseID]; ok {
+					result.ToolCalls[i].Result = block.Text
+					result.ToolCalls[i].IsError = block.IsError
+				}
+			}
+		}
+		if msg.Role == "assistant" || msg.Role == "model" {
+			if text := strings.TrimSpace(msg.GetContent()); text != "" {
+				result.Response = text
+				result.Sources = nil
+				for _, block := range msg.Content {
+					result.Sources = append(result.Sources, block.Sources...)
+				}
+			}
+		}
+	}
+	return result
+}
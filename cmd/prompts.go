@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcphost/pkg/history"
+)
+
+// serverPrompts 缓存每个 MCP 服务器提供的提示模板，用于解析 /server:prompt 命令和在 /help 中列出
+var serverPrompts = struct {
+	sync.RWMutex
+	m map[string][]mcp.Prompt
+}{m: make(map[string][]mcp.Prompt)}
+
+// loadPrompts 获取声明了 prompts 能力的服务器提供的提示模板。获取失败只记录日志。
+func loadPrompts(ctx context.Context, mcpClients map[string]mcpclient.MCPClient) {
+	for serverName, mcpClient := range mcpClients {
+		if getServerCapabilities(serverName).Prompts == nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		result, err := mcpClient.ListPrompts(ctx, mcp.ListPromptsRequest{})
+		cancel()
+		if err != nil {
+			log.Error("获取提示模板失败", "server", serverName, "error", err)
+			continue
+		}
+		setServerPrompts(serverName, result.Prompts)
+		log.Info("提示模板加载成功", "server", serverName, "count", len(result.Prompts))
+	}
+}
+
+// setServerPrompts 替换服务器的提示模板列表
+func setServerPrompts(serverName string, prompts []mcp.Prompt) {
+	serverPrompts.Lock()
+	defer serverPrompts.Unlock()
+	serverPrompts.m[serverName] = prompts
+}
+
+// findPrompt 按服务器名和模板名查找提示模板
+func findPrompt(serverName, name string) (mcp.Prompt, bool) {
+	serverPrompts.RLock()
+	defer serverPrompts.RUnlock()
+	for _, p := range serverPrompts.m[serverName] {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return mcp.Prompt{}, false
+}
+
+// promptCommandHelp 返回 /help 中列出的提示模板命令，按命令名排序
+func promptCommandHelp() []string {
+	serverPrompts.RLock()
+	defer serverPrompts.RUnlock()
+	var lines []string
+	for serverName, prompts := range serverPrompts.m {
+		for _, p := range prompts {
+			usage := "/" + serverName + ":" + p.Name
+			for _, arg := range p.Arguments {
+				if arg.Required {
+					usage += " " + arg.Name + "=..."
+				} else {
+					usage += " [" + arg.Name + "=...]"
+				}
+			}
+			line := "- `" + usage + "`"
+			if p.Description != "" {
+				line += ": " + p.Description
+			}
+			lines = append(lines, line)
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// handlePromptCommand 处理 /server:prompt 形式的命令：收集参数后获取提示模板，
+// 返回要加入对话的消息。命令后可以用 name=value 给出参数，模板只有一个参数时也可以直接写参数值，
+// 没有给出的参数通过表单填写。input 不是已连接服务器的提示模板命令时返回 false；
+// 出错或取消时已向用户提示，返回 true 和空消息。
+func handlePromptCommand(
+	ctx context.Context,
+	input string,
+	mcpClients map[string]mcpclient.MCPClient,
+) ([]history.HistoryMessage, bool) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return nil, false
+	}
+	serverName, name, ok := strings.Cut(fields[0][1:], ":")
+	if !ok {
+		return nil, false
+	}
+	client, ok := mcpClients[serverName]
+	if !ok {
+		return nil, false
+	}
+	prompt, ok := findPrompt(serverName, name)
+	if !ok {
+		fmt.Printf("\n%s\n\n", errorStyle.Render(fmt.Sprintf("服务器 %s 没有名为 %s 的提示模板", serverName, name)))
+		return nil, true
+	}
+
+	args, err := collectPromptArguments(fields[0], prompt, strings.TrimSpace(strings.TrimSpace(input)[len(fields[0]):]))
+	if err != nil {
+		if errors.Is(err, huh.ErrUserAborted) {
+			fmt.Printf("\n%s\n\n", descriptionStyle.Render("已取消"))
+		} else {
+			fmt.Printf("\n%s\n\n", errorStyle.Render(err.Error()))
+		}
+		return nil, true
+	}
+
+	var result *mcp.GetPromptResult
+	runWithSpinner("正在获取提示模板...", func() {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		req := mcp.GetPromptRequest{}
+		req.Params.Name = prompt.Name
+		req.Params.Arguments = args
+		result, err = client.GetPrompt(ctx, req)
+	})
+	if err != nil {
+		fmt.Printf("\n%s\n\n", errorStyle.Render(fmt.Sprintf("获取提示模板 %s:%s 失败: %v", serverName, name, err)))
+		return nil, true
+	}
+
+	messages := promptMessagesToHistory(result.Messages)
+	if len(messages) == 0 {
+		fmt.Printf("\n%s\n\n", errorStyle.Render(fmt.Sprintf("提示模板 %s:%s 没有返回消息", serverName, name)))
+		return nil, true
+	}
+	for _, msg := range messages {
+		label := "You"
+		if msg.Role == "assistant" {
+			label = "Assistant"
+		}
+		fmt.Printf("\n%s\n", promptStyle.Render(fmt.Sprintf("%s (/%s:%s): %s", label, serverName, name, msg.GetContent())))
+	}
+	return messages, true
+}
+
+// collectPromptArguments 解析命令中给出的参数，缺少的参数通过表单填写
+func collectPromptArguments(command string, prompt mcp.Prompt, rest string) (map[string]string, error) {
+	args := make(map[string]string)
+	if len(prompt.Arguments) == 0 {
+		return args, nil
+	}
+
+	known := make(map[string]bool, len(prompt.Arguments))
+	for _, arg := range prompt.Arguments {
+		known[arg.Name] = true
+	}
+	if rest != "" {
+		// 只有一个参数时，不是 name=value 形式的内容整体作为该参数的值
+		if name, _, ok := strings.Cut(rest, "="); len(prompt.Arguments) == 1 && (!ok || !known[name]) {
+			args[prompt.Arguments[0].Name] = rest
+		} else {
+			for _, field := range strings.Fields(rest) {
+				name, value, ok := strings.Cut(field, "=")
+				if !ok || !known[name] {
+					return nil, fmt.Errorf("无法识别的参数 %q，请使用 name=value 的形式", field)
+				}
+				args[name] = value
+			}
+		}
+	}
+
+	var missing []mcp.PromptArgument
+	for _, arg := range prompt.Arguments {
+		if _, ok := args[arg.Name]; !ok {
+			missing = append(missing, arg)
+		}
+	}
+	if len(missing) == 0 {
+		return args, nil
+	}
+
+	values := make([]string, len(missing))
+	fields := make([]huh.Field, len(missing))
+	for i, arg := range missing {
+		title := arg.Name
+		if arg.Required {
+			title += " *"
+		}
+		input := huh.NewInput().
+			Title(title).
+			Description(arg.Description).
+			Value(&values[i])
+		if arg.Required {
+			name := arg.Name
+			input = input.Validate(func(s string) error {
+				if strings.TrimSpace(s) == "" {
+					return fmt.Errorf("%s 不能为空", name)
+				}
+				return nil
+			})
+		}
+		fields[i] = input
+	}
+	title := command
+	if prompt.Description != "" {
+		title += " - " + prompt.Description
+	}
+	fmt.Println()
+	err := huh.NewForm(huh.NewGroup(
+		append([]huh.Field{huh.NewNote().Title(title)}, fields...)...,
+	)).WithWidth(getTerminalWidth()).
+		WithTheme(huh.ThemeCharm()).
+		Run()
+	if err != nil {
+		return nil, err
+	}
+	for i, arg := range missing {
+		// 没有填写的可选参数不传给服务器
+		if v := strings.TrimSpace(values[i]); v != "" {
+			args[arg.Name] = v
+		}
+	}
+	return args, nil
+}
+
+// promptMessagesToHistory 把提示模板返回的消息转换为对话消息，相邻的同角色消息合并为一条。
+// 嵌入的资源转换为文本，图片等二进制内容只保留说明。
+func promptMessagesToHistory(promptMessages []mcp.PromptMessage) []history.HistoryMessage {
+	var messages []history.HistoryMessage
+	for _, pm := range promptMessages {
+		var block history.ContentBlock
+		switch c := pm.Content.(type) {
+		case mcp.TextContent:
+			block = history.ContentBlock{Type: "text", Text: c.Text}
+		case mcp.ImageContent:
+			block = history.ContentBlock{Type: "text", Text: fmt.Sprintf("（图片 %s，已省略）", c.MIMEType)}
+		case mcp.EmbeddedResource:
+			switch r := c.Resource.(type) {
+			case mcp.TextResourceContents:
+				block = history.ContentBlock{Type: "text", Text: fmt.Sprintf("资源 %s：\n%s", r.URI, r.Text)}
+			case mcp.BlobResourceContents:
+				block = history.ContentBlock{Type: "text", Text: fmt.Sprintf("（%s 是二进制内容 %s，已省略）", r.URI, r.MIMEType)}
+			default:
+				continue
+			}
+		default:
+			continue
+		}
+
+		role := string(pm.Role)
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, block)
+			continue
+		}
+		messages = append(messages, history.HistoryMessage{Role: role, Content: []history.ContentBlock{block}})
+	}
+	return messages
+}
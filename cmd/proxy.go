@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/log"
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	proxyTransport string // 对外提供服务的传输方式：stdio 或 sse
+	proxyAddr      string // SSE 模式的监听地址
+	proxyBaseURL   string // SSE 模式下客户端访问的基础 URL
+)
+
+// proxyCmd 将所有已配置的 MCP 服务器聚合为一个 MCP 服务器
+var proxyCmd = &cobra.Command{
+	Use:   "mcp-proxy",
+	Short: "将所有 MCP 服务器聚合为一个 MCP 服务器",
+	Long: `以 MCP 服务器的形式运行，重新导出配置文件中所有 MCP 服务器的工具。
+工具名使用 server__tool 格式，调用会转发给对应的后端服务器，
+客户端只需配置 mcphost 一个服务器即可使用全部工具。
+
+示例：
+  mcphost mcp-proxy                          # 通过 stdio 提供服务
+  mcphost mcp-proxy --transport sse --addr :8081`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return runMCPProxy(context.Background())
+	},
+}
+
+func init() {
+	proxyCmd.Flags().StringVar(&proxyTransport, "transport", transportStdio, "对外提供服务的传输方式：stdio 或 sse")
+	proxyCmd.Flags().StringVar(&proxyAddr, "addr", "127.0.0.1:8081", "SSE 模式的监听地址")
+	proxyCmd.Flags().StringVar(&proxyBaseURL, "base-url", "", "SSE 模式下客户端访问的基础 URL（默认根据 --addr 推断）")
+	rootCmd.AddCommand(proxyCmd)
+}
+
+// runMCPProxy 连接所有后端 MCP 服务器，并通过 stdio 或 SSE 对外提供聚合后的工具
+func runMCPProxy(ctx context.Context) error {
+	switch proxyTransport {
+	case transportStdio, transportSSE:
+	default:
+		return fmt.Errorf("不支持的传输方式: %s（可选 stdio 或 sse）", proxyTransport)
+	}
+
+	// stdio 模式下 stdout 用于 MCP 协议通信，日志只能写到 stderr
+	log.SetOutput(os.Stderr)
+	configureLogging()
+
+	mcpConfig, err := loadMCPConfig()
+	if err != nil {
+		return fmt.Errorf("加载 MCP 配置失败: %v", err)
+	}
+	mcpClients, err := createMCPClients(mcpConfig)
+	if err != nil {
+		return fmt.Errorf("创建 MCP 客户端失败: %v", err)
+	}
+	defer closeMCPClients(mcpClients)
+
+	mcpServer := server.NewMCPServer(
+		"mcphost",
+		"0.1.0",
+		server.WithToolCapabilities(false),
+		server.WithRecovery(),
+	)
+	mcpServer.AddTools(proxyTools(ctx, mcpClients)...)
+
+	if proxyTransport == transportStdio {
+		log.Info("MCP 代理已启动", "transport", transportStdio)
+		return server.ServeStdio(mcpServer)
+	}
+	return serveProxySSE(ctx, mcpServer)
+}
+
+// proxyTools 收集所有后端服务器的工具，以 server__tool 的名称重新导出
+func proxyTools(ctx context.Context, mcpClients map[string]mcpclient.MCPClient) []server.ServerTool {
+	var tools []server.ServerTool
+	for _, serverName := range serverNames(mcpClients) {
+		client := mcpClients[serverName]
+
+		listCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		result, err := client.ListTools(listCtx, mcp.ListToolsRequest{})
+		cancel()
+		if err != nil {
+			log.Error("获取工具失败", "server", serverName, "error", err)
+			continue
+		}
+
+		for _, tool := range result.Tools {
+			exported := tool
+			exported.Name = namespacedToolName(serverName, tool.Name)
+			tools = append(tools, server.ServerTool{
+				Tool:    exported,
+				Handler: forwardToolCall(serverName, tool.Name, client),
+			})
+		}
+		log.Info("工具加载成功", "server", serverName, "count", len(result.Tools))
+	}
+
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].Tool.Name < tools[j].Tool.Name
+	})
+	return tools
+}
+
+// forwardToolCall 返回把工具调用转发给后端服务器的处理函数。
+// 后端调用失败时返回带 isError 标记的结果，而不是协议错误，便于调用方的模型据此调整。
+func forwardToolCall(serverName, toolName string, client mcpclient.MCPClient) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		request.Params.Name = toolName
+		log.Debug("转发工具调用", "server", serverName, "tool", toolName)
+
+		result, err := client.CallTool(ctx, request)
+		if err != nil {
+			log.Error("转发工具调用失败", "server", serverName, "tool", toolName, "error", err)
+			return mcp.NewToolResultError(fmt.Sprintf("调用工具 %s 错误: %v", toolName, err)), nil
+		}
+		return result, nil
+	}
+}
+
+// serveProxySSE 通过 SSE 提供 MCP 服务，收到中断信号时优雅退出
+func serveProxySSE(ctx context.Context, mcpServer *server.MCPServer) error {
+	baseURL := proxyBaseURL
+	if baseURL == "" {
+		baseURL = "http://" + proxyAddr
+		if proxyAddr != "" && proxyAddr[0] == ':' {
+			baseURL = "http://localhost" + proxyAddr
+		}
+	}
+	sseServer := server.NewSSEServer(mcpServer,
+		server.WithBaseURL(baseURL),
+		server.WithKeepAlive(true),
+	)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Info("MCP 代理已启动", "transport", transportSSE, "addr", proxyAddr,
+			"endpoint", sseServer.CompleteSseEndpoint())
+		errCh <- sseServer.Start(proxyAddr)
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("MCP 代理异常退出: %w", err)
+	case <-ctx.Done():
+		log.Info("正在关闭 MCP 代理...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return sseServer.Shutdown(shutdownCtx)
+	}
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/charmbracelet/log"
+	"github.com/mark3labs/mcphost/pkg/history"
+	"github.com/mark3labs/mcphost/pkg/rag"
+)
+
+var (
+	ragMode        bool    // 是否在每轮对话前自动检索知识库
+	ragTopK        int     // 自动检索时最多注入的结果数
+	ragMaxDistance float64 // 自动检索的距离阈值，0 表示不过滤
+	ragSearchMode  string  // 自动检索的检索策略，为空时使用配置中的 search_mode
+	ragRerank      bool    // 自动检索时是否用模型重排结果
+	ragRerankSet   bool    // 是否在命令行中显式指定了 --rag-rerank
+
+	// promptRetriever 在 --rag 模式下用于自动检索，为 nil 时不检索
+	promptRetriever rag.Retriever
+	// promptRerank 是自动检索实际使用的重排设置：指定了 --rag-rerank 时使用参数，否则使用配置
+	promptRerank bool
+
+	// ragReranker 用当前对话模型重排检索结果，内置 RAG 工具和自动检索共用。
+	// 没有创建模型的命令（例如 mcp-proxy）中 Provider 为 nil，此时不重排。
+	ragReranker = &rag.LLMReranker{}
+)
+
+func init() {
+	rootCmd.Flags().BoolVar(&ragMode, "rag", false, "每轮对话前用用户输入检索知识库，并把结果作为上下文注入")
+	rootCmd.Flags().IntVar(&ragTopK, "rag-top-k", 3, "自动检索时最多注入的结果数")
+	rootCmd.Flags().Float64Var(&ragMaxDistance, "rag-max-distance", 0,
+		"自动检索的距离阈值，超过阈值的结果被丢弃（默认 0 不过滤；距离的范围取决于后端和嵌入模型，例如本地后端的默认嵌入为 0 到 4）")
+	rootCmd.Flags().StringVar(&ragSearchMode, "rag-mode", "", "自动检索的检索策略：hybrid、vector 或 keyword（默认使用配置中的 search_mode）")
+	rootCmd.Flags().BoolVar(&ragRerank, "rag-rerank", false, "自动检索时用当前模型对候选结果重新排序（默认使用配置中的 rerank）")
+}
+
+// setupRetriever 在 --rag 模式下打开知识库后端，返回关闭后端的函数。
+// 配置文件中没有 rag 部分时使用默认的 gRPC 服务地址。
+func setupRetriever(config *MCPConfig) (func(), error) {
+	if !ragMode {
+		return func() {}, nil
+	}
+
+	cfg := rag.Config{}
+	if config.RAG != nil {
+		cfg = *config.RAG
+	}
+	if _, err := rag.ParseMode(ragSearchMode); err != nil {
+		return nil, err
+	}
+	backend, closeBackend, err := openRAGBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	searcher, err := rag.NewSearcher(backend, cfg, ragReranker)
+	if err != nil {
+		closeBackend()
+		return nil, err
+	}
+	promptRetriever = rag.NewRetriever(searcher)
+	promptRerank = searcher.DefaultRerank()
+	if ragRerankSet {
+		promptRerank = ragRerank
+	}
+	mode := ragSearchMode
+	if mode == "" {
+		mode = searcher.DefaultMode()
+	}
+	log.Info("已启用自动检索", "top_k", ragTopK, "max_distance", ragMaxDistance, "mode", mode, "rerank", promptRerank)
+	return func() {
+		promptRetriever = nil
+		closeBackend()
+	}, nil
+}
+
+// retrieveContext 用 prompt 检索知识库，返回要附在用户输入之后的内容块，
+// 每条结果是一个编号从 first 开始的 source 块。检索失败不影响对话，只记录警告并返回 nil。
+func retrieveContext(ctx context.Context, prompt string, first int) []history.ContentBlock {
+	if promptRetriever == nil {
+		return nil
+	}
+
+	var passages []rag.Passage
+	var err error
+	runWithSpinner("正在检索知识库...", func() {
+		passages, err = promptRetriever.Retrieve(ctx, prompt, rag.RetrieveOptions{
+			TopK:        ragTopK,
+			MaxDistance: ragMaxDistance,
+			Mode:        ragSearchMode,
+			Rerank:      promptRerank,
+		})
+	})
+	if err != nil {
+		log.Warn("自动检索失败，将不带检索结果继续", "error", err)
+		return nil
+	}
+
+	log.Debug("自动检索完成", "passages", len(passages))
+	return rag.ContextBlocks(passages, first)
+}
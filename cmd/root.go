@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/glamour/styles"
@@ -23,6 +26,7 @@ import (
 	"github.com/mark3labs/mcphost/pkg/llm/google"
 	"github.com/mark3labs/mcphost/pkg/llm/ollama"
 	"github.com/mark3labs/mcphost/pkg/llm/openai"
+	"github.com/mark3labs/mcphost/pkg/rag"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
@@ -33,12 +37,17 @@ var (
 	configFile       string                // 配置文件路径
 	systemPromptFile string                // 系统提示词文件路径
 	messageWindow    int                   // 上下文中保留的消息条数
+	pruneStrategy    string                // 上下文裁剪策略：tokens 或 window
+	contextTokens    int                   // 模型上下文窗口大小，0 表示按模型自动推断
 	modelFlag        string                // 模型选择参数，如 "openai:gpt-4"
 	openaiBaseURL    string                // OpenAI API 的基础 URL
 	anthropicBaseURL string                // Anthropic API 的基础 URL
 	openaiAPIKey     string                // OpenAI API 密钥
 	anthropicAPIKey  string                // Anthropic API 密钥
 	googleAPIKey     string                // Google Gemini API 密钥
+	dataDir          string                // 数据目录，用于保存会话等持久化数据
+	sessionID        string                // 要恢复或创建的会话 ID
+	continueSession  bool                  // 是否继续最近一次会话
 )
 
 // 定义常量用于控制重试策略
@@ -56,16 +65,28 @@ var rootCmd = &cobra.Command{
 它支持通过 MCP 服务器连接多种 AI 模型，并提供流式响应能力。
 
 可用模型可通过 --model 参数指定，例如：
-- Anthropic Claude（默认）：anthropic:claude-3-5-sonnet-latest
+- Anthropic Claude：anthropic:claude-3-5-sonnet-latest
 - OpenAI：openai:gpt-4
-- Ollama 本地模型：ollama:modelname
+- Ollama 本地模型（默认）：ollama:qwen3:1.7b
 - Google Gemini：google:modelname
 
 示例：
   mcphost -m ollama:qwen2.5:3b
   mcphost -m openai:gpt-4
-  mcphost -m google:gemini-2.0-flash`,
+  mcphost -m google:gemini-2.0-flash
+
+非交互模式（适用于脚本、git hooks、cron 等）：
+  mcphost -p "总结今天的提交"
+  git diff | mcphost -p "为这些改动写一条提交信息"
+  echo "你好" | mcphost --output json`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// 记录是否显式指定了模型，恢复会话时据此决定是否沿用会话中的模型
+		modelFlagSet = cmd.Flags().Changed("model")
+		ragRerankSet = cmd.Flags().Changed("rag-rerank")
+		// /bg 自动启动的任务执行进程沿用这些全局参数
+		taskWorkerArgs = inheritedFlags(cmd)
+		// 参数已解析，之后的错误不再打印用法说明
+		cmd.SilenceUsage = true
 		// 执行主逻辑（定义在 runMCPHost 中）
 		return runMCPHost(context.Background())
 	},
@@ -80,6 +101,8 @@ func Execute() {
 
 var debugMode bool // 是否启用调试模式
 
+var modelFlagSet bool // 是否在命令行中显式指定了 --model
+
 // 初始化函数，用于注册命令行参数
 func init() {
 	rootCmd.PersistentFlags().
@@ -87,11 +110,16 @@ func init() {
 	rootCmd.PersistentFlags().
 		StringVar(&systemPromptFile, "system-prompt", "", "系统提示词 JSON 文件")
 	rootCmd.PersistentFlags().
-		IntVar(&messageWindow, "message-window", 10, "上下文中保留的消息数")
+		IntVar(&messageWindow, "message-window", 10, "上下文中保留的消息数（window 裁剪策略）")
+	rootCmd.PersistentFlags().
+		StringVar(&pruneStrategy, "prune-strategy", pruneStrategyTokens, "上下文裁剪策略：tokens（按 token 预算）、window（按消息条数）或 compact（超出预算时自动摘要）")
+	rootCmd.PersistentFlags().
+		IntVar(&contextTokens, "context-tokens", 0, "模型上下文窗口大小（token），默认按模型自动推断")
 
-	// 模型选择参数，支持 anthropic/openai/ollama/google 等格式
+	// 模型选择参数，支持 anthropic/openai/ollama/google 等格式。
+	// 默认使用本地的 ollama 模型，与之前由 main 追加 -m 参数时的行为一致
 	rootCmd.PersistentFlags().
-		StringVarP(&modelFlag, "model", "m", "anthropic:claude-3-5-sonnet-latest",
+		StringVarP(&modelFlag, "model", "m", "ollama:qwen3:1.7b",
 			"使用的模型（格式：provider:model，例如 openai:gpt-4 或 ollama:qwen2.5:3b）")
 
 	// 调试模式开关
@@ -105,6 +133,15 @@ func init() {
 	flags.StringVar(&openaiAPIKey, "openai-api-key", "", "OpenAI API 密钥")
 	flags.StringVar(&anthropicAPIKey, "anthropic-api-key", "", "Anthropic API 密钥")
 	flags.StringVar(&googleAPIKey, "google-api-key", "", "Google Gemini API 密钥")
+
+	// 会话持久化参数
+	flags.StringVar(&dataDir, "data-dir", "", "数据目录 (默认是 $HOME/.mcphost)")
+	rootCmd.Flags().StringVar(&sessionID, "session", "", "恢复指定 ID 的会话，不存在时以该 ID 新建会话")
+	rootCmd.Flags().BoolVarP(&continueSession, "continue", "c", false, "继续最近一次会话")
+
+	// 非交互模式参数
+	rootCmd.Flags().StringVarP(&promptFlag, "prompt", "p", "", "以非交互模式运行单个 prompt，输出回复后退出")
+	rootCmd.Flags().StringVar(&outputFormat, "output", outputText, "非交互模式的输出格式：text 或 json")
 }
 
 // 创建 AI Provider 实例，根据 --model 参数动态选择后端模型提供方
@@ -161,6 +198,32 @@ func createProvider(ctx context.Context, modelString, systemPrompt string) (llm.
 	}
 }
 
+// closeProvider 释放 provider 持有的客户端，用于只在一次请求中使用的 provider
+func closeProvider(provider llm.Provider) {
+	if closer, ok := provider.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Warn("关闭提供者失败", "provider", provider.Name(), "error", err)
+		}
+	}
+}
+
+// createEmbedder 根据 "provider:model" 创建生成向量的嵌入器，例如 "openai:text-embedding-3-small"。
+// "hash" 或空字符串使用内置的哈希嵌入，不依赖任何服务。
+func createEmbedder(ctx context.Context, modelString string) (llm.Embedder, error) {
+	if rag.IsBuiltinEmbedding(modelString) {
+		return rag.NewEmbedder(modelString)
+	}
+	provider, err := createProvider(ctx, modelString, "")
+	if err != nil {
+		return nil, err
+	}
+	embedder, ok := provider.(llm.Embedder)
+	if !ok {
+		return nil, fmt.Errorf("模型提供方 %s 不支持生成向量", provider.Name())
+	}
+	return embedder, nil
+}
+
 // pruneMessages 用于裁剪对话历史，保留最近的 messageWindow 条消息，并移除无效的工具调用和结果。
 func pruneMessages(messages []history.HistoryMessage) []history.HistoryMessage {
 	if len(messages) <= messageWindow {
@@ -168,8 +231,11 @@ func pruneMessages(messages []history.HistoryMessage) []history.HistoryMessage {
 	}
 
 	// 仅保留最后 messageWindow 条消息
-	messages = messages[len(messages)-messageWindow:]
+	return removeOrphanToolBlocks(messages[len(messages)-messageWindow:])
+}
 
+// removeOrphanToolBlocks 移除失去配对的 tool_use 和 tool_result，保证工具调用与结果成对出现
+func removeOrphanToolBlocks(messages []history.HistoryMessage) []history.HistoryMessage {
 	toolUseIds := make(map[string]bool)    // 用于记录有效的 tool_use ID
 	toolResultIds := make(map[string]bool) // 用于记录有效的 tool_result 所引用的 tool_use ID
 
@@ -243,45 +309,65 @@ func updateRenderer() error {
 	return err
 }
 
-// runPrompt 向 LLM 发送 prompt，并处理返回内容及可能的工具调用
+// runPrompt 向 LLM 发送 prompt，并处理返回内容及可能的工具调用。
+// onEvent 不为 nil 时，流式事件交给它处理而不是输出到终端，也不显示加载动画（serve、后台任务）。
 func runPrompt(
 	ctx context.Context,
 	provider llm.Provider,
 	mcpClients map[string]mcpclient.MCPClient,
 	tools []llm.Tool,
+	budget int,
 	prompt string,
 	messages *[]history.HistoryMessage,
+	onEvent func(llm.StreamEvent),
 ) error {
+	quiet := quietMode || onEvent != nil
+	spin := func(title string, action func()) {
+		if quiet {
+			action()
+			return
+		}
+		runWithSpinner(title, action)
+	}
+
 	// 用户有 prompt 输入时，将其加入消息历史
 	if prompt != "" {
-		fmt.Printf("\n%s\n", promptStyle.Render("You: "+prompt))
+		if !quiet {
+			fmt.Printf("\n%s\n", promptStyle.Render("You: "+prompt))
+		}
+		content := []history.ContentBlock{{
+			Type: "text",
+			Text: prompt,
+		}}
+		// @server:uri 引用的资源和 --rag 模式下的检索结果作为可引用的 source 块附在用户输入之后
+		content = append(content, resourceContext(ctx, prompt, mcpClients, 1)...)
+		content = append(content, retrieveContext(ctx, prompt, nextCitationIndex([]history.HistoryMessage{{Role: "user", Content: content}}))...)
 		*messages = append(*messages, history.HistoryMessage{
-			Role: "user",
-			Content: []history.ContentBlock{{
-				Type: "text",
-				Text: prompt,
-			}},
+			Role:    "user",
+			Content: content,
 		})
 	}
 
-	var message llm.Message
+	var events <-chan llm.StreamEvent
 	var err error
 	backoff := initialBackoff
 	retries := 0
 
-	// 构建 llm 消息列表（接口适配）
-	llmMessages := make([]llm.Message, len(*messages))
-	for i := range *messages {
-		llmMessages[i] = &(*messages)[i]
+	// 按上下文预算裁剪后构建 llm 消息列表（接口适配）
+	contextMessages := prepareContext(*messages, budget)
+	llmMessages := make([]llm.Message, len(contextMessages))
+	for i := range contextMessages {
+		llmMessages[i] = &contextMessages[i]
 	}
 
-	// 重试机制，直到获取响应或超过最大次数
+	// 重试机制，直到建立流式响应或超过最大次数。
+	// prompt 已加入消息历史，不再单独传给 provider，避免同一条输入被发送两次。
 	for {
 		action := func() {
-			message, err = provider.CreateMessage(
-				ctx, prompt, llmMessages, tools)
+			events, err = provider.StreamMessage(
+				ctx, "", llmMessages, tools)
 		}
-		_ = spinner.New().Title("Thinking...").Action(action).Run()
+		spin("Thinking...", action)
 
 		if err != nil {
 			if strings.Contains(err.Error(), "overloaded_error") {
@@ -304,35 +390,27 @@ func runPrompt(
 		break
 	}
 
-	var messageContent []history.ContentBlock
-
-	// 显示 LLM 返回内容
-	if str, err := renderer.Render("\nAssistant: "); message.GetContent() != "" && err == nil {
-		fmt.Print(str)
+	// 逐步输出模型生成的内容
+	message, err := renderStream(events, onEvent)
+	if err != nil {
+		return err
 	}
 
+	var messageContent []history.ContentBlock
+
 	toolResults := []history.ContentBlock{}
 	messageContent = []history.ContentBlock{}
 
-	// 处理普通文本内容
+	// 处理普通文本内容（已在流式输出中显示）
 	if message.GetContent() != "" {
-		if err := updateRenderer(); err != nil {
-			return fmt.Errorf("更新渲染器失败: %v", err)
-		}
-		str, err := renderer.Render(message.GetContent() + "\n")
-		if err != nil {
-			log.Error("响应渲染失败", "error", err)
-			fmt.Print(message.GetContent() + "\n")
-		} else {
-			fmt.Print(str)
-		}
 		messageContent = append(messageContent, history.ContentBlock{
 			Type: "text",
 			Text: message.GetContent(),
 		})
 	}
 
-	// 处理工具调用
+	// 处理工具调用，成功的工具结果按顺序分配本轮的引用编号
+	nextCitation := nextCitationIndex(*messages)
 	for _, toolCall := range message.GetToolCalls() {
 		log.Info("🔧 调用工具", "name", toolCall.GetName())
 
@@ -344,29 +422,28 @@ func runPrompt(
 			Input: input,
 		})
 
-		inputTokens, outputTokens := message.GetUsage()
-		if inputTokens > 0 || outputTokens > 0 {
-			log.Info("令牌使用情况", "input", inputTokens,
-				"output", outputTokens, "total", inputTokens+outputTokens)
-		}
-
 		// 工具名称格式：server__tool
-		parts := strings.Split(toolCall.GetName(), "__")
-		if len(parts) != 2 {
-			fmt.Printf("错误：无效工具名称：%s\n", toolCall.GetName())
+		serverName, toolName, ok := splitToolName(toolCall.GetName())
+		if !ok {
+			errMsg := fmt.Sprintf("错误：无效工具名称：%s", toolCall.GetName())
+			printError(errMsg, quiet)
+			toolResults = append(toolResults, toolErrorResult(toolCall.GetID(), errMsg))
 			continue
 		}
 
-		serverName, toolName := parts[0], parts[1]
 		mcpClient, ok := mcpClients[serverName]
 		if !ok {
-			fmt.Printf("错误：找不到服务器：%s\n", serverName)
+			errMsg := fmt.Sprintf("错误：找不到服务器：%s", serverName)
+			printError(errMsg, quiet)
+			toolResults = append(toolResults, toolErrorResult(toolCall.GetID(), errMsg))
 			continue
 		}
 
 		var toolArgs map[string]interface{}
 		if err := json.Unmarshal(input, &toolArgs); err != nil {
-			fmt.Printf("解析工具参数失败: %v\n", err)
+			errMsg := fmt.Sprintf("解析工具参数失败: %v", err)
+			printError(errMsg, quiet)
+			toolResults = append(toolResults, toolErrorResult(toolCall.GetID(), errMsg))
 			continue
 		}
 
@@ -375,24 +452,14 @@ func runPrompt(
 			req := mcp.CallToolRequest{}
 			req.Params.Name = toolName
 			req.Params.Arguments = toolArgs
-			toolResultPtr, err = mcpClient.CallTool(context.Background(), req)
+			toolResultPtr, err = mcpClient.CallTool(ctx, req)
 		}
-		_ = spinner.New().
-			Title(fmt.Sprintf("运行工具 %s...", toolName)).
-			Action(action).
-			Run()
+		spin(fmt.Sprintf("运行工具 %s...", toolName), action)
 
 		if err != nil {
 			errMsg := fmt.Sprintf("调用工具 %s 错误: %v", toolName, err)
-			fmt.Printf("\n%s\n", errorStyle.Render(errMsg))
-			toolResults = append(toolResults, history.ContentBlock{
-				Type:      "tool_result",
-				ToolUseID: toolCall.GetID(),
-				Content: []history.ContentBlock{{
-					Type: "text",
-					Text: errMsg,
-				}},
-			})
+			printError(errMsg, quiet)
+			toolResults = append(toolResults, toolErrorResult(toolCall.GetID(), errMsg))
 			continue
 		}
 
@@ -400,10 +467,14 @@ func runPrompt(
 		if toolResult.Content != nil {
 			log.Debug("工具结果内容", "content", toolResult.Content)
 
+			sources := citeToolResult(toolCall.GetName(), &toolResult, nextCitation)
+			nextCitation += len(sources)
 			resultBlock := history.ContentBlock{
 				Type:      "tool_result",
 				ToolUseID: toolCall.GetID(),
 				Content:   toolResult.Content,
+				IsError:   toolResult.IsError,
+				Sources:   sources,
 			}
 
 			var resultText string
@@ -419,6 +490,13 @@ func runPrompt(
 		}
 	}
 
+	// 最终回复记录其中引用的来源，并在终端输出 Sources 脚注
+	var cited []history.Source
+	if len(toolResults) == 0 && len(messageContent) > 0 && messageContent[0].Type == "text" {
+		cited = history.CitedSources(messageContent[0].Text, history.TurnSources(*messages))
+		messageContent[0].Sources = cited
+	}
+
 	// 添加助手消息（包含文本 + 工具调用）
 	*messages = append(*messages, history.HistoryMessage{
 		Role:    message.GetRole(),
@@ -433,37 +511,196 @@ func runPrompt(
 				Content: []history.ContentBlock{toolResult},
 			})
 		}
-		// 继续对工具结果进行回复处理
-		return runPrompt(ctx, provider, mcpClients, tools, "", messages)
+		// 继续对工具结果进行回复处理。工具调用可能让服务器增减工具，使用最新的工具列表
+		return runPrompt(ctx, provider, mcpClients, currentTools(), budget, "", messages, onEvent)
 	}
 
-	fmt.Println() // 输出空行以分隔
+	if !quiet {
+		if footer := formatSources(cited); footer != "" {
+			fmt.Printf("\n%s\n", descriptionStyle.Render(footer))
+		}
+		fmt.Println() // 输出空行以分隔
+	}
 	return nil
 }
 
+// toolErrorResult 构造表示工具调用失败的 tool_result，模型可以据此调整后续操作
+func toolErrorResult(toolUseID, errMsg string) history.ContentBlock {
+	return history.ContentBlock{
+		Type:      "tool_result",
+		ToolUseID: toolUseID,
+		Text:      errMsg,
+		IsError:   true,
+		Content: []history.ContentBlock{{
+			Type: "text",
+			Text: errMsg,
+		}},
+	}
+}
+
+// terminalRequest 是后台 goroutine（例如 MCP 服务器的 sampling 请求）需要在终端中与用户交互的请求
+type terminalRequest struct {
+	run  func()
+	done chan struct{}
+}
+
+// terminalRequests 由正在显示加载动画的 runWithSpinner 接收：暂停动画，执行交互后再继续显示
+var terminalRequests = make(chan *terminalRequest)
+
+// runInTerminal 等待 runWithSpinner 在终端中执行 run，并等待 run 结束。
+// ctx 结束前没有 runWithSpinner 接收请求时返回 false，run 不会执行。
+func runInTerminal(ctx context.Context, run func()) bool {
+	req := &terminalRequest{run: run, done: make(chan struct{})}
+	select {
+	case terminalRequests <- req:
+	case <-ctx.Done():
+		return false
+	}
+	<-req.done
+	return true
+}
+
+// runWithSpinner 在终端中显示加载动画并执行 action，非交互模式下直接执行。
+// action 执行期间收到的终端交互请求会暂停动画后处理。
+func runWithSpinner(title string, action func()) {
+	if quietMode || !term.IsTerminal(int(os.Stdout.Fd())) {
+		action()
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		action()
+	}()
+	for {
+		// 动画在 action 结束或收到终端交互请求时停止
+		stopped := make(chan *terminalRequest, 1)
+		_ = spinner.New().Title(title).Action(func() {
+			select {
+			case <-done:
+				stopped <- nil
+			case req := <-terminalRequests:
+				stopped <- req
+			}
+		}).Run()
+
+		req := <-stopped
+		if req == nil {
+			return
+		}
+		req.run()
+		close(req.done)
+	}
+}
+
+// printError 输出错误信息：交互模式下打印到终端，非交互模式下写入日志（stderr），保持 stdout 干净
+func printError(msg string, quiet bool) {
+	if quiet {
+		log.Error(msg)
+		return
+	}
+	fmt.Printf("\n%s\n", errorStyle.Render(msg))
+}
+
+// renderStream 将流式事件中的文本增量实时打印到终端，并返回组装完成的消息。
+// onEvent 不为 nil 时每个事件都会先交给它处理，且不再打印文本。
+func renderStream(events <-chan llm.StreamEvent, onEvent func(llm.StreamEvent)) (llm.Message, error) {
+	var message llm.Message
+	started := false
+
+	for event := range events {
+		if onEvent != nil {
+			onEvent(event)
+		}
+		switch event.Type {
+		case llm.StreamEventText:
+			if quietMode || onEvent != nil {
+				continue
+			}
+			if !started {
+				// 收到第一个文本片段时才输出标题，纯工具调用的回复不显示
+				if str, err := renderer.Render("\nAssistant: "); err == nil {
+					fmt.Print(str)
+				}
+				started = true
+			}
+			fmt.Print(event.Text)
+		case llm.StreamEventToolCall:
+			if event.ToolCall.Name != "" {
+				log.Debug("模型请求工具", "name", event.ToolCall.Name)
+			}
+		case llm.StreamEventUsage:
+			if event.InputTokens > 0 || event.OutputTokens > 0 {
+				log.Info("令牌使用情况", "input", event.InputTokens,
+					"output", event.OutputTokens, "total", event.InputTokens+event.OutputTokens)
+			}
+		case llm.StreamEventDone:
+			message = event.Message
+		case llm.StreamEventError:
+			if started {
+				fmt.Println()
+			}
+			return nil, fmt.Errorf("接收流式响应失败: %w", event.Err)
+		}
+	}
+
+	if started {
+		fmt.Println()
+	}
+	if message == nil {
+		return nil, errors.New("流式响应意外结束")
+	}
+	return message, nil
+}
+
 // runMCPHost 启动 MCP 主机，设置日志、加载配置并启动交互循环
 func runMCPHost(ctx context.Context) error {
-	// 根据调试模式设置日志级别
-	if debugMode {
-		log.SetLevel(log.DebugLevel) // 设置为调试级别
-		log.SetReportCaller(true)    // 启用日志中的调用者信息
-	} else {
-		log.SetLevel(log.InfoLevel) // 设置为信息级别
-		log.SetReportCaller(false)  // 禁用调用者信息
+	// 通过 -p 或标准输入提供 prompt 时进入非交互模式
+	oneShotPrompt, err := readOneShotPrompt()
+	if err != nil {
+		return err
 	}
+	quietMode = oneShotPrompt != ""
+
+	configureLogging()
 
 	// 加载系统提示语
 	systemPrompt, err := loadSystemPrompt(systemPromptFile)
 	if err != nil {
 		return fmt.Errorf("加载系统提示失败: %v", err)
 	}
+	// 要求模型用 [n] 标注回答所依据的检索结果和工具结果
+	systemPrompt = withCitationInstruction(systemPrompt)
+
+	// 打开会话存储，并根据 --session / --continue 加载要恢复的会话
+	sessionStore, err := openSessionStore()
+	if err != nil {
+		return fmt.Errorf("打开会话存储失败: %v", err)
+	}
+	resumed, err := resumeSessionFromFlags(sessionStore)
+	if err != nil {
+		return fmt.Errorf("加载会话失败: %v", err)
+	}
+	// 恢复会话时沿用会话记录的模型，除非显式指定了 --model
+	if resumed != nil && resumed.Model != "" && !modelFlagSet {
+		modelFlag = resumed.Model
+	}
 
 	// 创建 LLM 提供者（根据模型标志选择）
-	fmt.Println("开始创建 provider ")
+	log.Debug("开始创建 provider")
 	provider, err := createProvider(ctx, modelFlag, systemPrompt)
 	if err != nil {
 		return fmt.Errorf("创建提供者失败: %v", err)
 	}
+	ragReranker.Provider = provider
+	sampler.Provider = provider
+
+	switch pruneStrategy {
+	case pruneStrategyTokens, pruneStrategyWindow, pruneStrategyCompact:
+	default:
+		return fmt.Errorf("不支持的裁剪策略: %s（可选 tokens、window 或 compact）", pruneStrategy)
+	}
 
 	// 从模型标志中分离出模型名称
 	parts := strings.SplitN(modelFlag, ":", 2)
@@ -472,33 +709,34 @@ func runMCPHost(ctx context.Context) error {
 		"model", parts[1])
 
 	// 加载 MCP 配置
-	fmt.Println("开始加载 MCP 配置")
+	log.Debug("开始加载 MCP 配置")
 	mcpConfig, err := loadMCPConfig()
 	if err != nil {
 		return fmt.Errorf("加载 MCP 配置失败: %v", err)
 	}
+	if resumed != nil {
+		restrictServers(mcpConfig, resumed.Servers)
+	}
+
+	// --rag 模式下连接 RAG 服务，每轮对话前自动检索
+	closeRetriever, err := setupRetriever(mcpConfig)
+	if err != nil {
+		return fmt.Errorf("启用自动检索失败: %v", err)
+	}
+	defer closeRetriever()
 
 	// 创建 MCP 客户端
 	mcpClients, err := createMCPClients(mcpConfig)
 	n := len(mcpClients)
 	if n == 0 {
-		fmt.Println("没有 mcpClients ")
+		log.Info("没有配置 MCP 服务器")
 	}
 	if err != nil {
 		return fmt.Errorf("创建 MCP 客户端失败: %v", err)
 	}
 
 	// 确保在函数退出时关闭所有 MCP 客户端
-	defer func() {
-		log.Info("正在关闭 MCP 服务器...")
-		for name, client := range mcpClients {
-			if err := client.Close(); err != nil {
-				log.Error("关闭服务器失败", "name", name, "error", err)
-			} else {
-				log.Info("服务器已关闭", "name", name)
-			}
-		}
-	}()
+	defer closeMCPClients(mcpClients)
 
 	// 打印每个已连接的服务器
 	for name := range mcpClients {
@@ -506,43 +744,48 @@ func runMCPHost(ctx context.Context) error {
 	}
 
 	// 收集所有工具
-	var allTools []llm.Tool
-	for serverName, mcpClient := range mcpClients {
-		// 设置 10 秒的超时
-		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-		// 获取工具列表
-		toolsResult, err := mcpClient.ListTools(ctx, mcp.ListToolsRequest{})
-		cancel()
-
-		if err != nil {
-			log.Error(
-				"获取工具失败",
-				"server", serverName,
-				"error", err,
-			)
-			continue
-		}
-
-		// 将工具转换为支持的格式
-		serverTools := mcpToolsToAnthropicTools(serverName, toolsResult.Tools)
-		allTools = append(allTools, serverTools...)
-		log.Info(
-			"工具加载成功",
-			"server", serverName,
-			"count", len(toolsResult.Tools),
-		)
-	}
+	allTools := loadTools(ctx, mcpClients)
+	// 服务器运行中增减工具或提示模板时更新列表
+	watchListChanges(mcpClients)
+	// 服务器提供的提示模板作为 /server:prompt 命令使用
+	loadPrompts(ctx, mcpClients)
 
 	// 初始化渲染器
 	if err := updateRenderer(); err != nil {
 		return fmt.Errorf("初始化渲染器失败: %v", err)
 	}
 
-	// 用于存储消息历史
+	// 用于存储消息历史，恢复会话时从会话文件载入
 	messages := make([]history.HistoryMessage, 0)
+	currentSession := resumed
+	if currentSession == nil {
+		currentSession, err = sessionStore.Create(sessionID, modelFlag, serverNames(mcpClients))
+		if err != nil {
+			return fmt.Errorf("创建会话失败: %v", err)
+		}
+	} else {
+		messages = append(messages, currentSession.Messages...)
+		if currentSession.Model != modelFlag || !sameServers(currentSession.Servers, serverNames(mcpClients)) {
+			if err := sessionStore.UpdateMeta(currentSession, modelFlag, serverNames(mcpClients)); err != nil {
+				log.Warn("更新会话信息失败", "error", err)
+			}
+		}
+		log.Info("已恢复会话", "id", currentSession.ID, "messages", len(messages))
+	}
+	log.Info("会话已就绪", "id", currentSession.ID)
+
+	if oneShotPrompt != "" {
+		return runOneShot(ctx, provider, mcpClients, allTools, systemPrompt,
+			sessionStore, currentSession, messages, oneShotPrompt)
+	}
+
+	// 本次交互中用 /bg 提交的后台任务，结束时在输入提示前通知
+	bgTasks := make(chatTasks)
 
 	// 主交互循环
 	for {
+		bgTasks.notify()
+
 		// 获取用户输入的提示
 		var prompt string
 		err := huh.NewForm(huh.NewGroup(huh.NewText().
@@ -567,8 +810,60 @@ func runMCPHost(ctx context.Context) error {
 			continue
 		}
 
+		// 处理会话相关命令（/sessions、/resume）
+		handled, err := handleSessionCommand(
+			ctx,
+			prompt,
+			sessionStore,
+			&currentSession,
+			&messages,
+			&provider,
+			systemPrompt,
+			mcpClients,
+		)
+		if err != nil {
+			return err
+		}
+		if handled {
+			continue
+		}
+
+		// 处理后台任务命令（/bg、/tasks、/task）
+		if handleTaskCommand(prompt, bgTasks, sessionStore, currentSession, &messages) {
+			continue
+		}
+
+		// 服务器重新连接后工具可能有变化，每轮使用最新的工具列表
+		allTools = currentTools()
+		budget := contextBudget(systemPrompt, allTools)
+
+		// 按需压缩对话历史
+		if strings.EqualFold(strings.TrimSpace(prompt), "/compact") {
+			handleCompactCommand(ctx, provider, sessionStore, currentSession, &messages, budget)
+			continue
+		}
+
+		// MCP 服务器的提示模板（/server:prompt）：返回的消息加入对话，最后一条来自用户时调用模型回复
+		if promptMessages, handled := handlePromptCommand(ctx, prompt, mcpClients); handled {
+			if len(promptMessages) == 0 {
+				continue
+			}
+			start := len(messages)
+			messages = append(messages, promptMessages...)
+			if promptMessages[len(promptMessages)-1].Role == "user" {
+				err = runPrompt(ctx, provider, mcpClients, allTools, budget, "", &messages, nil)
+			}
+			if saveErr := sessionStore.Append(currentSession, messages[start:]...); saveErr != nil {
+				log.Error("保存会话失败", "id", currentSession.ID, "error", saveErr)
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
 		// 处理斜杠命令（如 /help 等）
-		handled, err := handleSlashCommand(
+		handled, err = handleSlashCommand(
 			prompt,
 			mcpConfig,
 			mcpClients,
@@ -581,19 +876,113 @@ func runMCPHost(ctx context.Context) error {
 			continue // 如果是命令处理过，则跳过后续操作
 		}
 
-		// 如果消息历史不为空，则清理过期的消息
-		if len(messages) > 0 {
-			messages = pruneMessages(messages)
+		// compact 策略下，历史超出预算时先自动压缩
+		if pruneStrategy == pruneStrategyCompact &&
+			totalTokens(messages)+history.EstimateTokens(prompt) > budget {
+			// 保留最近约一半预算的消息，其余替换为摘要
+			if _, err := compactHistory(ctx, provider, sessionStore, currentSession, &messages, budget, budget/2); err != nil {
+				log.Warn("自动压缩对话失败，将按 token 预算裁剪", "error", err)
+			}
 		}
 
-		// 调用模型生成回复
-		err = runPrompt(ctx, provider, mcpClients, allTools, prompt, &messages)
+		// 调用模型生成回复（发送前按上下文预算裁剪），并把本轮新增的消息追加到会话文件
+		start := len(messages)
+		err = runPrompt(ctx, provider, mcpClients, allTools, budget, prompt, &messages, nil)
+		if saveErr := sessionStore.Append(currentSession, messages[start:]...); saveErr != nil {
+			log.Error("保存会话失败", "id", currentSession.ID, "error", saveErr)
+		}
 		if err != nil {
 			return err
 		}
 	}
 }
 
+// configureLogging 根据调试模式和非交互模式设置日志级别
+func configureLogging() {
+	if debugMode {
+		log.SetLevel(log.DebugLevel) // 设置为调试级别
+		log.SetReportCaller(true)    // 启用日志中的调用者信息
+	} else if quietMode {
+		log.SetLevel(log.WarnLevel) // 非交互模式只输出警告和错误
+		log.SetReportCaller(false)
+	} else {
+		log.SetLevel(log.InfoLevel) // 设置为信息级别
+		log.SetReportCaller(false)  // 禁用调用者信息
+	}
+}
+
+// toolRegistry 记录每个 MCP 服务器当前提供的工具。服务器重新连接后会刷新其中的条目，
+// 每轮对话通过 currentTools 取得最新的工具列表。
+var toolRegistry = struct {
+	sync.RWMutex
+	m map[string][]llm.Tool
+}{m: make(map[string][]llm.Tool)}
+
+// setServerTools 替换服务器的工具列表
+func setServerTools(serverName string, tools []llm.Tool) {
+	toolRegistry.Lock()
+	defer toolRegistry.Unlock()
+	toolRegistry.m[serverName] = tools
+}
+
+// currentTools 返回所有服务器当前的工具，按服务器名排序，保证每轮请求中的工具顺序稳定
+func currentTools() []llm.Tool {
+	toolRegistry.RLock()
+	defer toolRegistry.RUnlock()
+	names := make([]string, 0, len(toolRegistry.m))
+	for name := range toolRegistry.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var allTools []llm.Tool
+	for _, name := range names {
+		allTools = append(allTools, toolRegistry.m[name]...)
+	}
+	return allTools
+}
+
+// loadTools 从 MCP 服务器获取工具列表，转换为 LLM 可用的格式并记录到 toolRegistry，
+// 返回所有服务器当前的工具。获取失败的服务器保留之前的工具列表。
+func loadTools(ctx context.Context, mcpClients map[string]mcpclient.MCPClient) []llm.Tool {
+	for serverName, mcpClient := range mcpClients {
+		// 设置 10 秒的超时
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		// 获取工具列表
+		toolsResult, err := mcpClient.ListTools(ctx, mcp.ListToolsRequest{})
+		cancel()
+
+		if err != nil {
+			log.Error(
+				"获取工具失败",
+				"server", serverName,
+				"error", err,
+			)
+			continue
+		}
+
+		// 将工具转换为支持的格式
+		setServerTools(serverName, mcpToolsToAnthropicTools(serverName, toolsResult.Tools))
+		log.Info(
+			"工具加载成功",
+			"server", serverName,
+			"count", len(toolsResult.Tools),
+		)
+	}
+	return currentTools()
+}
+
+// closeMCPClients 关闭所有 MCP 客户端
+func closeMCPClients(mcpClients map[string]mcpclient.MCPClient) {
+	log.Info("正在关闭 MCP 服务器...")
+	for name, client := range mcpClients {
+		if err := client.Close(); err != nil {
+			log.Error("关闭服务器失败", "name", name, "error", err)
+		} else {
+			log.Info("服务器已关闭", "name", name)
+		}
+	}
+}
+
 // loadSystemPrompt 从文件加载系统提示
 func loadSystemPrompt(filePath string) (string, error) {
 	// 如果没有提供文件路径，则返回空字符串
@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcphost/pkg/history"
+	"github.com/mark3labs/mcphost/pkg/llm"
+	"github.com/mark3labs/mcphost/pkg/mcpconn"
+)
+
+// methodCreateMessage 是 MCP 服务器请求客户端调用模型的方法
+const methodCreateMessage = "sampling/createMessage"
+
+// sampling 请求的处理方式
+const (
+	samplingAllow = "allow" // 直接调用模型，不询问用户
+	samplingDeny  = "deny"  // 拒绝请求
+)
+
+const (
+	// samplingApprovalTimeout 是等待用户确认 sampling 请求的时间，超时视为拒绝
+	samplingApprovalTimeout = 2 * time.Minute
+	// samplingTimeout 是一次 sampling 请求调用模型的超时时间
+	samplingTimeout = 2 * time.Minute
+	// samplingPreviewChars 是确认提示中每条消息最多显示的字符数
+	samplingPreviewChars = 300
+)
+
+// SamplingConfig 是配置文件中的 sampling 部分，控制 MCP 服务器通过 sampling/createMessage
+// 请求使用当前模型。交互模式下默认每次请求都询问用户，无法询问用户时（--prompt、serve、
+// 后台任务）按 headless 处理。
+type SamplingConfig struct {
+	Headless string            `json:"headless,omitempty"` // 无法询问用户时的处理方式：deny（默认）或 allow
+	Servers  map[string]string `json:"servers,omitempty"`  // 按服务器名指定 allow 或 deny，在所有模式下都不再询问
+}
+
+// Validate 检查配置中的处理方式是否有效
+func (c *SamplingConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	check := func(field, policy string) error {
+		switch policy {
+		case "", samplingAllow, samplingDeny:
+			return nil
+		default:
+			return fmt.Errorf("sampling.%s 不支持的取值: %q（可选 allow、deny）", field, policy)
+		}
+	}
+	if err := check("headless", c.Headless); err != nil {
+		return err
+	}
+	for name, policy := range c.Servers {
+		if err := check("servers."+name, policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// policy 返回 serverName 的请求在不询问用户时的处理方式，为空表示需要询问
+func (c *SamplingConfig) policy(serverName string, interactive bool) string {
+	if c != nil && c.Servers[serverName] != "" {
+		return c.Servers[serverName]
+	}
+	if interactive {
+		return ""
+	}
+	if c != nil && c.Headless == samplingAllow {
+		return samplingAllow
+	}
+	return samplingDeny
+}
+
+// samplingUsage 是一个服务器通过 sampling 累计使用的模型用量
+type samplingUsage struct {
+	Requests     int
+	InputTokens  int
+	OutputTokens int
+}
+
+// sampler 处理 MCP 服务器的 sampling 请求，用当前对话模型生成回复，并按服务器累计用量
+var sampler = &samplingHandler{}
+
+// samplingHandler 把服务器的 sampling 请求转交给当前对话模型
+type samplingHandler struct {
+	// Provider 是当前对话模型。没有创建模型的命令（例如 mcp-proxy）中为 nil，此时不声明 sampling 能力。
+	Provider llm.Provider
+	// Headless 为 true 时不在终端询问用户，按配置中的 headless 处理（例如 serve 处理的 HTTP 请求）
+	Headless bool
+
+	mu      sync.Mutex
+	allowed map[string]bool // 交互模式下用户选择了本次运行中始终允许的服务器
+	usage   map[string]samplingUsage
+}
+
+// Enabled 返回是否向服务器声明 sampling 能力
+func (s *samplingHandler) Enabled() bool {
+	return s.Provider != nil
+}
+
+// Usage 返回 serverName 累计的 sampling 用量
+func (s *samplingHandler) Usage(serverName string) (samplingUsage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.usage[serverName]
+	return u, ok
+}
+
+// record 把一次请求的令牌用量记到 serverName 名下
+func (s *samplingHandler) record(serverName string, input, output int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.usage == nil {
+		s.usage = make(map[string]samplingUsage)
+	}
+	u := s.usage[serverName]
+	u.Requests++
+	u.InputTokens += input
+	u.OutputTokens += output
+	s.usage[serverName] = u
+}
+
+// samplingRequest 是 sampling/createMessage 的参数中用到的部分。
+// maxTokens、temperature 等生成参数由 provider 的配置决定，这里不使用。
+type samplingRequest struct {
+	Messages []struct {
+		Role    string `json:"role"`
+		Content struct {
+			Type     string `json:"type"`
+			Text     string `json:"text"`
+			MIMEType string `json:"mimeType"`
+		} `json:"content"`
+	} `json:"messages"`
+	SystemPrompt string `json:"systemPrompt,omitempty"`
+	MaxTokens    int    `json:"maxTokens"`
+}
+
+// historyMessages 把请求中的消息转换为对话消息，图片只保留说明
+func (r samplingRequest) historyMessages() ([]history.HistoryMessage, error) {
+	var messages []history.HistoryMessage
+	for _, m := range r.Messages {
+		if m.Role != string(mcp.RoleUser) && m.Role != string(mcp.RoleAssistant) {
+			return nil, fmt.Errorf("不支持的消息角色: %q", m.Role)
+		}
+		text := m.Content.Text
+		if m.Content.Type != "text" {
+			text = fmt.Sprintf("（%s 内容 %s，已省略）", m.Content.Type, m.Content.MIMEType)
+		}
+		messages = append(messages, history.HistoryMessage{
+			Role:    m.Role,
+			Content: []history.ContentBlock{{Type: "text", Text: text}},
+		})
+	}
+	if len(messages) == 0 {
+		return nil, errors.New("请求中没有消息")
+	}
+	return messages, nil
+}
+
+// handler 返回处理 serverName 的 sampling 请求的函数
+func (s *samplingHandler) handler(serverName string, cfg *SamplingConfig) mcpconn.RequestHandler {
+	return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var req samplingRequest
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, fmt.Errorf("解析 sampling 请求失败: %w", err)
+		}
+		messages, err := req.historyMessages()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.approve(ctx, serverName, cfg, req); err != nil {
+			log.Warn("已拒绝 sampling 请求", "server", serverName, "reason", err)
+			return nil, err
+		}
+
+		// 服务器指定了系统提示时为该请求单独创建 provider，否则使用当前对话模型
+		provider := s.Provider
+		if req.SystemPrompt != "" {
+			if provider, err = createProvider(ctx, modelFlag, req.SystemPrompt); err != nil {
+				return nil, err
+			}
+			defer closeProvider(provider)
+		}
+		llmMessages := make([]llm.Message, len(messages))
+		for i := range messages {
+			llmMessages[i] = &messages[i]
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, samplingTimeout)
+		defer cancel()
+		message, err := provider.CreateMessage(ctx, "", llmMessages, nil)
+		if err != nil {
+			return nil, fmt.Errorf("调用模型失败: %w", err)
+		}
+		input, output := message.GetUsage()
+		s.record(serverName, input, output)
+		log.Info("sampling 令牌使用情况", "server", serverName,
+			"input", input, "output", output, "total", input+output)
+
+		_, model, _ := strings.Cut(modelFlag, ":")
+		return mcp.CreateMessageResult{
+			SamplingMessage: mcp.SamplingMessage{
+				Role:    mcp.RoleAssistant,
+				Content: mcp.TextContent{Type: "text", Text: message.GetContent()},
+			},
+			Model:      model,
+			StopReason: "endTurn",
+		}, nil
+	}
+}
+
+// approve 按配置决定是否处理请求，需要时在终端询问用户。拒绝时返回 CodeUserRejected 错误。
+func (s *samplingHandler) approve(ctx context.Context, serverName string, cfg *SamplingConfig, req samplingRequest) error {
+	rejected := func(msg string) error {
+		return &mcpconn.Error{Code: mcpconn.CodeUserRejected, Message: msg}
+	}
+
+	switch cfg.policy(serverName, !quietMode && !s.Headless) {
+	case samplingAllow:
+		return nil
+	case samplingDeny:
+		return rejected("mcphost 配置不允许该服务器使用 sampling")
+	}
+
+	s.mu.Lock()
+	allowed := s.allowed[serverName]
+	s.mu.Unlock()
+	if allowed {
+		return nil
+	}
+
+	var choice string
+	ctx, cancel := context.WithTimeout(ctx, samplingApprovalTimeout)
+	defer cancel()
+	asked := runInTerminal(ctx, func() {
+		fmt.Println()
+		err := huh.NewForm(huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(fmt.Sprintf("MCP 服务器 %s 请求使用当前模型生成回复", serverName)).
+				Description(req.preview()).
+				Options(
+					huh.NewOption("允许本次请求", "once"),
+					huh.NewOption(fmt.Sprintf("本次运行中始终允许 %s", serverName), "always"),
+					huh.NewOption("拒绝", samplingDeny),
+				).
+				Value(&choice),
+		)).WithWidth(getTerminalWidth()).
+			WithTheme(huh.ThemeCharm()).
+			Run()
+		if err != nil {
+			choice = samplingDeny
+		}
+	})
+	if !asked {
+		return rejected("等待用户确认超时")
+	}
+
+	switch choice {
+	case "always":
+		s.mu.Lock()
+		if s.allowed == nil {
+			s.allowed = make(map[string]bool)
+		}
+		s.allowed[serverName] = true
+		s.mu.Unlock()
+		return nil
+	case "once":
+		return nil
+	default:
+		return rejected("用户拒绝了 sampling 请求")
+	}
+}
+
+// preview 返回确认提示中展示的请求内容
+func (r samplingRequest) preview() string {
+	var sb strings.Builder
+	if r.SystemPrompt != "" {
+		sb.WriteString("系统提示：" + truncateRunes(r.SystemPrompt, samplingPreviewChars) + "\n")
+	}
+	for _, m := range r.Messages {
+		text := m.Content.Text
+		if m.Content.Type != "text" {
+			text = "（" + m.Content.Type + "）"
+		}
+		sb.WriteString(m.Role + "：" + truncateRunes(strings.TrimSpace(text), samplingPreviewChars) + "\n")
+	}
+	if r.MaxTokens > 0 {
+		sb.WriteString(fmt.Sprintf("最多 %d tokens", r.MaxTokens))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// truncateRunes 把 s 截断到最多 n 个字符
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mark3labs/mcphost/pkg/asyntask"
+	"github.com/spf13/cobra"
+)
+
+// scheduleWait 表示 run-now 后等待运行结束并输出结果
+var scheduleWait bool
+
+// scheduleCmd 管理配置文件中的定时计划
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "管理定时运行的提问",
+	Long: `按 cron 表达式定时提交提问，结果写到标准输出、文件或 webhook。
+
+定时计划在配置文件的 schedules 中定义，由任务执行进程运行，每次运行都是一个后台任务，
+可以用 mcphost task 查看。有启用的定时计划时执行进程不会因空闲退出，
+可以用 mcphost task worker 常驻运行。执行进程没有运行而错过的运行时间，
+默认在执行进程启动后补执行一次，missed 为 skip 时跳过。
+
+配置示例：
+  "schedules": {
+    "daily-digest": {
+      "cron": "0 9 * * 1-5",
+      "prompt": "总结昨天 docs 目录下新增的内容",
+      "sink": {"type": "file", "path": "digests/{name}-{date}.md"}
+    }
+  }
+
+示例：
+  mcphost schedule list
+  mcphost schedule run-now daily-digest -w`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		taskWorkerArgs = inheritedFlags(cmd)
+	},
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出定时计划及其下一次运行时间",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return runScheduleList()
+	},
+}
+
+var scheduleRunNowCmd = &cobra.Command{
+	Use:   "run-now <name>",
+	Short: "立即运行一次定时计划",
+	Long:  "立即把定时计划的提问作为后台任务提交，结果同样写到计划的输出位置。不影响计划的下一次运行时间，已暂停的计划也可以运行。",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return runScheduleRunNow(context.Background(), args[0])
+	},
+}
+
+func init() {
+	scheduleRunNowCmd.Flags().BoolVarP(&scheduleWait, "wait", "w", false, "等待运行结束并输出结果")
+	scheduleCmd.AddCommand(scheduleListCmd, scheduleRunNowCmd)
+	rootCmd.AddCommand(scheduleCmd)
+}
+
+func runScheduleList() error {
+	mcpConfig, err := loadMCPConfig()
+	if err != nil {
+		return fmt.Errorf("加载 MCP 配置失败: %v", err)
+	}
+	if len(mcpConfig.Schedules) == 0 {
+		fmt.Println("配置文件中没有定时计划。")
+		return nil
+	}
+	tasks, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	// 每个计划最近一次运行的任务，包括 run-now 提交的
+	lastRun := make(map[string]asyntask.Task)
+	for _, t := range tasks {
+		if t.Schedule != "" && !t.CreatedAt.Before(lastRun[t.Schedule].CreatedAt) {
+			lastRun[t.Schedule] = t
+		}
+	}
+
+	names := make([]string, 0, len(mcpConfig.Schedules))
+	for name := range mcpConfig.Schedules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+	enabled := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCRON\tNEXT\tLAST\tSINK")
+	for _, name := range names {
+		cfg := mcpConfig.Schedules[name]
+		next := ""
+		cron, err := cfg.Validate()
+		switch {
+		case err != nil:
+			next = "配置无效: " + err.Error()
+		case cfg.Disabled:
+			next = "已暂停"
+		default:
+			enabled++
+			if t := cron.Next(now); t.IsZero() {
+				next = "不会运行"
+			} else {
+				next = t.Format("2006-01-02 15:04")
+			}
+		}
+		last := "-"
+		if t, ok := lastRun[name]; ok {
+			last = fmt.Sprintf("%s %s (%s)", t.CreatedAt.Format("2006-01-02 15:04"), t.State, t.ID)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", name, cfg.Cron, next, last, cfg.Sink.Describe())
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if paths, err := getTaskPaths(); err == nil && enabled > 0 && !asyntask.LockHeld(paths.lock) {
+		fmt.Fprintln(os.Stderr, "\n没有执行进程在运行，定时计划不会按时运行。运行 mcphost task worker 启动执行进程。")
+	}
+	return nil
+}
+
+func runScheduleRunNow(ctx context.Context, name string) error {
+	mcpConfig, err := loadMCPConfig()
+	if err != nil {
+		return fmt.Errorf("加载 MCP 配置失败: %v", err)
+	}
+	cfg, ok := mcpConfig.Schedules[name]
+	if !ok {
+		return fmt.Errorf("定时计划不存在: %s", name)
+	}
+	if _, err := cfg.Validate(); err != nil {
+		return fmt.Errorf("定时计划 %s: %w", name, err)
+	}
+
+	task, err := submitTask(asyntask.Task{Question: cfg.Prompt, Model: cfg.Model, Schedule: name})
+	if err != nil {
+		return err
+	}
+	if !scheduleWait {
+		fmt.Println(task.ID)
+		return nil
+	}
+	return waitTaskResult(ctx, task)
+}
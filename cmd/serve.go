@@ -0,0 +1,558 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/log"
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcphost/pkg/history"
+	"github.com/mark3labs/mcphost/pkg/llm"
+	"github.com/mark3labs/mcphost/pkg/rag"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr   string   // HTTP 服务监听地址
+	serveAPIKey string   // 访问 API 所需的密钥，为空时不校验
+	serveModels []string // 除 --model 外允许请求指定的模型
+)
+
+// maxRequestBodyBytes 限制单个请求体的大小
+const maxRequestBodyBytes = 8 << 20
+
+// serveCmd 以 OpenAI 兼容的 HTTP API 形式提供模型和 MCP 工具
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "以 OpenAI 兼容的 HTTP API 提供服务",
+	Long: `启动 OpenAI 兼容的 HTTP 服务，提供 /v1/chat/completions 和 /v1/models 接口。
+请求由 --model 指定的模型处理，请求中的 model 只能是该模型或 --allow-model
+列出的模型。MCP 服务器提供的工具在服务端执行，
+客户端只会收到最终的回复内容。支持流式（SSE）和非流式响应。
+
+示例：
+  mcphost serve --addr :8080 -m openai:gpt-4o
+  curl http://localhost:8080/v1/chat/completions \
+    -d '{"messages":[{"role":"user","content":"你好"}],"stream":true}'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return runServe(context.Background())
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:8080", "HTTP 服务监听地址")
+	serveCmd.Flags().StringVar(&serveAPIKey, "api-key", "", "访问 API 所需的密钥（Bearer），也可通过 MCPHOST_API_KEY 环境变量设置")
+	serveCmd.Flags().StringSliceVar(&serveModels, "allow-model", nil, "除 --model 外允许请求指定的模型（provider:model），可重复或用逗号分隔")
+	rootCmd.AddCommand(serveCmd)
+}
+
+// apiServer 保存处理 API 请求所需的共享状态
+type apiServer struct {
+	provider     llm.Provider
+	models       []string // 请求可以指定的模型，第一个是 --model 指定的默认模型
+	systemPrompt string
+	mcpClients   map[string]mcpclient.MCPClient
+	apiKey       string
+	created      int64
+
+	// sampler 和 reranker 是服务进程自己的 sampling 处理和检索重排，不使用交互模式共用的全局实例
+	sampler  *samplingHandler
+	reranker *rag.LLMReranker
+}
+
+// runServe 加载模型和 MCP 服务器后启动 HTTP 服务，收到中断信号时优雅退出
+func runServe(ctx context.Context) error {
+	configureLogging()
+
+	systemPrompt, err := loadSystemPrompt(systemPromptFile)
+	if err != nil {
+		return fmt.Errorf("加载系统提示失败: %v", err)
+	}
+	// 要求模型用 [n] 标注回答所依据的检索结果和工具结果
+	systemPrompt = withCitationInstruction(systemPrompt)
+
+	models, err := allowedModels(modelFlag, serveModels)
+	if err != nil {
+		return err
+	}
+	provider, err := createProvider(ctx, modelFlag, systemPrompt)
+	if err != nil {
+		return fmt.Errorf("创建提供者失败: %v", err)
+	}
+	apiKey := serveAPIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("MCPHOST_API_KEY")
+	}
+	// 重排和 sampling 可能被多个请求同时调用，共用的 provider 需要串行化
+	shared := &lockedProvider{Provider: provider}
+	s := &apiServer{
+		provider:     provider,
+		models:       models,
+		systemPrompt: systemPrompt,
+		apiKey:       apiKey,
+		created:      time.Now().Unix(),
+		// 服务模式下无法在终端询问用户
+		sampler:  &samplingHandler{Provider: shared, Headless: true},
+		reranker: &rag.LLMReranker{Provider: shared},
+	}
+
+	mcpConfig, err := loadMCPConfig()
+	if err != nil {
+		return fmt.Errorf("加载 MCP 配置失败: %v", err)
+	}
+	mcpClients, err := createMCPClientsWith(mcpConfig, s.sampler, s.reranker)
+	if err != nil {
+		return fmt.Errorf("创建 MCP 客户端失败: %v", err)
+	}
+	defer closeMCPClients(mcpClients)
+	s.mcpClients = mcpClients
+
+	tools := loadTools(ctx, mcpClients)
+	watchListChanges(mcpClients)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/models", s.authorize(s.handleModels))
+	mux.HandleFunc("/v1/chat/completions", s.authorize(s.handleChatCompletions))
+
+	server := &http.Server{
+		Addr:              serveAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Info("API 服务已启动", "addr", serveAddr, "models", models, "tools", len(tools))
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("API 服务异常退出: %w", err)
+	case <-ctx.Done():
+		log.Info("正在关闭 API 服务...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+// authorize 在配置了 API 密钥时校验 Authorization 请求头
+func (s *apiServer) authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.apiKey != "" {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(s.apiKey)) != 1 {
+				writeAPIError(w, http.StatusUnauthorized, "invalid_api_key", "无效的 API 密钥")
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// handleModels 返回当前可用的模型列表
+func (s *apiServer) handleModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, "invalid_request_error", "只支持 GET 请求")
+		return
+	}
+	list := modelList{Object: "list"}
+	for i, model := range s.models {
+		owner, _, _ := strings.Cut(model, ":")
+		if i == 0 {
+			owner = s.provider.Name()
+		}
+		list.Data = append(list.Data, modelInfo{
+			ID:      model,
+			Object:  "model",
+			Created: s.created,
+			OwnedBy: owner,
+		})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// handleChatCompletions 处理对话请求：工具调用在服务端完成，客户端只收到模型的文本回复
+func (s *apiServer) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, http.StatusMethodNotAllowed, "invalid_request_error", "只支持 POST 请求")
+		return
+	}
+
+	var req chatCompletionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)).Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("解析请求失败: %v", err))
+		return
+	}
+	if len(req.Tools) > 0 {
+		log.Debug("忽略请求中的工具定义，使用 MCP 服务器提供的工具", "count", len(req.Tools))
+	}
+
+	systemPrompt, messages, err := convertChatMessages(req.Messages)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	if len(messages) == 0 {
+		writeAPIError(w, http.StatusBadRequest, "invalid_request_error", "messages 中至少需要一条用户消息")
+		return
+	}
+
+	provider, model, err := s.providerFor(r.Context(), req.Model, systemPrompt)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	defer closeProvider(provider)
+
+	completion := &completionWriter{
+		id:      "chatcmpl-" + randomHex(12),
+		created: time.Now().Unix(),
+		model:   model,
+	}
+	if req.Stream {
+		if err := completion.startStream(w); err != nil {
+			writeAPIError(w, http.StatusInternalServerError, "server_error", err.Error())
+			return
+		}
+	}
+
+	// 服务器重新连接后工具可能有变化，每个请求使用最新的工具列表
+	tools := currentTools()
+	budget := modelContextBudget(model, s.systemPrompt+systemPrompt, tools)
+	err = runPrompt(r.Context(), provider, s.mcpClients, tools, budget, "", &messages, completion.handleEvent)
+	if err != nil {
+		log.Error("处理对话请求失败", "id", completion.id, "error", err)
+	}
+
+	if req.Stream {
+		includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+		completion.finishStream(err, includeUsage)
+		return
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusBadGateway, "server_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, completion.response())
+}
+
+// providerFor 为每个请求单独创建 provider，请求的系统提示在创建时传入。
+// 调用方在请求结束后用 closeProvider 释放 provider 持有的客户端。
+func (s *apiServer) providerFor(ctx context.Context, model, systemPrompt string) (llm.Provider, string, error) {
+	model, err := s.resolveModel(model)
+	if err != nil {
+		return nil, "", err
+	}
+
+	prompt := s.systemPrompt
+	if systemPrompt != "" {
+		if prompt != "" {
+			prompt += "\n\n"
+		}
+		prompt += systemPrompt
+	}
+	provider, err := createProvider(ctx, model, prompt)
+	if err != nil {
+		return nil, "", fmt.Errorf("创建提供者失败: %v", err)
+	}
+	return provider, model, nil
+}
+
+// resolveModel 返回请求使用的模型：未指定时使用默认模型，指定了不在允许列表中的模型时返回错误，
+// 避免 API 调用方使用服务端有凭据的任意模型
+func (s *apiServer) resolveModel(model string) (string, error) {
+	if model == "" {
+		return s.models[0], nil
+	}
+	for _, allowed := range s.models {
+		if model == allowed {
+			return model, nil
+		}
+	}
+	return "", fmt.Errorf("不支持的模型 %q，可用的模型：%s", model, strings.Join(s.models, ", "))
+}
+
+// allowedModels 返回请求可以指定的模型列表，默认模型排在第一个
+func allowedModels(defaultModel string, extra []string) ([]string, error) {
+	models := []string{defaultModel}
+	for _, model := range extra {
+		model = strings.TrimSpace(model)
+		if model == "" || slices.Contains(models, model) {
+			continue
+		}
+		if !strings.Contains(model, ":") {
+			return nil, fmt.Errorf("--allow-model 格式错误，应为 provider:model，实际收到 %s", model)
+		}
+		models = append(models, model)
+	}
+	return models, nil
+}
+
+// convertChatMessages 将 OpenAI 格式的消息转换为消息历史，system 消息合并后单独返回
+func convertChatMessages(chatMessages []chatMessage) (string, []history.HistoryMessage, error) {
+	var system []string
+	var messages []history.HistoryMessage
+	for i, msg := range chatMessages {
+		text, err := msg.text()
+		if err != nil {
+			return "", nil, fmt.Errorf("messages[%d]: %v", i, err)
+		}
+
+		switch msg.Role {
+		case "system", "developer":
+			if text != "" {
+				system = append(system, text)
+			}
+		case "user":
+			messages = append(messages, history.HistoryMessage{
+				Role:    "user",
+				Content: []history.ContentBlock{{Type: "text", Text: text}},
+			})
+		case "assistant":
+			var blocks []history.ContentBlock
+			if text != "" {
+				blocks = append(blocks, history.ContentBlock{Type: "text", Text: text})
+			}
+			for _, call := range msg.ToolCalls {
+				input := json.RawMessage(call.Function.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, history.ContentBlock{
+					Type:  "tool_use",
+					ID:    call.ID,
+					Name:  call.Function.Name,
+					Input: input,
+				})
+			}
+			if len(blocks) > 0 {
+				messages = append(messages, history.HistoryMessage{Role: "assistant", Content: blocks})
+			}
+		case "tool":
+			messages = append(messages, history.HistoryMessage{
+				Role: "tool",
+				Content: []history.ContentBlock{{
+					Type:      "tool_result",
+					ToolUseID: msg.ToolCallID,
+					Text:      text,
+					Content:   []history.ContentBlock{{Type: "text", Text: text}},
+				}},
+			})
+		default:
+			return "", nil, fmt.Errorf("messages[%d]: 不支持的角色 %q", i, msg.Role)
+		}
+	}
+	return strings.Join(system, "\n\n"), messages, nil
+}
+
+// completionWriter 收集一次请求中模型生成的文本和令牌用量，流式请求时同时以 SSE 转发文本
+type completionWriter struct {
+	id      string
+	created int64
+	model   string
+
+	w       http.ResponseWriter
+	flusher http.Flusher
+
+	content      strings.Builder
+	turnHasText  bool // 当前这一轮模型回复是否已输出文本
+	separate     bool // 下一段文本前是否需要空行（工具调用前后的多轮回复之间）
+	inputTokens  int
+	outputTokens int
+}
+
+// handleEvent 处理 runPrompt 转发的流式事件
+func (c *completionWriter) handleEvent(event llm.StreamEvent) {
+	switch event.Type {
+	case llm.StreamEventText:
+		if event.Text == "" {
+			return
+		}
+		text := event.Text
+		if c.separate {
+			text = "\n\n" + text
+			c.separate = false
+		}
+		c.turnHasText = true
+		c.content.WriteString(text)
+		if c.w != nil {
+			c.writeChunk(chatDelta{Content: text}, nil)
+		}
+	case llm.StreamEventUsage:
+		c.inputTokens += event.InputTokens
+		c.outputTokens += event.OutputTokens
+	case llm.StreamEventDone:
+		if c.turnHasText {
+			c.separate = true
+			c.turnHasText = false
+		}
+	}
+}
+
+// startStream 写入 SSE 响应头和第一个包含角色的数据块
+func (c *completionWriter) startStream(w http.ResponseWriter) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return errors.New("当前连接不支持流式响应")
+	}
+	c.w = w
+	c.flusher = flusher
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	c.writeChunk(chatDelta{Role: "assistant"}, nil)
+	return nil
+}
+
+// finishStream 写入结束块（或错误）、可选的用量统计和 [DONE] 标记
+func (c *completionWriter) finishStream(err error, includeUsage bool) {
+	if err != nil {
+		c.writeData(apiErrorResponse{Error: apiError{Message: err.Error(), Type: "server_error"}})
+	} else {
+		stop := "stop"
+		c.writeChunk(chatDelta{}, &stop)
+		if includeUsage {
+			usage := c.usage()
+			c.writeData(chatCompletionChunk{
+				ID:      c.id,
+				Object:  "chat.completion.chunk",
+				Created: c.created,
+				Model:   c.model,
+				Choices: []chatChunkChoice{},
+				Usage:   &usage,
+			})
+		}
+	}
+	fmt.Fprint(c.w, "data: [DONE]\n\n")
+	c.flusher.Flush()
+}
+
+func (c *completionWriter) writeChunk(delta chatDelta, finishReason *string) {
+	c.writeData(chatCompletionChunk{
+		ID:      c.id,
+		Object:  "chat.completion.chunk",
+		Created: c.created,
+		Model:   c.model,
+		Choices: []chatChunkChoice{{
+			Index:        0,
+			Delta:        delta,
+			FinishReason: finishReason,
+		}},
+	})
+}
+
+func (c *completionWriter) writeData(v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Error("序列化响应失败", "error", err)
+		return
+	}
+	fmt.Fprintf(c.w, "data: %s\n\n", data)
+	c.flusher.Flush()
+}
+
+func (c *completionWriter) usage() chatUsage {
+	return chatUsage{
+		PromptTokens:     c.inputTokens,
+		CompletionTokens: c.outputTokens,
+		TotalTokens:      c.inputTokens + c.outputTokens,
+	}
+}
+
+// response 返回非流式请求的完整响应
+func (c *completionWriter) response() chatCompletionResponse {
+	return chatCompletionResponse{
+		ID:      c.id,
+		Object:  "chat.completion",
+		Created: c.created,
+		Model:   c.model,
+		Choices: []chatChoice{{
+			Index: 0,
+			Message: chatResponseMessage{
+				Role:    "assistant",
+				Content: c.content.String(),
+			},
+			FinishReason: "stop",
+		}},
+		Usage: c.usage(),
+	}
+}
+
+// lockedProvider 串行化对一个共用 provider 的调用。流式调用在事件通道关闭后才释放锁。
+type lockedProvider struct {
+	llm.Provider
+	mu sync.Mutex
+}
+
+func (p *lockedProvider) CreateMessage(ctx context.Context, prompt string, messages []llm.Message, tools []llm.Tool) (llm.Message, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.Provider.CreateMessage(ctx, prompt, messages, tools)
+}
+
+func (p *lockedProvider) StreamMessage(ctx context.Context, prompt string, messages []llm.Message, tools []llm.Tool) (<-chan llm.StreamEvent, error) {
+	p.mu.Lock()
+	events, err := p.Provider.StreamMessage(ctx, prompt, messages, tools)
+	if err != nil {
+		p.mu.Unlock()
+		return nil, err
+	}
+	out := make(chan llm.StreamEvent)
+	go func() {
+		defer p.mu.Unlock()
+		defer close(out)
+		for event := range events {
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				// 调用方不再读取时丢弃剩余事件，直到 provider 结束流
+				for range events {
+				}
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error("写入响应失败", "error", err)
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, errType, message string) {
+	writeJSON(w, status, apiErrorResponse{Error: apiError{Message: message, Type: errType}})
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestServeResolveModel(t *testing.T) {
+	models, err := allowedModels("ollama:qwen3:1.7b", []string{"openai:gpt-4o", " ollama:qwen3:1.7b", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 默认模型排在第一个，重复和空的模型被忽略
+	if fmt.Sprint(models) != "[ollama:qwen3:1.7b openai:gpt-4o]" {
+		t.Fatalf("允许的模型 = %v", models)
+	}
+	s := &apiServer{models: models}
+
+	tests := []struct {
+		name  string
+		model string
+		want  string
+		ok    bool
+	}{
+		{"未指定时使用默认模型", "", "ollama:qwen3:1.7b", true},
+		{"默认模型", "ollama:qwen3:1.7b", "ollama:qwen3:1.7b", true},
+		{"允许列表中的模型", "openai:gpt-4o", "openai:gpt-4o", true},
+		{"不在允许列表中", "anthropic:claude-3-5-sonnet-latest", "", false},
+		{"没有 provider 前缀", "gpt-4o", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.resolveModel(tt.model)
+			if (err == nil) != tt.ok || got != tt.want {
+				t.Fatalf("resolveModel(%q) = %q, %v，期望 %q", tt.model, got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := allowedModels("ollama:qwen3:1.7b", []string{"gpt-4o"}); err == nil {
+		t.Fatal("没有 provider 前缀的 --allow-model 应该报错")
+	}
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcphost/pkg/history"
+	"github.com/mark3labs/mcphost/pkg/llm"
+	"github.com/mark3labs/mcphost/pkg/rag"
+	"github.com/mark3labs/mcphost/pkg/session"
+)
+
+// getDataDir 返回数据目录（优先使用 --data-dir，否则为 $HOME/.mcphost）
+func getDataDir() (string, error) {
+	if dataDir != "" {
+		return dataDir, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户主目录失败: %w", err)
+	}
+	return filepath.Join(homeDir, ".mcphost"), nil
+}
+
+// openSessionStore 打开数据目录下的会话存储
+func openSessionStore() (*session.Store, error) {
+	dir, err := getDataDir()
+	if err != nil {
+		return nil, err
+	}
+	return session.NewStore(filepath.Join(dir, "sessions"))
+}
+
+// resumeSessionFromFlags 根据 --session / --continue 参数加载需要恢复的会话，
+// 没有需要恢复的会话时返回 nil
+func resumeSessionFromFlags(store *session.Store) (*session.Session, error) {
+	switch {
+	case sessionID != "":
+		sess, err := store.Load(sessionID)
+		if errors.Is(err, session.ErrNotFound) {
+			// 指定的会话不存在时，稍后以该 ID 创建新会话
+			return nil, nil
+		}
+		return sess, err
+	case continueSession:
+		sess, err := store.Latest()
+		if errors.Is(err, session.ErrNotFound) {
+			log.Warn("没有可继续的会话，将创建新会话")
+			return nil, nil
+		}
+		return sess, err
+	default:
+		return nil, nil
+	}
+}
+
+// restrictServers 只保留会话中记录的 MCP 服务器，使恢复后的工具集与原会话一致
+func restrictServers(config *MCPConfig, servers []string) {
+	keep := make(map[string]bool, len(servers))
+	for _, name := range servers {
+		keep[name] = true
+		if name == rag.ServerName && config.RAG != nil {
+			continue
+		}
+		if _, ok := config.MCPServers[name]; !ok {
+			log.Warn("会话使用的 MCP 服务器已不在配置中", "server", name)
+		}
+	}
+	for name := range config.MCPServers {
+		if !keep[name] {
+			delete(config.MCPServers, name)
+		}
+	}
+	if !keep[rag.ServerName] {
+		config.RAG = nil
+	}
+}
+
+// serverNames 返回已连接的 MCP 服务器名称（已排序）
+func serverNames(mcpClients map[string]mcpclient.MCPClient) []string {
+	names := make([]string, 0, len(mcpClients))
+	for name := range mcpClients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sameServers 判断两组服务器名称是否相同
+func sameServers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// handleSessionCommand 处理 /sessions 和 /resume 命令。
+// /resume 会替换当前的消息历史，必要时按会话记录的模型重新创建 provider。
+func handleSessionCommand(
+	ctx context.Context,
+	prompt string,
+	store *session.Store,
+	current **session.Session,
+	messages *[]history.HistoryMessage,
+	provider *llm.Provider,
+	systemPrompt string,
+	mcpClients map[string]mcpclient.MCPClient,
+) (bool, error) {
+	fields := strings.Fields(prompt)
+	if len(fields) == 0 {
+		return false, nil
+	}
+
+	switch strings.ToLower(fields[0]) {
+	case "/sessions":
+		handleSessionsCommand(store, (*current).ID)
+		return true, nil
+	case "/resume":
+		var sess *session.Session
+		var err error
+		if len(fields) > 1 {
+			sess, err = store.Load(fields[1])
+		} else {
+			sess, err = latestOtherSession(store, (*current).ID)
+		}
+		if err != nil {
+			fmt.Printf("\n%s\n\n", errorStyle.Render(fmt.Sprintf("恢复会话失败: %v", err)))
+			return true, nil
+		}
+
+		if sess.Model != "" && sess.Model != modelFlag {
+			p, err := createProvider(ctx, sess.Model, systemPrompt)
+			if err != nil {
+				fmt.Printf("\n%s\n\n", errorStyle.Render(
+					fmt.Sprintf("无法切换到会话使用的模型 %s: %v", sess.Model, err)))
+				return true, nil
+			}
+			*provider = p
+			ragReranker.Provider = p
+			sampler.Provider = p
+			modelFlag = sess.Model
+			log.Info("已切换模型", "model", sess.Model)
+		}
+		if !sameServers(sess.Servers, serverNames(mcpClients)) {
+			log.Warn("当前连接的 MCP 服务器与会话记录不同，部分历史工具调用可能无法继续",
+				"session", strings.Join(sess.Servers, ","),
+				"current", strings.Join(serverNames(mcpClients), ","))
+		}
+
+		*current = sess
+		*messages = append([]history.HistoryMessage(nil), sess.Messages...)
+		log.Info("已恢复会话", "id", sess.ID, "messages", len(sess.Messages))
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// latestOtherSession 返回除当前会话外最近更新的会话
+func latestOtherSession(store *session.Store, currentID string) (*session.Session, error) {
+	summaries, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, summary := range summaries {
+		if summary.ID != currentID {
+			return store.Load(summary.ID)
+		}
+	}
+	return nil, session.ErrNotFound
+}
+
+// handleSessionsCommand 列出所有已保存的会话
+func handleSessionsCommand(store *session.Store, currentID string) {
+	if err := updateRenderer(); err != nil {
+		fmt.Printf("\n%s\n", errorStyle.Render(fmt.Sprintf("更新渲染器失败: %v", err)))
+		return
+	}
+
+	summaries, err := store.List()
+	if err != nil {
+		fmt.Printf("\n%s\n", errorStyle.Render(fmt.Sprintf("读取会话列表失败: %v", err)))
+		return
+	}
+
+	var markdown strings.Builder
+	markdown.WriteString("# 会话列表\n\n")
+	if len(summaries) == 0 {
+		markdown.WriteString("暂无已保存的会话。\n")
+	}
+	for _, summary := range summaries {
+		marker := ""
+		if summary.ID == currentID {
+			marker = " *(当前)*"
+		}
+		markdown.WriteString(fmt.Sprintf("- **%s**%s  \n", summary.ID, marker))
+		markdown.WriteString(fmt.Sprintf("  `%s` · %d 条消息 · 更新于 %s",
+			summary.Model, summary.MessageCount, summary.UpdatedAt.Format("2006-01-02 15:04")))
+		if summary.Title != "" {
+			markdown.WriteString("  \n  " + summary.Title)
+		}
+		markdown.WriteString("\n")
+	}
+	markdown.WriteString("\n使用 `/resume <id>` 恢复会话。\n")
+
+	rendered, err := renderer.Render(markdown.String())
+	if err != nil {
+		fmt.Printf("\n%s\n", errorStyle.Render(fmt.Sprintf("渲染会话列表失败: %v", err)))
+		return
+	}
+	fmt.Print(rendered)
+}
@@ -0,0 +1,913 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/log"
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcphost/pkg/asyntask"
+	"github.com/mark3labs/mcphost/pkg/history"
+	"github.com/mark3labs/mcphost/pkg/llm"
+	"github.com/mark3labs/mcphost/pkg/schedule"
+	"github.com/mark3labs/mcphost/pkg/session"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+// taskPollInterval 是等待任务状态变化时读取任务存储的间隔
+const taskPollInterval = time.Second
+
+var (
+	taskWait        bool          // submit 后等待任务结束并输出结果
+	taskFollow      bool          // logs 持续输出新消息，直到任务结束
+	taskAll         bool          // list 显示全部任务，而不只是最近的任务
+	taskIdleTimeout time.Duration // 执行进程在没有任务时等待多久后退出，0 表示一直运行
+
+	// taskWorkerArgs 是启动执行进程时传递的全局参数，使其与当前命令使用相同的配置
+	taskWorkerArgs []string
+)
+
+// TaskConfig 是配置文件中的 tasks 部分，控制后台任务的执行
+type TaskConfig struct {
+	Workers     int    `json:"workers,omitempty"`      // 同时执行的任务数，默认 2
+	Recovery    string `json:"recovery,omitempty"`     // 上次执行被中断的任务如何处理：retry（默认）或 fail
+	MaxAttempts int    `json:"max_attempts,omitempty"` // retry 策略下每个任务最多执行的次数，默认 3
+}
+
+// taskCmd 管理在后台执行的任务
+var taskCmd = &cobra.Command{
+	Use:   "task",
+	Short: "管理在后台执行的任务",
+	Long: `把耗时的提问交给后台执行，完成后再查看结果。
+
+任务由独立的执行进程运行：提交任务时如果没有执行进程在运行，会自动在后台启动一个，
+它使用与提交命令相同的 --config、--model 等参数，所有任务完成一段时间后自动退出。
+任务和执行过程中产生的消息保存在数据目录下，进程崩溃或退出后，
+未完成的任务会在下次启动执行进程时按配置中 tasks.recovery 的策略重试或标记为失败。
+
+示例：
+  mcphost task submit "调研一下这个仓库的测试覆盖情况"
+  mcphost task list
+  mcphost task logs 20250101-120000-a1b2c3 -f
+  mcphost task show 20250101-120000-a1b2c3
+  mcphost task cancel 20250101-120000-a1b2c3`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		taskWorkerArgs = inheritedFlags(cmd)
+	},
+}
+
+var taskSubmitCmd = &cobra.Command{
+	Use:   "submit [prompt]",
+	Short: "提交一个后台任务",
+	Long:  "提交一个后台任务并输出任务 ID。没有参数时从标准输入读取任务内容。",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return runTaskSubmit(context.Background(), args)
+	},
+}
+
+var taskListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出后台任务",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return runTaskList()
+	},
+}
+
+var taskShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "显示任务的状态和结果",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		task, err := findTask(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Print(formatTask(task))
+		return nil
+	},
+}
+
+var taskCancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "取消排队中或运行中的任务",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		task, err := cancelTask(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已请求取消任务 %s\n", task.ID)
+		return nil
+	},
+}
+
+var taskLogsCmd = &cobra.Command{
+	Use:   "logs <id>",
+	Short: "输出任务执行过程中的消息",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return runTaskLogs(context.Background(), args[0])
+	},
+}
+
+var taskWorkerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "在前台运行任务执行进程",
+	Long: `运行执行后台任务的进程。提交任务时会自动启动执行进程，通常不需要手动运行；
+需要常驻的执行进程时可以使用 --idle-timeout 0。同一数据目录下只会有一个执行进程。`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return runTaskWorker(context.Background())
+	},
+}
+
+func init() {
+	taskSubmitCmd.Flags().BoolVarP(&taskWait, "wait", "w", false, "等待任务结束并输出结果")
+	taskListCmd.Flags().BoolVarP(&taskAll, "all", "a", false, "显示全部任务（默认只显示最近 20 个）")
+	taskLogsCmd.Flags().BoolVarP(&taskFollow, "follow", "f", false, "持续输出新消息，直到任务结束")
+	taskWorkerCmd.Flags().DurationVar(&taskIdleTimeout, "idle-timeout", time.Minute, "没有任务时等待多久后退出，0 表示一直运行")
+	taskCmd.AddCommand(taskSubmitCmd, taskListCmd, taskShowCmd, taskCancelCmd, taskLogsCmd, taskWorkerCmd)
+	rootCmd.AddCommand(taskCmd)
+}
+
+// inheritedFlags 返回命令行中显式指定的全局参数，用于以相同配置启动执行进程
+func inheritedFlags(cmd *cobra.Command) []string {
+	var args []string
+	// 子命令解析后的参数记录在子命令的 FlagSet 中，这里只能根据 Changed 判断
+	cmd.Root().PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed {
+			args = append(args, "--"+f.Name+"="+f.Value.String())
+		}
+	})
+	return args
+}
+
+// taskPaths 是数据目录下后台任务使用的文件
+type taskPaths struct {
+	store     string // 任务及其消息
+	inbox     string // 其他进程提交的请求
+	lock      string // 执行进程的锁
+	log       string // 自动启动的执行进程的日志
+	schedules string // 定时计划的运行情况
+}
+
+func getTaskPaths() (taskPaths, error) {
+	dir, err := getDataDir()
+	if err != nil {
+		return taskPaths{}, err
+	}
+	dir = filepath.Join(dir, "tasks")
+	return taskPaths{
+		store:     filepath.Join(dir, "tasks.jsonl"),
+		inbox:     filepath.Join(dir, "inbox"),
+		lock:      filepath.Join(dir, "worker.lock"),
+		log:       filepath.Join(dir, "worker.log"),
+		schedules: filepath.Join(dir, "schedules.json"),
+	}, nil
+}
+
+// submitTask 把任务放入收件箱，并确保有执行进程在运行。任务没有指定模型时使用 --model。
+func submitTask(task asyntask.Task) (asyntask.Task, error) {
+	paths, err := getTaskPaths()
+	if err != nil {
+		return asyntask.Task{}, err
+	}
+	inbox, err := asyntask.OpenInbox(paths.inbox)
+	if err != nil {
+		return asyntask.Task{}, err
+	}
+	if task.Model == "" {
+		task.Model = modelFlag
+	}
+	task, err = inbox.Submit(task)
+	if err != nil {
+		return asyntask.Task{}, err
+	}
+	if err := ensureTaskWorker(paths); err != nil {
+		return task, fmt.Errorf("任务 %s 已提交，但启动执行进程失败: %w", task.ID, err)
+	}
+	return task, nil
+}
+
+// cancelTask 请求取消任务，任务已结束时返回错误
+func cancelTask(id string) (asyntask.Task, error) {
+	task, err := findTask(id)
+	if err != nil {
+		return asyntask.Task{}, err
+	}
+	if task.State.Terminal() {
+		return task, fmt.Errorf("%w: %s（%s）", asyntask.ErrFinished, task.ID, task.State)
+	}
+	paths, err := getTaskPaths()
+	if err != nil {
+		return task, err
+	}
+	inbox, err := asyntask.OpenInbox(paths.inbox)
+	if err != nil {
+		return task, err
+	}
+	if err := inbox.Cancel(task.ID); err != nil {
+		return task, err
+	}
+	return task, ensureTaskWorker(paths)
+}
+
+// loadTasks 返回所有任务，包括还在收件箱中、尚未被执行进程接收的任务
+func loadTasks() ([]asyntask.Task, error) {
+	paths, err := getTaskPaths()
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := asyntask.LoadTasks(paths.store)
+	if err != nil {
+		return nil, err
+	}
+	inbox, err := asyntask.OpenInbox(paths.inbox)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		known[t.ID] = true
+	}
+	for _, t := range inbox.Pending() {
+		if !known[t.ID] {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+// findTask 按 ID 查找任务，也可以只给出 ID 的唯一前缀
+func findTask(id string) (asyntask.Task, error) {
+	tasks, err := loadTasks()
+	if err != nil {
+		return asyntask.Task{}, err
+	}
+	var matches []asyntask.Task
+	for _, t := range tasks {
+		if t.ID == id {
+			return t, nil
+		}
+		if strings.HasPrefix(t.ID, id) {
+			matches = append(matches, t)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return asyntask.Task{}, fmt.Errorf("%w: %s", asyntask.ErrNotFound, id)
+	case 1:
+		return matches[0], nil
+	default:
+		return asyntask.Task{}, fmt.Errorf("任务 ID 前缀 %s 匹配到 %d 个任务，请给出更完整的 ID", id, len(matches))
+	}
+}
+
+// waitTask 定期读取任务存储，直到任务结束或 ctx 取消
+func waitTask(ctx context.Context, id string, onUpdate func(asyntask.Task)) (asyntask.Task, error) {
+	ticker := time.NewTicker(taskPollInterval)
+	defer ticker.Stop()
+	for {
+		task, err := findTask(id)
+		if err != nil {
+			return task, err
+		}
+		if onUpdate != nil {
+			onUpdate(task)
+		}
+		if task.State.Terminal() {
+			return task, nil
+		}
+		select {
+		case <-ctx.Done():
+			return task, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ensureTaskWorker 在没有执行进程运行时，以当前的全局参数在后台启动一个
+func ensureTaskWorker(paths taskPaths) error {
+	if asyntask.LockHeld(paths.lock) {
+		return nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("获取可执行文件路径失败: %w", err)
+	}
+	logFile, err := os.OpenFile(paths.log, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("打开执行进程日志失败: %w", err)
+	}
+	defer logFile.Close()
+
+	worker := exec.Command(exe, append([]string{"task", "worker"}, taskWorkerArgs...)...)
+	worker.Stdout = logFile
+	worker.Stderr = logFile
+	if err := worker.Start(); err != nil {
+		return fmt.Errorf("启动执行进程失败: %w", err)
+	}
+	log.Debug("已启动任务执行进程", "pid", worker.Process.Pid, "log", paths.log)
+	return worker.Process.Release()
+}
+
+func runTaskSubmit(ctx context.Context, args []string) error {
+	question := strings.Join(args, " ")
+	if question == "" && !term.IsTerminal(int(os.Stdin.Fd())) {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("读取标准输入失败: %w", err)
+		}
+		question = string(data)
+	}
+	if strings.TrimSpace(question) == "" {
+		return errors.New("请提供任务内容")
+	}
+
+	task, err := submitTask(asyntask.Task{Question: question})
+	if err != nil {
+		return err
+	}
+	if !taskWait {
+		fmt.Println(task.ID)
+		return nil
+	}
+	return waitTaskResult(ctx, task)
+}
+
+// waitTaskResult 等待刚提交的任务结束并输出结果，任务没有成功时返回错误
+func waitTaskResult(ctx context.Context, task asyntask.Task) error {
+	fmt.Fprintf(os.Stderr, "已提交任务 %s，等待执行结束...\n", task.ID)
+	task, err := waitTask(ctx, task.ID, nil)
+	if err != nil {
+		return err
+	}
+	if task.State != asyntask.StateSucceeded {
+		return fmt.Errorf("任务 %s %s: %s", task.ID, task.State, task.Error)
+	}
+	fmt.Println(task.Result)
+	if footer := formatSources(collectOneShotResult(task.Messages).Sources); footer != "" {
+		fmt.Printf("\n%s\n", footer)
+	}
+	return nil
+}
+
+func runTaskList() error {
+	tasks, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		fmt.Println("暂无后台任务。")
+		return nil
+	}
+	unfinished := 0
+	for _, t := range tasks {
+		if !t.State.Terminal() {
+			unfinished++
+		}
+	}
+	if !taskAll {
+		tasks = recentTasks(tasks, 20)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATE\tCREATED\tQUESTION")
+	for _, t := range tasks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.ID, t.State, t.CreatedAt.Format("2006-01-02 15:04"), taskTitle(t))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// 执行进程崩溃后，未完成的任务要等下次启动执行进程时才会继续
+	if paths, err := getTaskPaths(); err == nil && unfinished > 0 && !asyntask.LockHeld(paths.lock) {
+		fmt.Fprintf(os.Stderr, "\n有 %d 个未完成的任务，但没有执行进程在运行。运行 mcphost task worker 或提交新任务后会继续执行。\n", unfinished)
+	}
+	return nil
+}
+
+func runTaskLogs(ctx context.Context, id string) error {
+	printed := 0
+	printNew := func(task asyntask.Task) {
+		for _, msg := range task.Messages[printed:] {
+			fmt.Print(transcriptOf(msg))
+		}
+		printed = len(task.Messages)
+	}
+
+	if !taskFollow {
+		task, err := findTask(id)
+		if err != nil {
+			return err
+		}
+		printNew(task)
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	task, err := waitTask(ctx, id, printNew)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		return err
+	}
+	fmt.Printf("\n任务 %s 已结束：%s\n", task.ID, task.State)
+	return nil
+}
+
+// runTaskWorker 持有任务存储并执行任务，直到收到退出信号或空闲超过 --idle-timeout
+func runTaskWorker(ctx context.Context) error {
+	// 执行进程没有终端交互
+	quietMode = true
+	configureLogging()
+	if !debugMode {
+		log.SetLevel(log.InfoLevel)
+	}
+
+	paths, err := getTaskPaths()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(paths.lock), 0700); err != nil {
+		return fmt.Errorf("创建任务目录失败: %w", err)
+	}
+	lock, err := asyntask.AcquireLock(paths.lock)
+	if err != nil {
+		if errors.Is(err, asyntask.ErrLocked) {
+			log.Info("已有执行进程在运行", "detail", err)
+			return nil
+		}
+		return err
+	}
+	defer lock.Release()
+
+	systemPrompt, err := loadSystemPrompt(systemPromptFile)
+	if err != nil {
+		return fmt.Errorf("加载系统提示失败: %v", err)
+	}
+	systemPrompt = withCitationInstruction(systemPrompt)
+
+	mcpConfig, err := loadMCPConfig()
+	if err != nil {
+		return fmt.Errorf("加载 MCP 配置失败: %v", err)
+	}
+	cfg := TaskConfig{}
+	if mcpConfig.Tasks != nil {
+		cfg = *mcpConfig.Tasks
+	}
+	recovery, err := asyntask.ParseRecovery(cfg.Recovery)
+	if err != nil {
+		return err
+	}
+
+	providers := &taskProviders{ctx: ctx, systemPrompt: systemPrompt, providers: make(map[string]llm.Provider)}
+	provider, err := providers.get(modelFlag)
+	if err != nil {
+		return fmt.Errorf("创建提供者失败: %v", err)
+	}
+	ragReranker.Provider = provider
+	sampler.Provider = provider
+
+	mcpClients, err := createMCPClients(mcpConfig)
+	if err != nil {
+		return fmt.Errorf("创建 MCP 客户端失败: %v", err)
+	}
+	defer closeMCPClients(mcpClients)
+	tools := loadTools(ctx, mcpClients)
+	watchListChanges(mcpClients)
+
+	store, err := asyntask.OpenStore(paths.store)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	inbox, err := asyntask.OpenInbox(paths.inbox)
+	if err != nil {
+		return err
+	}
+
+	// 定时计划到点后直接提交到队列，不经过收件箱
+	var queue *asyntask.TaskQueue
+	scheduler, err := schedule.NewScheduler(mcpConfig.Schedules, paths.schedules, func(name string, sc schedule.Config) (string, error) {
+		task, err := queue.Submit(asyntask.Task{Question: sc.Prompt, Model: sc.Model, Schedule: name})
+		return task.ID, err
+	})
+	if err != nil {
+		return err
+	}
+	dataDir, err := getDataDir()
+	if err != nil {
+		return err
+	}
+
+	queue = asyntask.New(newTaskRunner(providers.get, mcpClients, systemPrompt), asyntask.Options{
+		Workers:     cfg.Workers,
+		Store:       store,
+		Recovery:    recovery,
+		MaxAttempts: cfg.MaxAttempts,
+		OnFinish:    deliverScheduledRun(mcpConfig.Schedules, dataDir),
+	})
+	defer queue.Close()
+	log.Info("任务执行进程已启动", "pid", os.Getpid(), "tools", len(tools), "schedules", scheduler.Len())
+	if scheduler.Len() > 0 {
+		if err := scheduler.Start(time.Now()); err != nil {
+			log.Warn("保存定时计划状态失败", "error", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(taskPollInterval)
+	defer ticker.Stop()
+	lastActive := time.Now()
+	for {
+		if scheduler.Len() > 0 {
+			if err := scheduler.Tick(time.Now()); err != nil {
+				log.Warn("保存定时计划状态失败", "error", err)
+			}
+		}
+		inbox.Drain(queue)
+		for _, t := range queue.List() {
+			if !t.State.Terminal() {
+				lastActive = time.Now()
+				break
+			}
+		}
+		// 有启用的定时计划时执行进程需要常驻
+		if taskIdleTimeout > 0 && scheduler.Len() == 0 && time.Since(lastActive) >= taskIdleTimeout {
+			log.Info("没有待执行的任务，执行进程退出")
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info("正在关闭任务执行进程...")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverScheduledRun 返回把定时计划提交的任务结果写到计划的输出位置的 OnFinish，
+// 其他任务被忽略。file 输出的相对路径相对于数据目录。
+func deliverScheduledRun(schedules map[string]schedule.Config, dataDir string) func(asyntask.Task) {
+	return func(task asyntask.Task) {
+		if task.Schedule == "" {
+			return
+		}
+		cfg, ok := schedules[task.Schedule]
+		if !ok {
+			log.Warn("任务所属的定时计划已不在配置中，结果未投递", "schedule", task.Schedule, "task", task.ID)
+			return
+		}
+		run := schedule.Run{
+			Schedule:   task.Schedule,
+			Task:       task.ID,
+			State:      string(task.State),
+			Result:     task.Result,
+			Error:      task.Error,
+			StartedAt:  task.StartedAt,
+			FinishedAt: task.FinishedAt,
+		}
+		for _, src := range collectOneShotResult(task.Messages).Sources {
+			run.Sources = append(run.Sources, fmt.Sprintf("[%d] %s", src.Index, src.Location()))
+		}
+		if err := schedule.Deliver(context.Background(), cfg.Sink, run, os.Stdout, dataDir); err != nil {
+			log.Error("投递定时运行结果失败", "schedule", task.Schedule, "task", task.ID, "error", err)
+			return
+		}
+		log.Info("已投递定时运行结果", "schedule", task.Schedule, "task", task.ID, "sink", cfg.Sink.Describe())
+	}
+}
+
+// taskProviders 按任务指定的模型创建并复用 provider。
+// provider 用执行进程的 ctx 创建，不随单个任务取消。
+type taskProviders struct {
+	ctx          context.Context
+	systemPrompt string
+
+	mu        sync.Mutex
+	providers map[string]llm.Provider
+}
+
+func (p *taskProviders) get(model string) (llm.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if provider, ok := p.providers[model]; ok {
+		return provider, nil
+	}
+	provider, err := createProvider(p.ctx, model, p.systemPrompt)
+	if err != nil {
+		return nil, err
+	}
+	p.providers[model] = provider
+	return provider, nil
+}
+
+// newTaskRunner 返回在后台执行任务的 RunFunc：任务的问题作为一轮新对话交给任务指定的模型，
+// 并和交互模式一样循环调用工具直到得到最终回复
+func newTaskRunner(
+	providerFor func(model string) (llm.Provider, error),
+	mcpClients map[string]mcpclient.MCPClient,
+	systemPrompt string,
+) asyntask.RunFunc {
+	return func(ctx context.Context, task asyntask.Task, record func(messages ...history.HistoryMessage)) (string, error) {
+		model := task.Model
+		if model == "" {
+			model = modelFlag
+		}
+		provider, err := providerFor(model)
+		if err != nil {
+			return "", fmt.Errorf("创建提供者失败: %w", err)
+		}
+
+		content := []history.ContentBlock{{Type: "text", Text: task.Question}}
+		content = append(content, resourceContext(ctx, task.Question, mcpClients, 1)...)
+		content = append(content, retrieveContext(ctx, task.Question, nextCitationIndex([]history.HistoryMessage{{Role: "user", Content: content}}))...)
+		messages := []history.HistoryMessage{{Role: "user", Content: content}}
+		record(messages...)
+
+		// 服务器重新连接后工具可能有变化，每个任务使用最新的工具列表
+		tools := currentTools()
+		budget := contextBudget(systemPrompt, tools)
+		// onEvent 不为 nil 时 runPrompt 不向终端输出流式文本
+		err = runPrompt(ctx, provider, mcpClients, tools, budget, "", &messages, func(llm.StreamEvent) {})
+		record(messages[1:]...)
+
+		result := collectOneShotResult(messages[1:])
+		return result.Response, err
+	}
+}
+
+// recentTasks 返回最近提交的 n 个任务，按提交时间从早到晚排列
+func recentTasks(tasks []asyntask.Task, n int) []asyntask.Task {
+	if len(tasks) > n {
+		return tasks[len(tasks)-n:]
+	}
+	return tasks
+}
+
+// taskTitle 返回任务问题的开头部分，用于列表展示
+func taskTitle(t asyntask.Task) string {
+	title := strings.Join(strings.Fields(t.Question), " ")
+	if runes := []rune(title); len(runes) > 60 {
+		title = string(runes[:60]) + "..."
+	}
+	return title
+}
+
+// formatTask 把任务的状态和结果格式化为纯文本
+func formatTask(t asyntask.Task) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("任务: %s\n", t.ID))
+	sb.WriteString(fmt.Sprintf("状态: %s", t.State))
+	if t.Attempts > 1 {
+		sb.WriteString(fmt.Sprintf("（第 %d 次执行）", t.Attempts))
+	}
+	sb.WriteString("\n")
+	if t.Model != "" {
+		sb.WriteString(fmt.Sprintf("模型: %s\n", t.Model))
+	}
+	if t.Schedule != "" {
+		sb.WriteString(fmt.Sprintf("定时计划: %s\n", t.Schedule))
+	}
+	sb.WriteString(fmt.Sprintf("提交: %s\n", t.CreatedAt.Format("2006-01-02 15:04:05")))
+	if !t.StartedAt.IsZero() {
+		sb.WriteString(fmt.Sprintf("开始: %s\n", t.StartedAt.Format("2006-01-02 15:04:05")))
+	}
+	if !t.FinishedAt.IsZero() {
+		sb.WriteString(fmt.Sprintf("结束: %s\n", t.FinishedAt.Format("2006-01-02 15:04:05")))
+	}
+	sb.WriteString(fmt.Sprintf("问题: %s\n", t.Question))
+	if t.Error != "" {
+		sb.WriteString(fmt.Sprintf("错误: %s\n", t.Error))
+	}
+	if t.Result != "" {
+		sb.WriteString(fmt.Sprintf("\n%s\n", t.Result))
+		if footer := formatSources(collectOneShotResult(t.Messages).Sources); footer != "" {
+			sb.WriteString(fmt.Sprintf("\n%s\n", footer))
+		}
+	}
+	return sb.String()
+}
+
+// chatTasks 记录本次交互中用 /bg 提交、尚未提示结束的任务
+type chatTasks map[string]bool
+
+// notify 在交互循环中提示已经结束的任务
+func (c chatTasks) notify() {
+	if len(c) == 0 {
+		return
+	}
+	tasks, err := loadTasks()
+	if err != nil {
+		log.Debug("读取后台任务失败", "error", err)
+		return
+	}
+	for _, t := range tasks {
+		if !c[t.ID] || !t.State.Terminal() {
+			continue
+		}
+		delete(c, t.ID)
+		if t.State == asyntask.StateSucceeded {
+			fmt.Printf("\n%s\n", toolNameStyle.Render(fmt.Sprintf("后台任务 %s 已完成：%s", t.ID, taskTitle(t))))
+			fmt.Printf("%s\n", descriptionStyle.Render(
+				fmt.Sprintf("使用 /task %s 查看结果，/task %s pull 把结果加入当前对话", t.ID, t.ID)))
+		} else {
+			fmt.Printf("\n%s\n\n", errorStyle.Render(fmt.Sprintf("后台任务 %s %s：%s", t.ID, t.State, t.Error)))
+		}
+	}
+}
+
+// handleTaskCommand 处理 /bg、/tasks 和 /task 命令。
+// /task <id> pull 把已完成任务的问题和结果作为一轮问答追加到当前对话，并保存到会话文件。
+func handleTaskCommand(
+	prompt string,
+	watched chatTasks,
+	store *session.Store,
+	sess *session.Session,
+	messages *[]history.HistoryMessage,
+) bool {
+	fields := strings.Fields(prompt)
+	if len(fields) == 0 {
+		return false
+	}
+
+	switch strings.ToLower(fields[0]) {
+	case "/bg":
+		question := strings.TrimSpace(strings.TrimSpace(prompt)[len(fields[0]):])
+		if question == "" {
+			fmt.Printf("\n%s\n\n", errorStyle.Render("用法: /bg <prompt>"))
+			return true
+		}
+		task, err := submitTask(asyntask.Task{Question: question})
+		if err != nil {
+			fmt.Printf("\n%s\n\n", errorStyle.Render(fmt.Sprintf("提交后台任务失败: %v", err)))
+			return true
+		}
+		watched[task.ID] = true
+		fmt.Printf("\n%s\n\n", descriptionStyle.Render(
+			fmt.Sprintf("已提交后台任务 %s，完成后会在这里提示，可以继续对话", task.ID)))
+		return true
+	case "/tasks":
+		handleTasksCommand()
+		return true
+	case "/task":
+		if len(fields) < 2 {
+			fmt.Printf("\n%s\n\n", errorStyle.Render("用法: /task <id> [pull|logs|cancel]"))
+			return true
+		}
+		task, err := findTask(fields[1])
+		if err != nil {
+			fmt.Printf("\n%s\n\n", errorStyle.Render(err.Error()))
+			return true
+		}
+		action := ""
+		if len(fields) > 2 {
+			action = strings.ToLower(fields[2])
+		}
+		switch action {
+		case "":
+			renderTask(task)
+		case "logs":
+			displayMessageHistory(task.Messages)
+		case "cancel":
+			if _, err := cancelTask(task.ID); err != nil {
+				fmt.Printf("\n%s\n\n", errorStyle.Render(fmt.Sprintf("取消任务失败: %v", err)))
+				return true
+			}
+			fmt.Printf("\n%s\n\n", descriptionStyle.Render("已请求取消任务 "+task.ID))
+		case "pull":
+			pulled, err := pullTaskResult(task)
+			if err != nil {
+				fmt.Printf("\n%s\n\n", errorStyle.Render(err.Error()))
+				return true
+			}
+			*messages = append(*messages, pulled...)
+			if err := store.Append(sess, pulled...); err != nil {
+				log.Error("保存会话失败", "id", sess.ID, "error", err)
+			}
+			fmt.Printf("\n%s\n\n", descriptionStyle.Render(
+				fmt.Sprintf("已把任务 %s 的结果加入当前对话", task.ID)))
+		default:
+			fmt.Printf("\n%s\n\n", errorStyle.Render("未知的操作: "+fields[2]+"（可选 pull、logs、cancel）"))
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// pullTaskResult 把已完成任务的问题和最终回复转换为一轮问答，回复保留其引用的来源
+func pullTaskResult(task asyntask.Task) ([]history.HistoryMessage, error) {
+	if task.State != asyntask.StateSucceeded {
+		return nil, fmt.Errorf("任务 %s 没有可加入对话的结果（%s）", task.ID, task.State)
+	}
+	result := collectOneShotResult(task.Messages)
+	if result.Response == "" {
+		result.Response = task.Result
+	}
+	return []history.HistoryMessage{
+		{
+			Role:    "user",
+			Content: []history.ContentBlock{{Type: "text", Text: fmt.Sprintf("（后台任务 %s）%s", task.ID, task.Question)}},
+		},
+		{
+			Role:    "assistant",
+			Content: []history.ContentBlock{{Type: "text", Text: result.Response, Sources: result.Sources}},
+		},
+	}, nil
+}
+
+// handleTasksCommand 列出最近的后台任务
+func handleTasksCommand() {
+	if err := updateRenderer(); err != nil {
+		fmt.Printf("\n%s\n", errorStyle.Render(fmt.Sprintf("更新渲染器失败: %v", err)))
+		return
+	}
+	tasks, err := loadTasks()
+	if err != nil {
+		fmt.Printf("\n%s\n", errorStyle.Render(fmt.Sprintf("读取后台任务失败: %v", err)))
+		return
+	}
+
+	var markdown strings.Builder
+	markdown.WriteString("# 后台任务\n\n")
+	if len(tasks) == 0 {
+		markdown.WriteString("暂无后台任务，使用 `/bg <prompt>` 提交。\n")
+	}
+	for _, t := range recentTasks(tasks, 20) {
+		markdown.WriteString(fmt.Sprintf("- **%s** `%s` · 提交于 %s  \n  %s\n",
+			t.ID, t.State, t.CreatedAt.Format("2006-01-02 15:04"), taskTitle(t)))
+	}
+	markdown.WriteString("\n使用 `/task <id>` 查看任务结果。\n")
+
+	rendered, err := renderer.Render(markdown.String())
+	if err != nil {
+		fmt.Printf("\n%s\n", errorStyle.Render(fmt.Sprintf("渲染任务列表失败: %v", err)))
+		return
+	}
+	fmt.Print(rendered)
+}
+
+// renderTask 在终端中显示任务的状态和结果
+func renderTask(t asyntask.Task) {
+	if err := updateRenderer(); err != nil {
+		fmt.Printf("\n%s\n", errorStyle.Render(fmt.Sprintf("更新渲染器失败: %v", err)))
+		return
+	}
+
+	var markdown strings.Builder
+	markdown.WriteString(fmt.Sprintf("# 任务 %s\n\n", t.ID))
+	markdown.WriteString(fmt.Sprintf("- **状态**: `%s`\n", t.State))
+	if t.Model != "" {
+		markdown.WriteString(fmt.Sprintf("- **模型**: `%s`\n", t.Model))
+	}
+	markdown.WriteString(fmt.Sprintf("- **提交于**: %s\n", t.CreatedAt.Format("2006-01-02 15:04:05")))
+	if !t.FinishedAt.IsZero() {
+		markdown.WriteString(fmt.Sprintf("- **结束于**: %s\n", t.FinishedAt.Format("2006-01-02 15:04:05")))
+	}
+	markdown.WriteString(fmt.Sprintf("\n**问题**: %s\n\n", t.Question))
+	if t.Error != "" {
+		markdown.WriteString(fmt.Sprintf("**错误**: %s\n\n", t.Error))
+	}
+	if t.Result != "" {
+		markdown.WriteString("## 结果\n\n" + t.Result + "\n\n")
+		if footer := formatSources(collectOneShotResult(t.Messages).Sources); footer != "" {
+			markdown.WriteString("```\n" + footer + "\n```\n\n")
+		}
+	}
+	if t.State == asyntask.StateSucceeded {
+		markdown.WriteString(fmt.Sprintf("使用 `/task %s pull` 把结果加入当前对话。\n", t.ID))
+	}
+
+	rendered, err := renderer.Render(markdown.String())
+	if err != nil {
+		fmt.Printf("\n%s\n", errorStyle.Render(fmt.Sprintf("渲染任务失败: %v", err)))
+		return
+	}
+	fmt.Print(rendered)
+}
@@ -1 +1,381 @@
 package asyntask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/mark3labs/mcphost/pkg/history"
+)
+
+const (
+	// DefaultWorkers 是未指定时同时执行的任务数
+	DefaultWorkers = 2
+	// DefaultMaxAttempts 是未指定时被中断的任务最多执行的次数
+	DefaultMaxAttempts = 3
+)
+
+// Recovery 决定队列启动时如何处理上次运行中被中断（进程崩溃或退出）的任务
+type Recovery string
+
+const (
+	RecoverRetry Recovery = "retry" // 重新排队执行，超过最大次数后标记为失败
+	RecoverFail  Recovery = "fail"  // 直接标记为失败
+)
+
+// ParseRecovery 解析恢复策略，空字符串表示 RecoverRetry
+func ParseRecovery(s string) (Recovery, error) {
+	switch Recovery(strings.ToLower(strings.TrimSpace(s))) {
+	case "", RecoverRetry:
+		return RecoverRetry, nil
+	case RecoverFail:
+		return RecoverFail, nil
+	}
+	return "", fmt.Errorf("不支持的恢复策略: %q（可选 retry、fail）", s)
+}
+
+var (
+	// ErrNotFound 表示指定的任务不存在
+	ErrNotFound = errors.New("任务不存在")
+	// ErrFinished 表示任务已经结束，无法再取消
+	ErrFinished = errors.New("任务已结束")
+	// ErrClosed 表示队列已关闭，不再接受新任务
+	ErrClosed = errors.New("任务队列已关闭")
+)
+
+// Options 是任务队列的配置
+type Options struct {
+	Workers int // 同时执行的任务数，不大于 0 时使用 DefaultWorkers
+
+	// Store 不为 nil 时任务持久化到其中，队列创建时恢复未完成的任务；为 nil 时任务只保存在内存中
+	Store       *Store
+	Recovery    Recovery // 被中断任务的处理方式，默认 RecoverRetry
+	MaxAttempts int      // RecoverRetry 时每个任务最多执行的次数，不大于 0 时使用 DefaultMaxAttempts
+
+	// OnFinish 不为 nil 时在任务结束后于单独的 goroutine 中调用，因队列关闭而中断的任务除外。
+	// Close 会等待进行中的 OnFinish 返回。
+	OnFinish func(task Task)
+}
+
+// TaskQueue 是在后台按提交顺序执行任务的队列，由固定数量的 worker 并发执行，可以在多个 goroutine 中同时使用
+type TaskQueue struct {
+	run      RunFunc
+	store    *Store
+	onFinish func(task Task)
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   map[string]*entry
+	pending []string // 等待执行的任务 ID，按提交顺序排列
+	closed  bool
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	notifyWG sync.WaitGroup // 进行中的 OnFinish
+}
+
+// entry 是队列中的一个任务及其运行状态
+type entry struct {
+	task            Task
+	cancel          context.CancelFunc // 任务运行时取消其 ctx
+	cancelRequested bool               // 运行中的任务已被要求取消
+	done            chan struct{}      // 任务结束时关闭
+}
+
+// New 创建任务队列并启动 worker，任务由 run 执行。
+// 配置了 Store 时先恢复其中的任务：排队中的任务继续排队，运行中被中断的任务按 Recovery 处理。
+func New(run RunFunc, opts Options) *TaskQueue {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &TaskQueue{
+		run:      run,
+		store:    opts.Store,
+		onFinish: opts.OnFinish,
+		queue:    make(map[string]*entry),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	q.cond = sync.NewCond(&q.mu)
+	if q.store != nil {
+		q.recover(opts)
+	}
+
+	q.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go q.worker()
+	}
+	return q
+}
+
+// Submit 提交任务，返回加入队列后的任务。ID 为空时自动生成，Question 不能为空。
+func (q *TaskQueue) Submit(task Task) (Task, error) {
+	task.Question = strings.TrimSpace(task.Question)
+	if task.Question == "" {
+		return Task{}, errors.New("任务内容不能为空")
+	}
+	if task.ID == "" {
+		task.ID = NewID()
+	}
+	task.State = StateQueued
+	task.Result, task.Error, task.Messages, task.Attempts = "", "", nil, 0
+	task.CreatedAt = time.Now()
+	task.StartedAt, task.FinishedAt = time.Time{}, time.Time{}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return Task{}, ErrClosed
+	}
+	if _, exists := q.queue[task.ID]; exists {
+		return Task{}, fmt.Errorf("任务 %s 已存在", task.ID)
+	}
+	if q.store != nil {
+		if err := q.store.saveTask(task); err != nil {
+			return Task{}, err
+		}
+	}
+	q.queue[task.ID] = &entry{task: task, done: make(chan struct{})}
+	q.pending = append(q.pending, task.ID)
+	q.cond.Signal()
+	return task.clone(), nil
+}
+
+// Get 返回任务的当前状态
+func (q *TaskQueue) Get(id string) (Task, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e, ok := q.queue[id]
+	if !ok {
+		return Task{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return e.task.clone(), nil
+}
+
+// List 返回所有任务，按提交时间从早到晚排列
+func (q *TaskQueue) List() []Task {
+	q.mu.Lock()
+	tasks := make([]Task, 0, len(q.queue))
+	for _, e := range q.queue {
+		tasks = append(tasks, e.task.clone())
+	}
+	q.mu.Unlock()
+
+	sortTasks(tasks)
+	return tasks
+}
+
+// Cancel 取消任务。排队中的任务立即变为已取消；运行中的任务会取消其 ctx，
+// 在 RunFunc 返回后变为已取消。已结束的任务返回 ErrFinished。
+func (q *TaskQueue) Cancel(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e, ok := q.queue[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	switch e.task.State {
+	case StateQueued:
+		q.finish(e, StateCanceled, "", "任务已取消")
+		q.save(e)
+		q.notify(e)
+	case StateRunning:
+		e.cancelRequested = true
+		e.cancel()
+	default:
+		return fmt.Errorf("%w: %s（%s）", ErrFinished, id, e.task.State)
+	}
+	return nil
+}
+
+// Wait 等待任务结束并返回结束后的任务；ctx 取消时返回 ctx 的错误
+func (q *TaskQueue) Wait(ctx context.Context, id string) (Task, error) {
+	q.mu.Lock()
+	e, ok := q.queue[id]
+	q.mu.Unlock()
+	if !ok {
+		return Task{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	select {
+	case <-e.done:
+		return q.Get(id)
+	case <-ctx.Done():
+		return Task{}, ctx.Err()
+	}
+}
+
+// Close 停止接受新任务，取消排队中和运行中的任务，并等待所有 worker 退出。
+// 配置了 Store 时这些任务在存储中保持排队中或运行中，下次创建队列时恢复。Store 需由调用方关闭。
+func (q *TaskQueue) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	for _, id := range q.pending {
+		if e := q.queue[id]; e.task.State == StateQueued {
+			q.finish(e, StateCanceled, "", "任务队列已关闭")
+		}
+	}
+	q.pending = nil
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	q.cancel()
+	q.wg.Wait()
+	q.notifyWG.Wait()
+	return nil
+}
+
+// worker 依次取出排队中的任务执行，直到队列关闭
+func (q *TaskQueue) worker() {
+	defer q.wg.Done()
+	for {
+		e, ctx, ok := q.next()
+		if !ok {
+			return
+		}
+		task := e.task.clone()
+		record := func(messages ...history.HistoryMessage) {
+			q.mu.Lock()
+			e.task.Messages = append(e.task.Messages, messages...)
+			q.mu.Unlock()
+			if q.store != nil {
+				if err := q.store.appendMessages(task.ID, messages...); err != nil {
+					log.Warn("保存任务消息失败", "task", task.ID, "error", err)
+				}
+			}
+		}
+
+		result, err := q.run(ctx, task, record)
+
+		q.mu.Lock()
+		e.cancel()
+		switch {
+		case e.cancelRequested:
+			q.finish(e, StateCanceled, result, "任务已取消")
+			q.save(e)
+			q.notify(e)
+		case q.closed && ctx.Err() != nil:
+			// 因队列关闭而中断的任务不写入存储，下次创建队列时按恢复策略处理
+			q.finish(e, StateCanceled, result, "任务队列已关闭")
+		case err != nil:
+			q.finish(e, StateFailed, result, err.Error())
+			q.save(e)
+			q.notify(e)
+		default:
+			q.finish(e, StateSucceeded, result, "")
+			q.save(e)
+			q.notify(e)
+		}
+		q.mu.Unlock()
+	}
+}
+
+// next 等待并取出下一个排队中的任务，将其标记为运行中。队列关闭时返回 false。
+func (q *TaskQueue) next() (*entry, context.Context, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		for len(q.pending) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if q.closed {
+			return nil, nil, false
+		}
+
+		id := q.pending[0]
+		q.pending = q.pending[1:]
+		e := q.queue[id]
+		// 排队期间被取消的任务直接跳过
+		if e.task.State != StateQueued {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(q.ctx)
+		e.cancel = cancel
+		e.task.State = StateRunning
+		e.task.StartedAt = time.Now()
+		e.task.Attempts++
+		q.save(e)
+		return e, ctx, true
+	}
+}
+
+// finish 把任务标记为结束，调用方需持有 q.mu
+func (q *TaskQueue) finish(e *entry, state State, result, errMsg string) {
+	e.task.State = state
+	e.task.Result = result
+	e.task.Error = errMsg
+	e.task.FinishedAt = time.Now()
+	close(e.done)
+}
+
+// save 把任务的最新状态写入存储，调用方需持有 q.mu。
+// 写入失败只记录日志，任务在内存中照常推进。
+func (q *TaskQueue) save(e *entry) {
+	if q.store == nil {
+		return
+	}
+	if err := q.store.saveTask(e.task); err != nil {
+		log.Warn("保存任务状态失败", "task", e.task.ID, "error", err)
+	}
+}
+
+// notify 在单独的 goroutine 中调用 OnFinish，调用方需持有 q.mu
+func (q *TaskQueue) notify(e *entry) {
+	if q.onFinish == nil {
+		return
+	}
+	task := e.task.clone()
+	q.notifyWG.Add(1)
+	go func() {
+		defer q.notifyWG.Done()
+		q.onFinish(task)
+	}()
+}
+
+// recover 从存储中恢复任务。已结束的任务只供查询，排队中的任务按提交顺序重新排队，
+// 运行中的任务说明上次执行被中断，按恢复策略重新排队或标记为失败，已记录的消息都会保留。
+func (q *TaskQueue) recover(opts Options) {
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	for _, task := range q.store.Tasks() {
+		e := &entry{task: task, done: make(chan struct{})}
+		q.queue[task.ID] = e
+
+		switch {
+		case task.State.Terminal():
+			close(e.done)
+			continue
+		case task.State == StateRunning && opts.Recovery == RecoverFail:
+			q.finish(e, StateFailed, "", "任务执行被中断")
+			q.save(e)
+			q.notify(e)
+			continue
+		case task.State == StateRunning && task.Attempts >= maxAttempts:
+			q.finish(e, StateFailed, "", fmt.Sprintf("任务执行被中断，已尝试 %d 次", task.Attempts))
+			q.save(e)
+			q.notify(e)
+			continue
+		case task.State == StateRunning:
+			log.Info("重新执行被中断的任务", "task", task.ID, "attempt", task.Attempts+1)
+			e.task.State = StateQueued
+			e.task.StartedAt = time.Time{}
+			q.save(e)
+		}
+		q.pending = append(q.pending, task.ID)
+	}
+}
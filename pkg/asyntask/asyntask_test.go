@@ -0,0 +1,354 @@
+package asyntask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcphost/pkg/history"
+)
+
+// waitTimeout 是测试中等待任务状态变化的上限，超过时认为队列卡住
+const waitTimeout = 5 * time.Second
+
+// blockingRunner 执行的任务在开始后阻塞，直到 release 关闭或 ctx 取消
+type blockingRunner struct {
+	started chan string
+	release chan struct{}
+	// ignoreCancel 为 true 时不理会 ctx 的取消，模拟没有及时响应取消的 RunFunc
+	ignoreCancel bool
+}
+
+func newBlockingRunner() *blockingRunner {
+	return &blockingRunner{started: make(chan string, 100), release: make(chan struct{})}
+}
+
+func (r *blockingRunner) run(ctx context.Context, task Task, record func(...history.HistoryMessage)) (string, error) {
+	r.started <- task.ID
+	record(history.HistoryMessage{Role: "assistant", Content: []history.ContentBlock{{Type: "text", Text: "处理中"}}})
+	if r.ignoreCancel {
+		<-r.release
+		return "完成: " + task.Question, nil
+	}
+	select {
+	case <-r.release:
+		return "完成: " + task.Question, nil
+	case <-ctx.Done():
+		return "部分结果", ctx.Err()
+	}
+}
+
+// waitStarted 等待 id 对应的任务开始执行
+func (r *blockingRunner) waitStarted(t *testing.T, id string) {
+	t.Helper()
+	select {
+	case got := <-r.started:
+		if got != id {
+			t.Fatalf("开始执行的任务是 %s，期望 %s", got, id)
+		}
+	case <-time.After(waitTimeout):
+		t.Fatalf("任务 %s 没有开始执行", id)
+	}
+}
+
+// finishedTasks 记录 OnFinish 收到的任务
+type finishedTasks struct {
+	mu    sync.Mutex
+	tasks map[string]Task
+}
+
+func (f *finishedTasks) add(task Task) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.tasks == nil {
+		f.tasks = make(map[string]Task)
+	}
+	f.tasks[task.ID] = task
+}
+
+func (f *finishedTasks) get(id string) (Task, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	task, ok := f.tasks[id]
+	return task, ok
+}
+
+func (f *finishedTasks) len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.tasks)
+}
+
+func waitTask(t *testing.T, q *TaskQueue, id string) Task {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), waitTimeout)
+	defer cancel()
+	task, err := q.Wait(ctx, id)
+	if err != nil {
+		t.Fatalf("等待任务 %s 失败: %v", id, err)
+	}
+	return task
+}
+
+func TestConcurrentSubmit(t *testing.T) {
+	const (
+		workers      = 4
+		submitters   = 20
+		perSubmitter = 10
+	)
+	var running, maxRunning int32
+	run := func(ctx context.Context, task Task, record func(...history.HistoryMessage)) (string, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			peak := atomic.LoadInt32(&maxRunning)
+			if n <= peak || atomic.CompareAndSwapInt32(&maxRunning, peak, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		record(history.HistoryMessage{Role: "assistant", Content: []history.ContentBlock{{Type: "text", Text: task.Question}}})
+		return "回答: " + task.Question, nil
+	}
+	finished := &finishedTasks{}
+	q := New(run, Options{Workers: workers, OnFinish: finished.add})
+
+	var wg sync.WaitGroup
+	ids := make(chan string, submitters*perSubmitter)
+	for i := 0; i < submitters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perSubmitter; j++ {
+				task, err := q.Submit(Task{Question: fmt.Sprintf("问题 %d-%d", i, j)})
+				if err != nil {
+					t.Errorf("提交失败: %v", err)
+					return
+				}
+				ids <- task.ID
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(ids)
+
+	count := 0
+	for id := range ids {
+		task := waitTask(t, q, id)
+		if task.State != StateSucceeded || task.Result != "回答: "+task.Question || task.Attempts != 1 {
+			t.Fatalf("任务 %s = %+v", id, task)
+		}
+		if len(task.Messages) != 1 || task.Messages[0].GetContent() != task.Question {
+			t.Fatalf("任务 %s 的消息 = %+v", id, task.Messages)
+		}
+		count++
+	}
+	if count != submitters*perSubmitter || len(q.List()) != count {
+		t.Fatalf("完成 %d 个任务，List 返回 %d 个，期望 %d 个", count, len(q.List()), submitters*perSubmitter)
+	}
+	if peak := atomic.LoadInt32(&maxRunning); peak > workers {
+		t.Fatalf("同时执行了 %d 个任务，超过 worker 数 %d", peak, workers)
+	}
+
+	q.Close()
+	if finished.len() != count {
+		t.Fatalf("OnFinish 收到 %d 个任务，期望 %d 个", finished.len(), count)
+	}
+}
+
+func TestSubmitValidation(t *testing.T) {
+	q := New(newBlockingRunner().run, Options{Workers: 1})
+	defer q.Close()
+
+	if _, err := q.Submit(Task{Question: "  "}); err == nil {
+		t.Fatal("空问题应该提交失败")
+	}
+	if _, err := q.Submit(Task{ID: "a", Question: "问题"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Submit(Task{ID: "a", Question: "问题"}); err == nil {
+		t.Fatal("重复的任务 ID 应该提交失败")
+	}
+	if _, err := q.Get("不存在"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("期望 ErrNotFound，得到 %v", err)
+	}
+	if err := q.Cancel("不存在"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("期望 ErrNotFound，得到 %v", err)
+	}
+}
+
+func TestCancelQueued(t *testing.T) {
+	runner := newBlockingRunner()
+	finished := &finishedTasks{}
+	q := New(runner.run, Options{Workers: 1, OnFinish: finished.add})
+	defer q.Close()
+
+	a, _ := q.Submit(Task{ID: "a", Question: "第一个"})
+	runner.waitStarted(t, a.ID)
+	b, _ := q.Submit(Task{ID: "b", Question: "第二个"})
+	c, _ := q.Submit(Task{ID: "c", Question: "第三个"})
+
+	// 排队中的任务立即变为已取消，不需要等 worker
+	if err := q.Cancel(b.ID); err != nil {
+		t.Fatal(err)
+	}
+	task, err := q.Get(b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.State != StateCanceled || task.Error != "任务已取消" || task.Attempts != 0 {
+		t.Fatalf("取消后的任务 = %+v", task)
+	}
+	if err := q.Cancel(b.ID); !errors.Is(err, ErrFinished) {
+		t.Fatalf("重复取消: 期望 ErrFinished，得到 %v", err)
+	}
+
+	// 被取消的任务不会执行，worker 跳过它执行下一个任务
+	close(runner.release)
+	runner.waitStarted(t, c.ID)
+	if task := waitTask(t, q, c.ID); task.State != StateSucceeded {
+		t.Fatalf("任务 c = %+v", task)
+	}
+	if task := waitTask(t, q, a.ID); task.State != StateSucceeded {
+		t.Fatalf("任务 a = %+v", task)
+	}
+	q.Close()
+	if task, ok := finished.get(b.ID); !ok || task.State != StateCanceled {
+		t.Fatalf("OnFinish 应收到已取消的任务 b，得到 %+v, %v", task, ok)
+	}
+}
+
+func TestCancelRunning(t *testing.T) {
+	tests := []struct {
+		name         string
+		ignoreCancel bool
+		wantResult   string
+	}{
+		{"RunFunc 响应取消", false, "部分结果"},
+		// RunFunc 没有理会取消并正常返回时，任务仍然按已取消处理
+		{"RunFunc 忽略取消", true, "完成: 第一个"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newBlockingRunner()
+			runner.ignoreCancel = tt.ignoreCancel
+			finished := &finishedTasks{}
+			q := New(runner.run, Options{Workers: 1, OnFinish: finished.add})
+			defer q.Close()
+
+			a, _ := q.Submit(Task{ID: "a", Question: "第一个"})
+			b, _ := q.Submit(Task{ID: "b", Question: "第二个"})
+			runner.waitStarted(t, a.ID)
+
+			if err := q.Cancel(a.ID); err != nil {
+				t.Fatal(err)
+			}
+			if tt.ignoreCancel {
+				// 在 RunFunc 返回之前任务仍在运行
+				if task, _ := q.Get(a.ID); task.State != StateRunning {
+					t.Fatalf("RunFunc 返回前任务状态 = %s，期望 %s", task.State, StateRunning)
+				}
+				close(runner.release)
+			}
+
+			task := waitTask(t, q, a.ID)
+			if task.State != StateCanceled || task.Error != "任务已取消" || task.Result != tt.wantResult {
+				t.Fatalf("任务 a = %+v", task)
+			}
+			if len(task.Messages) != 1 {
+				t.Fatalf("取消的任务应保留已记录的消息，得到 %+v", task.Messages)
+			}
+			if err := q.Cancel(a.ID); !errors.Is(err, ErrFinished) {
+				t.Fatalf("期望 ErrFinished，得到 %v", err)
+			}
+
+			// 取消不影响后面的任务
+			runner.waitStarted(t, b.ID)
+			if !tt.ignoreCancel {
+				close(runner.release)
+			}
+			if task := waitTask(t, q, b.ID); task.State != StateSucceeded {
+				t.Fatalf("任务 b = %+v", task)
+			}
+			q.Close()
+			if task, ok := finished.get(a.ID); !ok || task.State != StateCanceled {
+				t.Fatalf("OnFinish 应收到已取消的任务 a，得到 %+v, %v", task, ok)
+			}
+		})
+	}
+}
+
+func TestWaitContextCanceled(t *testing.T) {
+	runner := newBlockingRunner()
+	q := New(runner.run, Options{Workers: 1})
+	defer q.Close()
+
+	a, _ := q.Submit(Task{ID: "a", Question: "第一个"})
+	runner.waitStarted(t, a.ID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := q.Wait(ctx, a.ID); !errors.Is(err, context.Canceled) {
+		t.Fatalf("期望 context.Canceled，得到 %v", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Wait(ctx, a.ID); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("期望 context.DeadlineExceeded，得到 %v", err)
+	}
+	if _, err := q.Wait(context.Background(), "不存在"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("期望 ErrNotFound，得到 %v", err)
+	}
+
+	// 等待超时不影响任务本身
+	if task, _ := q.Get(a.ID); task.State != StateRunning {
+		t.Fatalf("任务状态 = %s，期望 %s", task.State, StateRunning)
+	}
+	close(runner.release)
+	if task := waitTask(t, q, a.ID); task.State != StateSucceeded {
+		t.Fatalf("任务 a = %+v", task)
+	}
+}
+
+func TestCloseWhileRunning(t *testing.T) {
+	runner := newBlockingRunner()
+	finished := &finishedTasks{}
+	q := New(runner.run, Options{Workers: 1, OnFinish: finished.add})
+
+	a, _ := q.Submit(Task{ID: "a", Question: "第一个"})
+	b, _ := q.Submit(Task{ID: "b", Question: "第二个"})
+	runner.waitStarted(t, a.ID)
+
+	done := make(chan struct{})
+	go func() {
+		q.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(waitTimeout):
+		t.Fatal("Close 没有等到运行中的任务退出")
+	}
+
+	for _, id := range []string{a.ID, b.ID} {
+		task := waitTask(t, q, id)
+		if task.State != StateCanceled || !strings.Contains(task.Error, "任务队列已关闭") {
+			t.Fatalf("任务 %s = %+v", id, task)
+		}
+	}
+	// 因队列关闭而中断的任务不通知 OnFinish
+	if finished.len() != 0 {
+		t.Fatalf("OnFinish 不应被调用，收到 %d 个任务", finished.len())
+	}
+	if _, err := q.Submit(Task{Question: "关闭后提交"}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("期望 ErrClosed，得到 %v", err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatalf("重复关闭: %v", err)
+	}
+}
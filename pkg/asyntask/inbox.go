@@ -0,0 +1,154 @@
+package asyntask
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// 收件箱中请求的类型
+const (
+	requestSubmit = "submit"
+	requestCancel = "cancel"
+)
+
+// request 是收件箱中的一个请求文件
+type request struct {
+	Type   string `json:"type"`
+	Task   *Task  `json:"task,omitempty"`
+	TaskID string `json:"taskid,omitempty"`
+}
+
+// Inbox 是其他进程向持有队列的进程提交和取消任务的目录，每个请求保存为一个文件。
+// 请求先写临时文件再改名，持有队列的进程通过 Drain 按提交顺序处理并删除它们。
+type Inbox struct {
+	dir string
+}
+
+// OpenInbox 打开 dir 处的收件箱，目录不存在时创建
+func OpenInbox(dir string) (*Inbox, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建任务收件箱失败: %w", err)
+	}
+	return &Inbox{dir: dir}, nil
+}
+
+// Submit 把任务放入收件箱，返回分配了 ID 的排队中任务
+func (b *Inbox) Submit(task Task) (Task, error) {
+	task.Question = strings.TrimSpace(task.Question)
+	if task.Question == "" {
+		return Task{}, errors.New("任务内容不能为空")
+	}
+	if task.ID == "" {
+		task.ID = NewID()
+	}
+	task.State = StateQueued
+	task.CreatedAt = time.Now()
+	if err := b.write(request{Type: requestSubmit, Task: &task}); err != nil {
+		return Task{}, err
+	}
+	return task, nil
+}
+
+// Cancel 请求取消任务，由持有队列的进程执行
+func (b *Inbox) Cancel(id string) error {
+	return b.write(request{Type: requestCancel, TaskID: id})
+}
+
+// Pending 返回已放入收件箱、尚未被队列接收的任务
+func (b *Inbox) Pending() []Task {
+	var tasks []Task
+	for _, name := range b.names() {
+		r, err := b.read(name)
+		if err == nil && r.Type == requestSubmit && r.Task != nil {
+			tasks = append(tasks, *r.Task)
+		}
+	}
+	return tasks
+}
+
+// Drain 把收件箱中的请求按提交顺序交给队列处理，处理过的请求文件被删除。
+// 单个请求失败（例如取消已结束的任务）只记录日志，不影响其他请求。
+func (b *Inbox) Drain(q *TaskQueue) {
+	for _, name := range b.names() {
+		r, err := b.read(name)
+		if err != nil {
+			log.Warn("读取任务请求失败", "file", name, "error", err)
+		} else {
+			switch r.Type {
+			case requestSubmit:
+				if r.Task == nil {
+					break
+				}
+				if _, err := q.Submit(*r.Task); err != nil {
+					log.Warn("提交任务失败", "task", r.Task.ID, "error", err)
+				}
+			case requestCancel:
+				if err := q.Cancel(r.TaskID); err != nil {
+					log.Warn("取消任务失败", "task", r.TaskID, "error", err)
+				}
+			}
+		}
+		if err := os.Remove(filepath.Join(b.dir, name)); err != nil && !os.IsNotExist(err) {
+			log.Warn("删除任务请求失败", "file", name, "error", err)
+		}
+	}
+}
+
+// write 先写临时文件再改名，Drain 不会读到写了一半的请求。
+// 文件名以纳秒时间戳开头，按文件名排序即按提交顺序。
+func (b *Inbox) write(r request) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("序列化任务请求失败: %w", err)
+	}
+	id := r.TaskID
+	if r.Task != nil {
+		id = r.Task.ID
+	}
+	name := fmt.Sprintf("%020d-%s-%s.json", time.Now().UnixNano(), r.Type, id)
+	tmpPath := filepath.Join(b.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("写入任务请求失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, filepath.Join(b.dir, name)); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("写入任务请求失败: %w", err)
+	}
+	return nil
+}
+
+func (b *Inbox) read(name string) (request, error) {
+	var r request
+	data, err := os.ReadFile(filepath.Join(b.dir, name))
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(data, &r)
+	return r, err
+}
+
+// names 返回收件箱中的请求文件名，按提交顺序排列，忽略临时文件
+func (b *Inbox) names() []string {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,89 @@
+package asyntask
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrLocked 表示任务队列已被其他进程持有
+var ErrLocked = errors.New("任务队列已被其他进程持有")
+
+const (
+	lockRefresh = 5 * time.Second  // 持有锁的进程刷新锁文件修改时间的间隔
+	lockStale   = 30 * time.Second // 锁文件超过这个时间未刷新，视为持有者已退出
+)
+
+// Lock 保证同一个任务存储只由一个进程执行。锁文件记录持有者的 PID，
+// 持有期间定期刷新修改时间；进程崩溃后锁文件不再刷新，过期后可被其他进程接管。
+type Lock struct {
+	path string
+	stop chan struct{}
+	once sync.Once
+}
+
+// AcquireLock 创建 path 处的锁文件，锁被其他进程持有且未过期时返回 ErrLocked
+func AcquireLock(path string) (*Lock, error) {
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_, err = f.WriteString(strconv.Itoa(os.Getpid()))
+			f.Close()
+			if err != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("写入锁文件失败: %w", err)
+			}
+			l := &Lock{path: path, stop: make(chan struct{})}
+			go l.refresh()
+			return l, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("创建锁文件失败: %w", err)
+		}
+		if attempt > 0 || LockHeld(path) {
+			pid, _ := os.ReadFile(path)
+			return nil, fmt.Errorf("%w（PID %s）", ErrLocked, strings.TrimSpace(string(pid)))
+		}
+		// 锁已过期，删除后重试一次
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("删除过期的锁文件失败: %w", err)
+		}
+	}
+}
+
+// LockHeld 判断 path 处的锁当前是否被某个进程持有
+func LockHeld(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && time.Since(info.ModTime()) < lockStale
+}
+
+// refresh 定期刷新锁文件的修改时间，直到锁被释放
+func (l *Lock) refresh() {
+	ticker := time.NewTicker(lockRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			_ = os.Chtimes(l.path, now, now)
+		}
+	}
+}
+
+// Release 释放锁并删除锁文件
+func (l *Lock) Release() error {
+	var err error
+	l.once.Do(func() {
+		close(l.stop)
+		if rmErr := os.Remove(l.path); rmErr != nil && !os.IsNotExist(rmErr) {
+			err = fmt.Errorf("删除锁文件失败: %w", rmErr)
+		}
+	})
+	return err
+}
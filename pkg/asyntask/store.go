@@ -0,0 +1,272 @@
+package asyntask
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/mark3labs/mcphost/pkg/history"
+	"github.com/mark3labs/mcphost/pkg/jsonl"
+)
+
+// 存储文件中每一行记录的类型
+const (
+	recordTask    = "task"    // 任务状态快照，不含消息，加载时覆盖之前的状态
+	recordMessage = "message" // 任务执行过程中新产生的消息
+)
+
+// compactThreshold 是触发重写存储文件的失效记录数下限
+const compactThreshold = 1000
+
+// record 是存储文件中的一行，文件采用只追加的 JSONL 格式，崩溃时最多丢失最后一行
+type record struct {
+	Type     string                   `json:"type"`
+	Task     *Task                    `json:"task,omitempty"`
+	TaskID   string                   `json:"taskid,omitempty"`
+	Messages []history.HistoryMessage `json:"messages,omitempty"`
+}
+
+// Store 把任务及其消息历史保存在单个文件中，进程重启后队列据此恢复任务。
+// 全部任务常驻内存，状态变化和新消息追加到文件。
+type Store struct {
+	path string
+
+	mu      sync.Mutex
+	file    *os.File
+	tasks   map[string]*Task
+	garbage int // 文件中已被覆盖的状态快照数
+}
+
+// OpenStore 打开 path 处的任务存储，文件不存在时创建
+func OpenStore(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("创建任务存储目录失败: %w", err)
+	}
+
+	s := &Store{path: path, tasks: make(map[string]*Task)}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	var err error
+	s.file, err = os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("打开任务存储文件失败: %w", err)
+	}
+	if err := jsonl.TerminateLastLine(s.file); err != nil {
+		s.file.Close()
+		return nil, fmt.Errorf("修复任务存储文件失败: %w", err)
+	}
+	if err := s.maybeCompact(); err != nil {
+		s.file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// LoadTasks 只读地加载 path 处存储中的任务，按提交时间从早到晚排列。
+// 可以在其他进程持有该存储时调用，文件不存在时返回空列表。
+func LoadTasks(path string) ([]Task, error) {
+	s := &Store{path: path, tasks: make(map[string]*Task)}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s.Tasks(), nil
+}
+
+// load 逐行回放存储文件，跳过无法解析的行（例如崩溃时写了一半的最后一行）
+func (s *Store) load() error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("打开任务存储文件失败: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		switch r.Type {
+		case recordTask:
+			if r.Task != nil {
+				s.put(*r.Task)
+			}
+		case recordMessage:
+			if t, ok := s.tasks[r.TaskID]; ok {
+				t.Messages = append(t.Messages, r.Messages...)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取任务存储文件失败: %w", err)
+	}
+	return nil
+}
+
+// put 在内存中更新任务状态，保留已有的消息
+func (s *Store) put(task Task) {
+	task.Messages = nil
+	if old, ok := s.tasks[task.ID]; ok {
+		task.Messages = old.Messages
+		s.garbage++
+	}
+	s.tasks[task.ID] = &task
+}
+
+// Tasks 返回存储中的所有任务，按提交时间从早到晚排列
+func (s *Store) Tasks() []Task {
+	s.mu.Lock()
+	tasks := make([]Task, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		tasks = append(tasks, t.clone())
+	}
+	s.mu.Unlock()
+
+	sortTasks(tasks)
+	return tasks
+}
+
+// saveTask 记录任务的最新状态，消息由 appendMessages 单独记录
+func (s *Store) saveTask(task Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return errors.New("任务存储已关闭")
+	}
+
+	snapshot := task
+	snapshot.Messages = nil
+	if err := s.appendRecords(record{Type: recordTask, Task: &snapshot}); err != nil {
+		return err
+	}
+	s.put(snapshot)
+	return s.maybeCompact()
+}
+
+// appendMessages 追加任务执行过程中新产生的消息
+func (s *Store) appendMessages(id string, messages ...history.HistoryMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return errors.New("任务存储已关闭")
+	}
+	t, ok := s.tasks[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	if err := s.appendRecords(record{Type: recordMessage, TaskID: id, Messages: messages}); err != nil {
+		return err
+	}
+	t.Messages = append(t.Messages, messages...)
+	return nil
+}
+
+func (s *Store) appendRecords(records ...record) error {
+	w := bufio.NewWriter(s.file)
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("序列化任务记录失败: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("写入任务存储文件失败: %w", err)
+	}
+	return s.file.Sync()
+}
+
+// maybeCompact 在失效记录过多时重写存储文件
+func (s *Store) maybeCompact() error {
+	if s.garbage < compactThreshold || s.garbage < len(s.tasks) {
+		return nil
+	}
+	return s.compact()
+}
+
+// compact 先写临时文件再替换，任何时刻磁盘上都有完整的存储文件
+func (s *Store) compact() error {
+	tasks := make([]Task, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		tasks = append(tasks, *t)
+	}
+	sortTasks(tasks)
+
+	tmpPath := s.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for i := range tasks {
+		if err != nil {
+			break
+		}
+		snapshot := tasks[i]
+		snapshot.Messages = nil
+		err = enc.Encode(record{Type: recordTask, Task: &snapshot})
+		if err == nil && len(tasks[i].Messages) > 0 {
+			err = enc.Encode(record{Type: recordMessage, TaskID: snapshot.ID, Messages: tasks[i].Messages})
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	tmp.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("重写任务存储文件失败: %w", err)
+	}
+
+	s.file.Close()
+	renameErr := os.Rename(tmpPath, s.path)
+	s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if renameErr != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("替换任务存储文件失败: %w", renameErr)
+	}
+	if err != nil {
+		return fmt.Errorf("打开任务存储文件失败: %w", err)
+	}
+	s.garbage = 0
+	return nil
+}
+
+// Close 关闭存储文件，之后的写操作会返回错误
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// sortTasks 按提交时间从早到晚排列任务
+func sortTasks(tasks []Task) {
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+}
@@ -0,0 +1,238 @@
+package asyntask
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcphost/pkg/history"
+)
+
+func openTestStore(t *testing.T, path string) *Store {
+	t.Helper()
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestStoreReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.jsonl")
+	store := openTestStore(t, path)
+
+	now := time.Now()
+	task := Task{ID: "a", Question: "问题", State: StateQueued, CreatedAt: now}
+	if err := store.saveTask(task); err != nil {
+		t.Fatal(err)
+	}
+	msg := history.HistoryMessage{Role: "assistant", Content: []history.ContentBlock{{Type: "text", Text: "第一步"}}}
+	if err := store.appendMessages("a", msg); err != nil {
+		t.Fatal(err)
+	}
+	// 状态快照不含消息，保存新状态不会丢掉之前记录的消息
+	task.State, task.Result = StateSucceeded, "回答"
+	if err := store.saveTask(task); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.saveTask(Task{ID: "b", Question: "第二个", State: StateQueued, CreatedAt: now.Add(time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.appendMessages("不存在", msg); err == nil {
+		t.Fatal("给不存在的任务追加消息应该失败")
+	}
+	store.Close()
+	if err := store.saveTask(task); err == nil {
+		t.Fatal("关闭后写入应该失败")
+	}
+
+	for name, load := range map[string]func() ([]Task, error){
+		"OpenStore": func() ([]Task, error) { return openTestStore(t, path).Tasks(), nil },
+		"LoadTasks": func() ([]Task, error) { return LoadTasks(path) },
+	} {
+		tasks, err := load()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(tasks) != 2 || tasks[0].ID != "a" || tasks[1].ID != "b" {
+			t.Fatalf("%s: 任务 = %+v", name, tasks)
+		}
+		if tasks[0].State != StateSucceeded || tasks[0].Result != "回答" || len(tasks[0].Messages) != 1 {
+			t.Fatalf("%s: 任务 a = %+v", name, tasks[0])
+		}
+	}
+	if tasks, err := LoadTasks(filepath.Join(t.TempDir(), "不存在.jsonl")); err != nil || len(tasks) != 0 {
+		t.Fatalf("文件不存在时应返回空列表，得到 %v, %v", tasks, err)
+	}
+}
+
+func TestStoreSkipsTruncatedLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.jsonl")
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.saveTask(Task{ID: "a", Question: "问题", State: StateQueued, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// 模拟写到一半时崩溃
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"type":"task","task":{"taskid":"b","ques`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	store, err = OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tasks := store.Tasks(); len(tasks) != 1 {
+		t.Fatalf("任务 = %+v", tasks)
+	}
+	// 崩溃后继续写入的记录不能和写了一半的行连在一起
+	if err := store.saveTask(Task{ID: "c", Question: "问题", State: StateQueued, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	tasks, err := LoadTasks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 || tasks[1].ID != "c" {
+		t.Fatalf("任务 = %+v", tasks)
+	}
+}
+
+// crashWhileRunning 用 path 处的存储启动队列，等任务 a 开始执行后关闭队列，
+// 模拟进程在任务运行中退出：存储中的任务 a 保持运行中，排在它后面的任务 b 保持排队中。
+func crashWhileRunning(t *testing.T, path string, opts Options) {
+	t.Helper()
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	runner := newBlockingRunner()
+	opts.Workers = 1
+	opts.Store = store
+	q := New(runner.run, opts)
+	if len(q.List()) == 0 {
+		if _, err := q.Submit(Task{ID: "a", Question: "第一个"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := q.Submit(Task{ID: "b", Question: "第二个"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runner.waitStarted(t, "a")
+	q.Close()
+}
+
+func TestRecover(t *testing.T) {
+	tests := []struct {
+		name         string
+		recovery     Recovery
+		maxAttempts  int
+		crashes      int
+		wantState    State
+		wantError    string
+		wantAttempts int
+		wantMessages int
+	}{
+		{"重新执行", RecoverRetry, 3, 1, StateSucceeded, "", 2, 2},
+		{"超过最大次数后失败", RecoverRetry, 2, 2, StateFailed, "任务执行被中断，已尝试 2 次", 2, 2},
+		{"最大次数为 1 时不重试", RecoverRetry, 1, 1, StateFailed, "任务执行被中断，已尝试 1 次", 1, 1},
+		{"直接标记为失败", RecoverFail, 3, 1, StateFailed, "任务执行被中断", 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tasks.jsonl")
+			opts := Options{Recovery: tt.recovery, MaxAttempts: tt.maxAttempts}
+			for i := 0; i < tt.crashes; i++ {
+				crashWhileRunning(t, path, opts)
+			}
+
+			tasks, err := LoadTasks(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tasks) != 2 || tasks[0].State != StateRunning || tasks[1].State != StateQueued {
+				t.Fatalf("崩溃后存储中的任务 = %+v", tasks)
+			}
+
+			store := openTestStore(t, path)
+			runner := newBlockingRunner()
+			close(runner.release)
+			finished := &finishedTasks{}
+			opts.Workers, opts.Store, opts.OnFinish = 1, store, finished.add
+			q := New(runner.run, opts)
+
+			a := waitTask(t, q, "a")
+			if a.State != tt.wantState || a.Error != tt.wantError || a.Attempts != tt.wantAttempts {
+				t.Fatalf("任务 a = %+v", a)
+			}
+			// 每次执行记录一条消息，之前被中断的执行记录的消息都保留
+			if len(a.Messages) != tt.wantMessages {
+				t.Fatalf("任务 a 的消息数 = %d，期望 %d", len(a.Messages), tt.wantMessages)
+			}
+			// 排队中的任务不受恢复策略影响，继续执行
+			if b := waitTask(t, q, "b"); b.State != StateSucceeded || b.Attempts != 1 {
+				t.Fatalf("任务 b = %+v", b)
+			}
+			q.Close()
+			if task, ok := finished.get("a"); !ok || task.State != tt.wantState {
+				t.Fatalf("OnFinish 收到的任务 a = %+v, %v", task, ok)
+			}
+
+			// 恢复后的结果写回存储，再次打开时不会重复处理
+			store.Close()
+			tasks, err = LoadTasks(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tasks[0].State != tt.wantState || tasks[0].Attempts != tt.wantAttempts || tasks[1].State != StateSucceeded {
+				t.Fatalf("恢复后存储中的任务 = %+v", tasks)
+			}
+			if tt.wantState == StateFailed && !strings.HasPrefix(tasks[0].Error, "任务执行被中断") {
+				t.Fatalf("存储中的失败原因 = %q", tasks[0].Error)
+			}
+		})
+	}
+}
+
+func TestRecoverKeepsFinishedTasks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.jsonl")
+	store := openTestStore(t, path)
+	done := Task{ID: "done", Question: "问题", State: StateSucceeded, Result: "回答", Attempts: 1, CreatedAt: time.Now()}
+	if err := store.saveTask(done); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := newBlockingRunner()
+	finished := &finishedTasks{}
+	q := New(runner.run, Options{Store: store, OnFinish: finished.add})
+	defer q.Close()
+
+	// 已结束的任务只供查询，不会重新执行，也不会再通知
+	task := waitTask(t, q, "done")
+	if task.State != StateSucceeded || task.Result != "回答" {
+		t.Fatalf("任务 = %+v", task)
+	}
+	if err := q.Cancel("done"); err == nil {
+		t.Fatal("已结束的任务不能取消")
+	}
+	q.Close()
+	if len(runner.started) != 0 || finished.len() != 0 {
+		t.Fatalf("已结束的任务不应执行或通知")
+	}
+}
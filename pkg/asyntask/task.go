@@ -1,10 +1,59 @@
 package asyntask
 
-type TaskQueue struct {
-	queue map[string]Task
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/mark3labs/mcphost/pkg/history"
+)
+
+// State 是任务的状态
+type State string
+
+const (
+	StateQueued    State = "queued"    // 等待空闲的 worker
+	StateRunning   State = "running"   // 正在执行
+	StateSucceeded State = "succeeded" // 执行成功，Result 为最终回复
+	StateFailed    State = "failed"    // 执行失败，Error 为失败原因
+	StateCanceled  State = "canceled"  // 被取消
+)
+
+// Terminal 判断任务是否已经结束，结束的任务不会再改变状态
+func (s State) Terminal() bool {
+	return s == StateSucceeded || s == StateFailed || s == StateCanceled
 }
 
+// Task 是一个在后台执行的提问
 type Task struct {
-	taskid   string
-	question string
+	ID         string                   `json:"taskid"`
+	Question   string                   `json:"question"`
+	Model      string                   `json:"model,omitempty"`    // 执行任务使用的模型，为空时由 RunFunc 决定
+	Schedule   string                   `json:"schedule,omitempty"` // 由定时计划提交时为计划名称
+	State      State                    `json:"state"`
+	Result     string                   `json:"result,omitempty"`
+	Error      string                   `json:"error,omitempty"`
+	Messages   []history.HistoryMessage `json:"messages,omitempty"` // 执行过程中产生的消息，包括工具调用和结果；重试时保留之前的记录
+	Attempts   int                      `json:"attempts,omitempty"` // 已开始执行的次数
+	CreatedAt  time.Time                `json:"created_at"`
+	StartedAt  time.Time                `json:"started_at"`
+	FinishedAt time.Time                `json:"finished_at"`
+}
+
+// clone 返回任务的副本，消息列表不与队列内部共享
+func (t Task) clone() Task {
+	t.Messages = append([]history.HistoryMessage(nil), t.Messages...)
+	return t
+}
+
+// RunFunc 执行一个任务，返回最终回复。
+// 执行过程中产生的消息通过 record 记录到任务中；ctx 在任务被取消或队列关闭时取消。
+type RunFunc func(ctx context.Context, task Task, record func(messages ...history.HistoryMessage)) (string, error)
+
+// NewID 生成形如 20060102-150405-a1b2c3 的任务 ID，按字典序即按时间排序
+func NewID() string {
+	buf := make([]byte, 3)
+	_, _ = rand.Read(buf)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(buf)
 }
@@ -0,0 +1,105 @@
+package history
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SourceBlockType is the ContentBlock type of a retrieved chunk the assistant can cite.
+// The chunk text is stored in Text and its citation details in Source.
+const SourceBlockType = "source"
+
+// Source identifies a cited document chunk or tool result
+type Source struct {
+	Index  int    `json:"index"`            // Citation number, cited in answers as [Index]
+	ID     string `json:"id,omitempty"`     // Chunk ID in the knowledge base, if any
+	Path   string `json:"path"`             // Document path, or the tool name for tool results
+	Offset string `json:"offset,omitempty"` // Position of the chunk in the document, e.g. "L12-30" or a heading
+}
+
+// Location returns the path together with the chunk offset
+func (s Source) Location() string {
+	switch {
+	case s.Offset == "":
+		return s.Path
+	case strings.HasPrefix(s.Offset, "L"):
+		return s.Path + ":" + s.Offset
+	default:
+		return s.Path + "#" + s.Offset
+	}
+}
+
+// NewSourceBlock returns a source block carrying text as the citable content
+func NewSourceBlock(src Source, text string) ContentBlock {
+	return ContentBlock{Type: SourceBlockType, Text: text, Source: &src}
+}
+
+// sourceText renders a source block the way the model sees it
+func (b ContentBlock) sourceText() string {
+	if b.Source == nil {
+		return b.Text
+	}
+	return fmt.Sprintf("[%d] 来源: %s\n%s", b.Source.Index, b.Source.Location(), strings.TrimSpace(b.Text))
+}
+
+// citationPattern matches citations such as [1], [2, 3] and [1][4]
+var citationPattern = regexp.MustCompile(`\[(\d+(?:\s*[,，]\s*\d+)*)\]`)
+
+// CitedIndexes returns the distinct citation numbers used in text, in ascending order
+func CitedIndexes(text string) []int {
+	seen := make(map[int]bool)
+	var indexes []int
+	for _, m := range citationPattern.FindAllStringSubmatch(text, -1) {
+		for _, part := range strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == '，' || r == ' ' }) {
+			n, err := strconv.Atoi(part)
+			if err != nil || n <= 0 || seen[n] {
+				continue
+			}
+			seen[n] = true
+			indexes = append(indexes, n)
+		}
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// TurnSources returns the sources available to the current turn: those carried by
+// source blocks and tool results since the last user message with text.
+func TurnSources(messages []HistoryMessage) []Source {
+	start := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" && !messages[i].IsToolResponse() {
+			start = i
+			break
+		}
+	}
+
+	var sources []Source
+	for _, msg := range messages[start:] {
+		for _, block := range msg.Content {
+			if block.Source != nil {
+				sources = append(sources, *block.Source)
+			}
+			sources = append(sources, block.Sources...)
+		}
+	}
+	return sources
+}
+
+// CitedSources returns the sources cited in text, looked up by their index
+func CitedSources(text string, available []Source) []Source {
+	byIndex := make(map[int]Source, len(available))
+	for _, s := range available {
+		byIndex[s.Index] = s
+	}
+	var cited []Source
+	for _, n := range CitedIndexes(text) {
+		if s, ok := byIndex[n]; ok {
+			cited = append(cited, s)
+		}
+	}
+	return cited
+}
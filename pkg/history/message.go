@@ -18,11 +18,14 @@ func (m *HistoryMessage) GetRole() string {
 }
 
 func (m *HistoryMessage) GetContent() string {
-	// Concatenate all text content blocks
+	// Concatenate all text content blocks, with source blocks rendered as numbered citations
 	var content string
 	for _, block := range m.Content {
-		if block.Type == "text" {
+		switch block.Type {
+		case "text":
 			content += block.Text + " "
+		case SourceBlockType:
+			content = strings.TrimRight(content, " ") + "\n\n" + block.sourceText() + " "
 		}
 	}
 	return strings.TrimSpace(content)
@@ -96,4 +99,7 @@ type ContentBlock struct {
 	Name      string          `json:"name,omitempty"`
 	Input     json.RawMessage `json:"input,omitempty"`
 	Content   interface{}     `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+	Source    *Source         `json:"source,omitempty"`  // Citation details of a source block
+	Sources   []Source        `json:"sources,omitempty"` // Sources inside a tool result, or cited by an assistant text
 }
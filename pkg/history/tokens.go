@@ -0,0 +1,54 @@
+package history
+
+import (
+	"encoding/json"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Per-item overhead covering role markers and block framing
+const (
+	messageOverheadTokens = 4
+	blockOverheadTokens   = 3
+)
+
+// EstimateTokens approximates the token count of text without a model-specific tokenizer.
+// CJK characters count as one token each, everything else as roughly four bytes per token.
+func EstimateTokens(text string) int {
+	cjk, other := 0, 0
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			cjk++
+		} else {
+			other += utf8.RuneLen(r)
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// Tokens returns the estimated token count of the block
+func (b ContentBlock) Tokens() int {
+	tokens := blockOverheadTokens + EstimateTokens(b.Name) + EstimateTokens(b.ID)
+	if len(b.Input) > 0 {
+		tokens += EstimateTokens(string(b.Input))
+	}
+	switch {
+	case b.Text != "":
+		// Tool results carry the same content in Text and Content, count it once
+		tokens += EstimateTokens(b.Text)
+	case b.Content != nil:
+		if data, err := json.Marshal(b.Content); err == nil {
+			tokens += EstimateTokens(string(data))
+		}
+	}
+	return tokens
+}
+
+// Tokens returns the estimated token count of the message
+func (m *HistoryMessage) Tokens() int {
+	tokens := messageOverheadTokens
+	for _, block := range m.Content {
+		tokens += block.Tokens()
+	}
+	return tokens
+}
@@ -0,0 +1,72 @@
+package ingest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// codeLanguages 是支持的源代码扩展名及对应的语言名
+var codeLanguages = map[string]string{
+	".go": "go", ".py": "python", ".js": "javascript", ".jsx": "javascript", ".ts": "typescript",
+	".tsx": "typescript", ".java": "java", ".kt": "kotlin", ".scala": "scala", ".c": "c", ".h": "c",
+	".cc": "cpp", ".cpp": "cpp", ".hpp": "cpp", ".cs": "csharp", ".rs": "rust", ".rb": "ruby",
+	".php": "php", ".swift": "swift", ".sh": "shell", ".bash": "shell", ".sql": "sql",
+	".lua": "lua", ".r": "r", ".proto": "protobuf", ".yaml": "yaml", ".yml": "yaml", ".toml": "toml",
+}
+
+// loadCode 按行切分源代码，不在行中间断开，相邻分块按行重叠。
+// 分块优先在空行处结束，使函数等代码块尽量保持完整；元数据 lines 记录起止行号。
+func loadCode(data []byte, language string, opts Options) []Chunk {
+	lines := strings.Split(strings.TrimRight(normalizeNewlines(string(data)), "\n"), "\n")
+	var chunks []Chunk
+
+	start := 0
+	for start < len(lines) {
+		end, size := start, 0
+		lastBlank := -1
+		for end < len(lines) {
+			l := runeLen(lines[end]) + 1
+			if size+l > opts.ChunkSize && end > start {
+				break
+			}
+			size += l
+			if strings.TrimSpace(lines[end]) == "" {
+				lastBlank = end
+			}
+			end++
+		}
+		// 没有读完时，在后半段的空行处结束
+		if end < len(lines) && lastBlank > start+(end-start)/2 {
+			end = lastBlank + 1
+		}
+
+		text := strings.Trim(strings.Join(lines[start:end], "\n"), "\n")
+		if strings.TrimSpace(text) != "" {
+			chunks = append(chunks, Chunk{
+				Text:    text,
+				Content: text,
+				Metadata: map[string]string{
+					"type":     TypeCode,
+					"language": language,
+					"lines":    fmt.Sprintf("%d-%d", start+1, end),
+				},
+			})
+		}
+		if end >= len(lines) {
+			break
+		}
+
+		// 从末尾向前取不超过 overlap 个字符的整行作为下一个分块的开头
+		next, kept := end, 0
+		for next > start+1 {
+			l := runeLen(lines[next-1]) + 1
+			if kept+l > opts.ChunkOverlap {
+				break
+			}
+			kept += l
+			next--
+		}
+		start = next
+	}
+	return chunks
+}
@@ -0,0 +1,134 @@
+package ingest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// skippedElements 中的元素不包含正文
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true,
+	"head": true, "nav": true, "footer": true, "svg": true, "iframe": true,
+}
+
+// blockElements 前后需要换行，避免相邻块的文字粘在一起
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true, "aside": true,
+	"header": true, "ul": true, "ol": true, "li": true, "table": true, "tr": true,
+	"blockquote": true, "pre": true, "br": true, "hr": true, "dl": true, "dt": true, "dd": true,
+	"figure": true, "figcaption": true,
+}
+
+// loadHTML 提取 HTML 正文，把 h1-h6 转换为 Markdown 标题后按 Markdown 的方式切分
+func loadHTML(data []byte, opts Options) ([]Chunk, error) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	if title := findTitle(doc); title != "" {
+		sb.WriteString("# " + title + "\n\n")
+	}
+	writeHTMLText(&sb, doc, false)
+	return markdownChunks(collapseBlankLines(sb.String()), TypeHTML, opts), nil
+}
+
+func findTitle(n *html.Node) string {
+	if n.Type == html.ElementNode && n.Data == "title" {
+		return strings.TrimSpace(nodeText(n))
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if t := findTitle(c); t != "" {
+			return t
+		}
+	}
+	return ""
+}
+
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// writeHTMLText 按文档顺序输出文字；pre 中保留原始空白，其余位置合并连续空白
+func writeHTMLText(sb *strings.Builder, n *html.Node, pre bool) {
+	switch n.Type {
+	case html.TextNode:
+		if pre {
+			sb.WriteString(n.Data)
+		} else if text := strings.Join(strings.Fields(n.Data), " "); text != "" {
+			sb.WriteString(text + " ")
+		}
+		return
+	case html.ElementNode:
+		if skippedElements[n.Data] {
+			return
+		}
+		if len(n.Data) == 2 && n.Data[0] == 'h' && n.Data[1] >= '1' && n.Data[1] <= '6' {
+			level := int(n.Data[1] - '0')
+			if text := nodeText(n); text != "" {
+				fmt.Fprintf(sb, "\n\n%s %s\n\n", strings.Repeat("#", level), text)
+			}
+			return
+		}
+		if n.Data == "pre" && !pre {
+			// 用代码块包住预格式文本，避免其中以 # 开头的行被当作标题
+			sb.WriteString("\n```\n")
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				writeHTMLText(sb, c, true)
+			}
+			sb.WriteString("\n```\n")
+			return
+		}
+		if blockElements[n.Data] {
+			sb.WriteString("\n")
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeHTMLText(sb, c, pre)
+	}
+	if n.Type == html.ElementNode && blockElements[n.Data] {
+		sb.WriteString("\n")
+	}
+}
+
+// collapseBlankLines 去掉行首尾空白，把连续的空行合并为一个；代码块中的内容保持不变
+func collapseBlankLines(s string) string {
+	var lines []string
+	blank := false
+	inFence := false
+	for _, line := range strings.Split(s, "\n") {
+		if fencePattern.MatchString(line) {
+			inFence = !inFence
+		} else if inFence {
+			lines = append(lines, line)
+			blank = false
+			continue
+		}
+		line = strings.TrimRight(line, " \t")
+		if strings.TrimSpace(line) == "" {
+			if !blank && len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		lines = append(lines, strings.TrimLeft(line, " "))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,160 @@
+// Package ingest 读取本地文档并切分为适合生成向量的分块，
+// 支持 Markdown、纯文本、JSON/JSONL 问答、HTML 和源代码。
+package ingest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultChunkSize 是默认的分块长度（按字符计）
+	DefaultChunkSize = 1000
+	// DefaultChunkOverlap 是相邻分块默认重叠的字符数
+	DefaultChunkOverlap = 200
+)
+
+// 分块元数据中 type 字段的取值
+const (
+	TypeMarkdown = "markdown"
+	TypeText     = "text"
+	TypeQA       = "qa"
+	TypeHTML     = "html"
+	TypeCode     = "code"
+)
+
+// Chunk 是文档切分后的一段内容
+type Chunk struct {
+	Text     string            // 用于生成向量的文本
+	Content  string            // 检索命中时返回的内容，问答记录中是答案，其余与 Text 相同
+	Metadata map[string]string // 例如 type、heading、lines
+}
+
+// Hash 返回分块内容的 SHA-256，用于去重和判断分块是否变化
+func (c Chunk) Hash() string {
+	sum := sha256.Sum256([]byte(c.Text + "\x00" + c.Content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Options 控制分块的长度和重叠
+type Options struct {
+	ChunkSize    int // 每个分块的最大字符数，不大于 0 时使用 DefaultChunkSize
+	ChunkOverlap int // 相邻分块重叠的字符数，小于 0 时使用 DefaultChunkOverlap
+}
+
+func (o Options) normalized() (Options, error) {
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = DefaultChunkSize
+	}
+	if o.ChunkOverlap < 0 {
+		o.ChunkOverlap = DefaultChunkOverlap
+	}
+	if o.ChunkOverlap >= o.ChunkSize {
+		return o, fmt.Errorf("分块重叠（%d）必须小于分块长度（%d）", o.ChunkOverlap, o.ChunkSize)
+	}
+	return o, nil
+}
+
+// DefaultOptions 返回默认的分块配置
+func DefaultOptions() Options {
+	return Options{ChunkSize: DefaultChunkSize, ChunkOverlap: DefaultChunkOverlap}
+}
+
+// loader 把文件内容切分为分块
+type loader func(data []byte, opts Options) ([]Chunk, error)
+
+// loaders 按扩展名（小写）选择读取方式
+var loaders = map[string]loader{
+	".md":       loadMarkdown,
+	".markdown": loadMarkdown,
+	".txt":      loadText,
+	".text":     loadText,
+	".json":     loadQAJSON,
+	".jsonl":    loadQAJSONL,
+	".html":     loadHTML,
+	".htm":      loadHTML,
+}
+
+// Supported 判断是否支持读取该文件
+func Supported(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if _, ok := loaders[ext]; ok {
+		return true
+	}
+	_, ok := codeLanguages[ext]
+	return ok
+}
+
+// LoadFile 读取文件并按类型切分为分块
+func LoadFile(path string, opts Options) ([]Chunk, error) {
+	opts, err := opts.normalized()
+	if err != nil {
+		return nil, err
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	load, ok := loaders[ext]
+	if !ok {
+		language, isCode := codeLanguages[ext]
+		if !isCode {
+			return nil, fmt.Errorf("不支持的文件类型: %s", path)
+		}
+		load = func(data []byte, opts Options) ([]Chunk, error) {
+			return loadCode(data, language, opts), nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	chunks, err := load(data, opts)
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", path, err)
+	}
+	return dedupe(chunks), nil
+}
+
+// dedupe 去掉同一文件中内容完全相同的分块，保留第一次出现的
+func dedupe(chunks []Chunk) []Chunk {
+	seen := make(map[string]bool, len(chunks))
+	result := chunks[:0]
+	for _, c := range chunks {
+		if strings.TrimSpace(c.Text) == "" {
+			continue
+		}
+		h := c.Hash()
+		if seen[h] {
+			continue
+		}
+		seen[h] = true
+		result = append(result, c)
+	}
+	return result
+}
+
+// textChunks 把纯文本切分后包装为分块，extra 中的元数据会复制到每个分块，元数据 lines 记录起止行号
+func textChunks(text string, typ string, extra map[string]string, opts Options) []Chunk {
+	var chunks []Chunk
+	pieces := splitText(text, opts.ChunkSize, opts.ChunkOverlap)
+	lines := lineRanges(text, pieces, 1)
+	for i, piece := range pieces {
+		metadata := map[string]string{"type": typ}
+		for k, v := range extra {
+			metadata[k] = v
+		}
+		if lines[i] != "" {
+			metadata["lines"] = lines[i]
+		}
+		chunks = append(chunks, Chunk{Text: piece, Content: piece, Metadata: metadata})
+	}
+	return chunks
+}
+
+func loadText(data []byte, opts Options) ([]Chunk, error) {
+	return textChunks(string(data), TypeText, nil, opts), nil
+}
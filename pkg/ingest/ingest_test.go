@@ -0,0 +1,63 @@
+package ingest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDedupe(t *testing.T) {
+	a := Chunk{Text: "年假五天", Content: "年假五天"}
+	b := Chunk{Text: "病假需要证明", Content: "病假需要证明"}
+	// 问答记录的问题相同但答案不同，不是重复的分块
+	qa := Chunk{Text: "年假五天", Content: "以人事系统为准"}
+	blank := Chunk{Text: " \n", Content: " \n"}
+
+	got := dedupe([]Chunk{a, b, a, blank, qa, b})
+	if want := []Chunk{a, b, qa}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("dedupe = %+v，期望 %+v", got, want)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// 两个章节下的内容相同，带上标题前缀后不是重复的分块
+	md := write("leave.md", "# 年假\r\n\r\n提前三天申请。\r\n\r\n# 调休\r\n\r\n提前三天申请。\r\n")
+	chunks, err := LoadFile(md, DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || chunks[0].Text != "年假\n提前三天申请。" || chunks[1].Metadata["lines"] != "7-7" {
+		t.Fatalf("Markdown 分块 = %+v", chunks)
+	}
+
+	// 同一段落重复出现时只保留第一个，扩展名不区分大小写
+	txt := write("notes.TXT", "重复。\n\n重复。\n\n不同。")
+	chunks, err = LoadFile(txt, Options{ChunkSize: 4, ChunkOverlap: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || chunks[0].Text != "重复。" || chunks[0].Metadata["lines"] != "1-1" || chunks[1].Text != "不同。" {
+		t.Fatalf("文本分块 = %+v", chunks)
+	}
+
+	if _, err := LoadFile(write("data.bin", "x"), DefaultOptions()); err == nil {
+		t.Fatal("不支持的文件类型应该报错")
+	}
+	if _, err := LoadFile(txt, Options{ChunkSize: 10, ChunkOverlap: 10}); err == nil {
+		t.Fatal("重叠不小于分块长度时应该报错")
+	}
+	if _, err := LoadFile(filepath.Join(dir, "missing.md"), DefaultOptions()); err == nil {
+		t.Fatal("文件不存在时应该报错")
+	}
+}
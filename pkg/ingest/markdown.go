@@ -0,0 +1,115 @@
+package ingest
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	fencePattern   = regexp.MustCompile("^\\s*(```|~~~)")
+)
+
+// section 是 Markdown 中一个标题下的正文
+type section struct {
+	headings []string // 从一级标题到当前标题的路径
+	body     string
+	line     int // body 第一行在文件中的行号
+}
+
+// loadMarkdown 按标题切分 Markdown：每个分块只包含同一标题下的内容，
+// 并在开头带上标题路径，使分块脱离上下文后仍能看出所属章节。元数据 lines 记录起止行号。
+func loadMarkdown(data []byte, opts Options) ([]Chunk, error) {
+	return markdownChunks(string(data), TypeMarkdown, opts), nil
+}
+
+func markdownChunks(text, typ string, opts Options) []Chunk {
+	var chunks []Chunk
+	for _, sec := range splitSections(text) {
+		heading := strings.Join(sec.headings, " > ")
+		size := opts.ChunkSize
+		if heading != "" {
+			// 为标题前缀预留空间，保证加上前缀后仍不超过分块长度
+			size = max(opts.ChunkSize-runeLen(heading)-1, opts.ChunkSize/2)
+		}
+		overlap := min(opts.ChunkOverlap, size-1)
+		pieces := splitText(sec.body, size, overlap)
+		lines := lineRanges(sec.body, pieces, sec.line)
+		for i, piece := range pieces {
+			text := piece
+			metadata := map[string]string{"type": typ}
+			if heading != "" {
+				text = heading + "\n" + piece
+				metadata["heading"] = heading
+			}
+			// HTML 转换后的行号与原文件不对应，不记录
+			if typ != TypeHTML && lines[i] != "" {
+				metadata["lines"] = lines[i]
+			}
+			chunks = append(chunks, Chunk{Text: text, Content: text, Metadata: metadata})
+		}
+	}
+	return chunks
+}
+
+// splitSections 按 ATX 标题（# 开头）把文档分节，忽略代码块中的 # 行
+func splitSections(text string) []section {
+	var sections []section
+	var stack []string
+	var body strings.Builder
+	bodyLine := 1
+	inFence := false
+
+	flush := func() {
+		if strings.TrimSpace(body.String()) != "" {
+			sections = append(sections, section{
+				headings: append([]string(nil), stack...),
+				body:     body.String(),
+				line:     bodyLine,
+			})
+		}
+		body.Reset()
+	}
+
+	for i, line := range strings.Split(normalizeNewlines(text), "\n") {
+		if fencePattern.MatchString(line) {
+			inFence = !inFence
+		}
+		if !inFence {
+			if m := headingPattern.FindStringSubmatch(line); m != nil {
+				flush()
+				level := len(m[1])
+				if level <= len(stack) {
+					stack = stack[:level-1]
+				}
+				// 跳级的标题（例如 # 后直接 ###）用空串补齐，拼接路径时去掉
+				for len(stack) < level-1 {
+					stack = append(stack, "")
+				}
+				stack = append(stack, strings.TrimSpace(m[2]))
+				continue
+			}
+		}
+		if body.Len() == 0 {
+			bodyLine = i + 1
+		}
+		body.WriteString(line)
+		body.WriteString("\n")
+	}
+	flush()
+
+	for i := range sections {
+		sections[i].headings = compact(sections[i].headings)
+	}
+	return sections
+}
+
+func compact(items []string) []string {
+	result := items[:0]
+	for _, item := range items {
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
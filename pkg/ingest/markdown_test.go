@@ -0,0 +1,92 @@
+package ingest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitSections(t *testing.T) {
+	doc := strings.Join([]string{
+		"前言",         // 1
+		"# 总则",       // 2
+		"适用范围。",      // 3
+		"## 年假",      // 4
+		"每年五天。",      // 5
+		"```",        // 6
+		"# 代码块中的注释",  // 7
+		"```",        // 8
+		"### 申请 ###", // 9
+		"提前三天。",      // 10
+		"",           // 11
+		"## 病假",      // 12
+		"需要证明。",      // 13
+		"# 附录",       // 14
+		"#### 跳级的标题", // 15
+		"见附件。",       // 16
+		"#没有空格不是标题",  // 17
+		"## 空章节",     // 18
+	}, "\r\n")
+
+	type want struct {
+		headings string
+		body     string
+		line     int
+	}
+	var got []want
+	for _, sec := range splitSections(doc) {
+		got = append(got, want{strings.Join(sec.headings, " > "), strings.TrimSpace(sec.body), sec.line})
+	}
+	expected := []want{
+		{"", "前言", 1},
+		{"总则", "适用范围。", 3},
+		{"总则 > 年假", "每年五天。\n```\n# 代码块中的注释\n```", 5},
+		{"总则 > 年假 > 申请", "提前三天。", 10},
+		{"总则 > 病假", "需要证明。", 13},
+		{"附录 > 跳级的标题", "见附件。\n#没有空格不是标题", 16},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("splitSections =\n%q\n期望\n%q", got, expected)
+	}
+}
+
+func TestMarkdownChunks(t *testing.T) {
+	doc := "# 请假制度\n\n## 年假\n\n" +
+		"员工每年享有五天带薪年假。\n\n" +
+		"年假需要提前三天在系统中申请。\n\n" +
+		"未休完的年假可以顺延到次年第一季度。\n\n" +
+		"## 病假\n\n需要医院证明。\n"
+
+	chunks := markdownChunks(doc, TypeMarkdown, Options{ChunkSize: 50, ChunkOverlap: 0})
+	type want struct {
+		text    string
+		heading string
+		lines   string
+	}
+	var got []want
+	for _, c := range chunks {
+		if c.Content != c.Text || c.Metadata["type"] != TypeMarkdown {
+			t.Fatalf("分块 = %+v", c)
+		}
+		// 加上标题前缀后仍不超过分块长度
+		if n := runeLen(c.Text); n > 50 {
+			t.Fatalf("分块长度 %d 超过 50: %q", n, c.Text)
+		}
+		got = append(got, want{c.Text, c.Metadata["heading"], c.Metadata["lines"]})
+	}
+	expected := []want{
+		{"请假制度 > 年假\n员工每年享有五天带薪年假。\n\n年假需要提前三天在系统中申请。", "请假制度 > 年假", "5-7"},
+		{"请假制度 > 年假\n未休完的年假可以顺延到次年第一季度。", "请假制度 > 年假", "9-9"},
+		{"请假制度 > 病假\n需要医院证明。", "请假制度 > 病假", "13-13"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("markdownChunks =\n%q\n期望\n%q", got, expected)
+	}
+
+	// HTML 转换后的文本行号与原文件不对应，不记录 lines
+	for _, c := range markdownChunks(doc, TypeHTML, Options{ChunkSize: 40}) {
+		if _, ok := c.Metadata["lines"]; ok || c.Metadata["type"] != TypeHTML {
+			t.Fatalf("HTML 分块的元数据 = %v", c.Metadata)
+		}
+	}
+}
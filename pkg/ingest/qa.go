@@ -0,0 +1,103 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// qaRecord 是问答文件中的一条记录。字段名兼容 vdatabase 使用的 instruction/output，
+// 以及常见的 question/answer 和 prompt/response。
+type qaRecord struct {
+	Instruction string `json:"instruction"`
+	Input       string `json:"input"`
+	Output      string `json:"output"`
+	Question    string `json:"question"`
+	Answer      string `json:"answer"`
+	Prompt      string `json:"prompt"`
+	Response    string `json:"response"`
+}
+
+func (r qaRecord) question() string {
+	q := firstNonEmpty(r.Instruction, r.Question, r.Prompt)
+	if r.Input != "" {
+		q += "\n" + r.Input
+	}
+	return strings.TrimSpace(q)
+}
+
+func (r qaRecord) answer() string {
+	return strings.TrimSpace(firstNonEmpty(r.Output, r.Answer, r.Response))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// qaChunks 每条问答生成一个分块：对问题生成向量，命中时返回答案。问答不再切分。
+// 元数据 record 记录是文件中的第几条记录。
+func qaChunks(records []qaRecord) []Chunk {
+	var chunks []Chunk
+	for i, r := range records {
+		q, a := r.question(), r.answer()
+		if q == "" || a == "" {
+			continue
+		}
+		chunks = append(chunks, Chunk{
+			Text:     q,
+			Content:  a,
+			Metadata: map[string]string{"type": TypeQA, "instruction": q, "record": strconv.Itoa(i + 1)},
+		})
+	}
+	return chunks
+}
+
+// loadQAJSON 读取 JSON 数组形式的问答文件
+func loadQAJSON(data []byte, _ Options) ([]Chunk, error) {
+	var records []qaRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("JSON 文件应为问答记录数组: %w", err)
+	}
+	chunks := qaChunks(records)
+	if len(chunks) == 0 && len(records) > 0 {
+		return nil, errors.New("没有找到问答字段（instruction/output、question/answer 或 prompt/response）")
+	}
+	return chunks, nil
+}
+
+// loadQAJSONL 读取每行一条问答记录的 JSONL 文件
+func loadQAJSONL(data []byte, _ Options) ([]Chunk, error) {
+	var records []qaRecord
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var r qaRecord
+		if err := json.Unmarshal(line, &r); err != nil {
+			return nil, fmt.Errorf("第 %d 行: %w", lineNo, err)
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	chunks := qaChunks(records)
+	if len(chunks) == 0 && len(records) > 0 {
+		return nil, errors.New("没有找到问答字段（instruction/output、question/answer 或 prompt/response）")
+	}
+	return chunks, nil
+}
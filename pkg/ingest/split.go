@@ -0,0 +1,132 @@
+package ingest
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// separators 按优先级排列的切分位置：段落、行、句子、词，最后按字符硬切
+var separators = []string{"\n\n", "\n", "。", "！", "？", ". ", "! ", "? ", "；", "; ", "，", ", ", " ", ""}
+
+// splitText 把文本切分为不超过 size 个字符的分块，相邻分块重叠约 overlap 个字符。
+// 优先在段落、行、句子边界处切分，分块尽量填满 size。
+func splitText(text string, size, overlap int) []string {
+	text = strings.TrimSpace(normalizeNewlines(text))
+	if text == "" {
+		return nil
+	}
+	return mergePieces(splitPieces(text, size, separators), size, overlap)
+}
+
+func normalizeNewlines(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\r", "\n")
+}
+
+// splitPieces 递归切分，直到每一段都不超过 size 个字符。分隔符保留在前一段的末尾。
+func splitPieces(text string, size int, seps []string) []string {
+	if runeLen(text) <= size {
+		return []string{text}
+	}
+
+	sep := seps[0]
+	if sep == "" {
+		return hardSplit(text, size)
+	}
+	if !strings.Contains(text, sep) {
+		return splitPieces(text, size, seps[1:])
+	}
+
+	var pieces []string
+	parts := strings.SplitAfter(text, sep)
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		if runeLen(part) > size {
+			pieces = append(pieces, splitPieces(part, size, seps[1:])...)
+		} else {
+			pieces = append(pieces, part)
+		}
+	}
+	return pieces
+}
+
+// hardSplit 在没有任何分隔符可用时按字符数切分
+func hardSplit(text string, size int) []string {
+	var pieces []string
+	runes := []rune(text)
+	for len(runes) > size {
+		pieces = append(pieces, string(runes[:size]))
+		runes = runes[size:]
+	}
+	if len(runes) > 0 {
+		pieces = append(pieces, string(runes))
+	}
+	return pieces
+}
+
+// mergePieces 把小段依次合并为分块；开始新分块时带上上一个分块末尾不超过 overlap 个字符的若干段
+func mergePieces(pieces []string, size, overlap int) []string {
+	var chunks []string
+	var current []string
+	currentLen := 0
+
+	emit := func() {
+		chunk := strings.TrimSpace(strings.Join(current, ""))
+		if chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+	}
+
+	for _, piece := range pieces {
+		pieceLen := runeLen(piece)
+		if currentLen+pieceLen > size && len(current) > 0 {
+			emit()
+			// 从末尾向前保留不超过 overlap 的段作为下一个分块的开头
+			keep := 0
+			kept := 0
+			for i := len(current) - 1; i >= 0; i-- {
+				l := runeLen(current[i])
+				if kept+l > overlap || kept+l+pieceLen > size {
+					break
+				}
+				kept += l
+				keep++
+			}
+			current = append([]string(nil), current[len(current)-keep:]...)
+			currentLen = kept
+		}
+		current = append(current, piece)
+		currentLen += pieceLen
+	}
+	if len(current) > 0 {
+		emit()
+	}
+	return chunks
+}
+
+// lineRanges 返回每个分块在 text 中的起止行号（"a-b"），firstLine 是 text 第一行的行号。
+// pieces 应为 splitText(text, ...) 的结果，依次在 text 中查找；找不到的分块返回空串。
+func lineRanges(text string, pieces []string, firstLine int) []string {
+	text = normalizeNewlines(text)
+	ranges := make([]string, len(pieces))
+	from := 0
+	for i, piece := range pieces {
+		pos := strings.Index(text[from:], piece)
+		if pos < 0 {
+			continue
+		}
+		pos += from
+		start := firstLine + strings.Count(text[:pos], "\n")
+		end := start + strings.Count(piece, "\n")
+		ranges[i] = fmt.Sprintf("%d-%d", start, end)
+		// 相邻分块有重叠，下一个分块从当前分块开头之后查找
+		from = pos + 1
+	}
+	return ranges
+}
+
+func runeLen(s string) int {
+	return utf8.RuneCountInString(s)
+}
@@ -0,0 +1,149 @@
+package ingest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		size    int
+		overlap int
+		want    []string
+	}{
+		{"短文本不切分", "  年假五天。\n", 10, 2, []string{"年假五天。"}},
+		{"空白文本", " \n\n ", 10, 2, nil},
+		{"在段落处切分", "第一段内容。\n\n第二段内容。", 8, 0, []string{"第一段内容。", "第二段内容。"}},
+		{"分块尽量填满", "一一一。二二二。三三三。四四四。", 8, 0, []string{"一一一。二二二。", "三三三。四四四。"}},
+		{
+			name:    "相邻分块重叠",
+			text:    "一一一。二二二。三三三。四四四。",
+			size:    8,
+			overlap: 4,
+			want:    []string{"一一一。二二二。", "二二二。三三三。", "三三三。四四四。"},
+		},
+		// 重叠只取完整的段，末尾一段超过 overlap 时不重叠
+		{"末尾一段超过 overlap", "一一一。二二二。三三三。", 8, 3, []string{"一一一。二二二。", "三三三。"}},
+		{
+			name: "过长的段落按句子切分",
+			text: "短段。\n\n第一句话很长。第二句话很长。第三句话。",
+			size: 8,
+			want: []string{"短段。", "第一句话很长。", "第二句话很长。", "第三句话。"},
+		},
+		{"没有分隔符时按字符硬切", "abcdefghij", 4, 2, []string{"abcd", "efgh", "ij"}},
+		{"英文按句子和单词切分", "One two three. Four five six.", 16, 0, []string{"One two three.", "Four five six."}},
+		{"CRLF 换行", "第一行\r\n第二行\r\n\r\n第三段", 7, 0, []string{"第一行", "第二行", "第三段"}},
+		{"单独的 CR 换行", "第一行\r第二行", 4, 0, []string{"第一行", "第二行"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitText(tt.text, tt.size, tt.overlap)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("splitText = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitTextRespectsSize(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 50; i++ {
+		sb.WriteString("员工每年享有带薪年假，申请需提前三天提交。")
+		if i%7 == 6 {
+			sb.WriteString("\n\n")
+		}
+	}
+	// 还有一个没有任何分隔符的超长段落
+	sb.WriteString(strings.Repeat("长", 130))
+	text := sb.String()
+
+	withoutNewlines := func(s string) string { return strings.ReplaceAll(s, "\n", "") }
+	var noOverlapTotal int
+	// 每句 21 个字符，overlap 不小于一句时才会重叠
+	for _, overlap := range []int{0, 25, 40} {
+		chunks := splitText(text, 60, overlap)
+		if len(chunks) < 2 {
+			t.Fatalf("overlap %d: 只切出 %d 个分块", overlap, len(chunks))
+		}
+		total := 0
+		for _, c := range chunks {
+			if n := runeLen(c); n > 60 || n == 0 {
+				t.Fatalf("overlap %d: 分块长度 %d 超出范围: %q", overlap, n, c)
+			}
+			total += runeLen(withoutNewlines(c))
+		}
+		if overlap == 0 {
+			// 不重叠时分块依次拼起来就是原文，没有丢失或重复的内容
+			if withoutNewlines(strings.Join(chunks, "")) != withoutNewlines(text) {
+				t.Fatal("不重叠的分块拼接后与原文不一致")
+			}
+			noOverlapTotal = total
+		} else if total <= noOverlapTotal {
+			t.Fatalf("overlap %d: 分块之间没有重叠", overlap)
+		}
+	}
+}
+
+func TestLineRanges(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		size      int
+		overlap   int
+		firstLine int
+		want      []string
+	}{
+		{"跨空行", "一\n二\n\n三\n四", 4, 0, 1, []string{"1-2", "4-5"}},
+		{"开头的空行计入行号", "\n\n正文\n", 10, 0, 1, []string{"3-3"}},
+		{"从指定行号开始", "一\n二\n\n三\n四", 4, 0, 10, []string{"10-11", "13-14"}},
+		{"重叠的分块", "第一句。\n第二句。\n第三句。", 10, 5, 1, []string{"1-2", "2-3"}},
+		// 内容相同的分块按出现顺序对应到不同的行
+		{"重复的内容", "重复。\n重复。\n重复。", 6, 3, 1, []string{"1-1", "2-2", "3-3"}},
+		{"CRLF 换行", "一\r\n二\r\n\r\n三\r\n四", 4, 0, 1, []string{"1-2", "4-5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pieces := splitText(tt.text, tt.size, tt.overlap)
+			got := lineRanges(tt.text, pieces, tt.firstLine)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("lineRanges(%q) = %q，期望 %q", pieces, got, tt.want)
+			}
+		})
+	}
+
+	// 找不到的分块返回空串，不影响后面的分块
+	if got := lineRanges("一\n二", []string{"不存在", "二"}, 1); !reflect.DeepEqual(got, []string{"", "2-2"}) {
+		t.Fatalf("lineRanges = %q", got)
+	}
+}
+
+func TestTextChunkLines(t *testing.T) {
+	var lines []string
+	for i := 0; i < 40; i++ {
+		lines = append(lines, "这是第"+strings.Repeat("行", i%5+1)+"内容。")
+		if i%4 == 3 {
+			lines = append(lines, "")
+		}
+	}
+	text := strings.Join(lines, "\r\n")
+
+	// 每个分块记录的行号范围内的原文都包含该分块
+	chunks := textChunks(text, TypeText, map[string]string{"source": "a.txt"}, Options{ChunkSize: 50, ChunkOverlap: 15})
+	for _, c := range chunks {
+		var start, end int
+		if _, err := fmt.Sscanf(c.Metadata["lines"], "%d-%d", &start, &end); err != nil {
+			t.Fatalf("分块 %q 的行号 %q: %v", c.Text, c.Metadata["lines"], err)
+		}
+		window := strings.Join(lines[start-1:end], "\n")
+		if strings.TrimSpace(window) != c.Text {
+			t.Fatalf("第 %d-%d 行 = %q，分块 = %q", start, end, window, c.Text)
+		}
+		if c.Metadata["type"] != TypeText || c.Metadata["source"] != "a.txt" {
+			t.Fatalf("元数据 = %v", c.Metadata)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+// Package inprocess 提供进程内的 MCP 客户端，使内置工具可以像外部 MCP 服务器一样
+// 注册到 mcpClients 中，复用同一套工具命名、调用和展示逻辑。
+package inprocess
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+var _ mcpclient.MCPClient = (*Client)(nil)
+
+// Client 直接调用 server.MCPServer 的进程内 MCP 客户端
+type Client struct {
+	server    *server.MCPServer
+	session   *session
+	requestID atomic.Int64
+
+	mu            sync.RWMutex
+	notifications []func(mcp.JSONRPCNotification)
+	initialized   bool
+	closed        bool
+	done          chan struct{}
+	onClose       func() error
+}
+
+// Option 是 NewClient 的可选配置
+type Option func(*Client)
+
+// WithOnClose 设置关闭客户端时执行的清理函数，例如关闭内置工具依赖的连接
+func WithOnClose(fn func() error) Option {
+	return func(c *Client) {
+		c.onClose = fn
+	}
+}
+
+// NewClient 创建连接到 s 的进程内客户端
+func NewClient(s *server.MCPServer, opts ...Option) (*Client, error) {
+	c := &Client{
+		server: s,
+		done:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.session = &session{
+		id:            fmt.Sprintf("inprocess-%p", c),
+		notifications: make(chan mcp.JSONRPCNotification, 16),
+	}
+	if err := s.RegisterSession(context.Background(), c.session); err != nil {
+		return nil, fmt.Errorf("注册进程内会话失败: %w", err)
+	}
+	go c.forwardNotifications()
+	return c, nil
+}
+
+// forwardNotifications 把服务端发给本会话的通知转交给已注册的处理函数
+func (c *Client) forwardNotifications() {
+	for {
+		select {
+		case notification := <-c.session.notifications:
+			c.mu.RLock()
+			handlers := append([]func(mcp.JSONRPCNotification){}, c.notifications...)
+			c.mu.RUnlock()
+			for _, handler := range handlers {
+				handler(notification)
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// sendRequest 以 JSON-RPC 消息的形式调用服务端，返回 result 字段的原始内容
+func (c *Client) sendRequest(ctx context.Context, method string, params interface{}) (*json.RawMessage, error) {
+	c.mu.RLock()
+	closed, initialized := c.closed, c.initialized
+	c.mu.RUnlock()
+	if closed {
+		return nil, errors.New("客户端已关闭")
+	}
+	if !initialized && method != string(mcp.MethodInitialize) {
+		return nil, errors.New("客户端尚未初始化")
+	}
+
+	request := mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      c.requestID.Add(1),
+		Request: mcp.Request{Method: method},
+		Params:  params,
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	ctx = c.server.WithContext(ctx, c.session)
+	reply := c.server.HandleMessage(ctx, data)
+	if reply == nil {
+		return nil, fmt.Errorf("服务端未返回响应: %s", method)
+	}
+	replyData, err := json.Marshal(reply)
+	if err != nil {
+		return nil, fmt.Errorf("序列化响应失败: %w", err)
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(replyData, &response); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("%s (code %d)", response.Error.Message, response.Error.Code)
+	}
+	return &response.Result, nil
+}
+
+// call 发送请求并把结果解析到 result
+func (c *Client) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	response, err := c.sendRequest(ctx, method, params)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(*response, result); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) Initialize(ctx context.Context, request mcp.InitializeRequest) (*mcp.InitializeResult, error) {
+	var result mcp.InitializeResult
+	if err := c.call(ctx, string(mcp.MethodInitialize), request.Params, &result); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.initialized = true
+	c.mu.Unlock()
+	c.session.Initialize()
+	return &result, nil
+}
+
+func (c *Client) Ping(ctx context.Context) error {
+	return c.call(ctx, string(mcp.MethodPing), nil, nil)
+}
+
+func (c *Client) ListResourcesByPage(ctx context.Context, request mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
+	var result mcp.ListResourcesResult
+	if err := c.call(ctx, string(mcp.MethodResourcesList), request.Params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) ListResources(ctx context.Context, request mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
+	result, err := c.ListResourcesByPage(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	for result.NextCursor != "" {
+		request.Params.Cursor = result.NextCursor
+		page, err := c.ListResourcesByPage(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		result.Resources = append(result.Resources, page.Resources...)
+		result.NextCursor = page.NextCursor
+	}
+	return result, nil
+}
+
+func (c *Client) ListResourceTemplatesByPage(ctx context.Context, request mcp.ListResourceTemplatesRequest) (*mcp.ListResourceTemplatesResult, error) {
+	var result mcp.ListResourceTemplatesResult
+	if err := c.call(ctx, string(mcp.MethodResourcesTemplatesList), request.Params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) ListResourceTemplates(ctx context.Context, request mcp.ListResourceTemplatesRequest) (*mcp.ListResourceTemplatesResult, error) {
+	result, err := c.ListResourceTemplatesByPage(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	for result.NextCursor != "" {
+		request.Params.Cursor = result.NextCursor
+		page, err := c.ListResourceTemplatesByPage(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		result.ResourceTemplates = append(result.ResourceTemplates, page.ResourceTemplates...)
+		result.NextCursor = page.NextCursor
+	}
+	return result, nil
+}
+
+func (c *Client) ReadResource(ctx context.Context, request mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	response, err := c.sendRequest(ctx, string(mcp.MethodResourcesRead), request.Params)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.ParseReadResourceResult(response)
+}
+
+func (c *Client) Subscribe(ctx context.Context, request mcp.SubscribeRequest) error {
+	return c.call(ctx, "resources/subscribe", request.Params, nil)
+}
+
+func (c *Client) Unsubscribe(ctx context.Context, request mcp.UnsubscribeRequest) error {
+	return c.call(ctx, "resources/unsubscribe", request.Params, nil)
+}
+
+func (c *Client) ListPromptsByPage(ctx context.Context, request mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
+	var result mcp.ListPromptsResult
+	if err := c.call(ctx, string(mcp.MethodPromptsList), request.Params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) ListPrompts(ctx context.Context, request mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
+	result, err := c.ListPromptsByPage(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	for result.NextCursor != "" {
+		request.Params.Cursor = result.NextCursor
+		page, err := c.ListPromptsByPage(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		result.Prompts = append(result.Prompts, page.Prompts...)
+		result.NextCursor = page.NextCursor
+	}
+	return result, nil
+}
+
+func (c *Client) GetPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	response, err := c.sendRequest(ctx, string(mcp.MethodPromptsGet), request.Params)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.ParseGetPromptResult(response)
+}
+
+func (c *Client) ListToolsByPage(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	var result mcp.ListToolsResult
+	if err := c.call(ctx, string(mcp.MethodToolsList), request.Params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	result, err := c.ListToolsByPage(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	for result.NextCursor != "" {
+		request.Params.Cursor = result.NextCursor
+		page, err := c.ListToolsByPage(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		result.Tools = append(result.Tools, page.Tools...)
+		result.NextCursor = page.NextCursor
+	}
+	return result, nil
+}
+
+func (c *Client) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	response, err := c.sendRequest(ctx, string(mcp.MethodToolsCall), request.Params)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.ParseCallToolResult(response)
+}
+
+func (c *Client) SetLevel(ctx context.Context, request mcp.SetLevelRequest) error {
+	return c.call(ctx, "logging/setLevel", request.Params, nil)
+}
+
+func (c *Client) Complete(ctx context.Context, request mcp.CompleteRequest) (*mcp.CompleteResult, error) {
+	var result mcp.CompleteResult
+	if err := c.call(ctx, "completion/complete", request.Params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Close 注销会话并停止转发通知
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	c.server.UnregisterSession(c.session.id)
+	close(c.done)
+	if c.onClose != nil {
+		return c.onClose()
+	}
+	return nil
+}
+
+func (c *Client) OnNotification(handler func(notification mcp.JSONRPCNotification)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notifications = append(c.notifications, handler)
+}
+
+// session 实现 server.ClientSession，使服务端可以向进程内客户端发送通知
+type session struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+	initialized   atomic.Bool
+}
+
+func (s *session) SessionID() string {
+	return s.id
+}
+
+func (s *session) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func (s *session) Initialize() {
+	s.initialized.Store(true)
+}
+
+func (s *session) Initialized() bool {
+	return s.initialized.Load()
+}
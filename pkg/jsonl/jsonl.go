@@ -0,0 +1,30 @@
+// Package jsonl 提供按行追加 JSON 记录的存储文件共用的崩溃恢复处理。
+package jsonl
+
+import (
+	"fmt"
+	"os"
+)
+
+// TerminateLastLine 在文件末尾不是换行时补上换行。进程在追加记录时崩溃，最后一行可能只写了一半，
+// 不补换行的话之后追加的记录会和它连成无法解析的一行。f 需要以可读写、追加的方式打开。
+func TerminateLastLine(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("读取文件信息失败: %w", err)
+	}
+	if info.Size() == 0 {
+		return nil
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return fmt.Errorf("读取文件末尾失败: %w", err)
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+	if _, err := f.Write([]byte{'\n'}); err != nil {
+		return fmt.Errorf("补写换行失败: %w", err)
+	}
+	return nil
+}
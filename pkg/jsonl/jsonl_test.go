@@ -0,0 +1,64 @@
+package jsonl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTerminateLastLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"空文件", "", `{"n":2}` + "\n"},
+		{"以换行结尾", `{"n":1}` + "\n", `{"n":1}` + "\n" + `{"n":2}` + "\n"},
+		{"最后一行写了一半", `{"n":1}` + "\n" + `{"n":`, `{"n":1}` + "\n" + `{"n":` + "\n" + `{"n":2}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "records.jsonl")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			if err := TerminateLastLine(f); err != nil {
+				t.Fatal(err)
+			}
+			// 重复调用不会多写换行
+			if err := TerminateLastLine(f); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.WriteString(`{"n":2}` + "\n"); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Fatalf("文件内容 = %q，期望 %q", data, tt.want)
+			}
+		})
+	}
+
+	// 只写方式打开的文件无法读取末尾
+	path := filepath.Join(t.TempDir(), "records.jsonl")
+	if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := TerminateLastLine(f); err == nil {
+		t.Fatal("只写打开的文件应该返回错误")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 )
@@ -29,6 +30,32 @@ func NewClient(apiKey string, baseURL string) *Client {
 }
 
 func (c *Client) CreateMessage(ctx context.Context, req CreateRequest) (*APIMessage, error) {
+	resp, err := c.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var message APIMessage
+	if err := json.NewDecoder(resp.Body).Decode(&message); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+
+	return &message, nil
+}
+
+// StreamMessage sends a streaming request and returns the SSE response body.
+// The caller is responsible for closing it.
+func (c *Client) StreamMessage(ctx context.Context, req CreateRequest) (io.ReadCloser, error) {
+	req.Stream = true
+	resp, err := c.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (c *Client) post(ctx context.Context, req CreateRequest) (*http.Response, error) {
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling request: %w", err)
@@ -47,9 +74,9 @@ func (c *Client) CreateMessage(ctx context.Context, req CreateRequest) (*APIMess
 	if err != nil {
 		return nil, fmt.Errorf("error making request: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
 		var errResp struct {
 			Error struct {
 				Type    string `json:"type"`
@@ -67,10 +94,5 @@ func (c *Client) CreateMessage(ctx context.Context, req CreateRequest) (*APIMess
 		return nil, fmt.Errorf("%s: %s", errResp.Error.Type, errResp.Error.Message)
 	}
 
-	var message APIMessage
-	if err := json.NewDecoder(resp.Body).Decode(&message); err != nil {
-		return nil, fmt.Errorf("error decoding response: %w", err)
-	}
-
-	return &message, nil
+	return resp, nil
 }
@@ -34,6 +34,147 @@ func (p *Provider) CreateMessage(
 	messages []llm.Message,
 	tools []llm.Tool,
 ) (llm.Message, error) {
+	// Make the API call
+	resp, err := p.client.CreateMessage(ctx, p.buildRequest(prompt, messages, tools))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{Msg: *resp}, nil
+}
+
+func (p *Provider) StreamMessage(
+	ctx context.Context,
+	prompt string,
+	messages []llm.Message,
+	tools []llm.Tool,
+) (<-chan llm.StreamEvent, error) {
+	body, err := p.client.StreamMessage(ctx, p.buildRequest(prompt, messages, tools))
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan llm.StreamEvent)
+	go func() {
+		defer close(events)
+		defer body.Close()
+
+		var msg APIMessage
+		toolInputs := make(map[int]*strings.Builder)
+
+		err := llm.ReadSSE(body, func(_, data string) error {
+			var event StreamEvent
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				return fmt.Errorf("error decoding stream event: %w", err)
+			}
+
+			switch event.Type {
+			case "message_start":
+				if event.Message != nil {
+					msg = *event.Message
+					msg.Content = nil
+				}
+			case "content_block_start":
+				if event.ContentBlock == nil {
+					return nil
+				}
+				block := *event.ContentBlock
+				if block.Type == "tool_use" {
+					// The input arrives as input_json_delta fragments
+					block.Input = nil
+					toolInputs[event.Index] = &strings.Builder{}
+					if !llm.SendEvent(ctx, events, llm.StreamEvent{
+						Type: llm.StreamEventToolCall,
+						ToolCall: &llm.ToolCallDelta{
+							Index: event.Index,
+							ID:    block.ID,
+							Name:  block.Name,
+						},
+					}) {
+						return ctx.Err()
+					}
+				}
+				for len(msg.Content) <= event.Index {
+					msg.Content = append(msg.Content, ContentBlock{})
+				}
+				msg.Content[event.Index] = block
+			case "content_block_delta":
+				if event.Delta == nil || event.Index >= len(msg.Content) {
+					return nil
+				}
+				switch event.Delta.Type {
+				case "text_delta":
+					msg.Content[event.Index].Text += event.Delta.Text
+					if !llm.SendEvent(ctx, events, llm.StreamEvent{
+						Type: llm.StreamEventText,
+						Text: event.Delta.Text,
+					}) {
+						return ctx.Err()
+					}
+				case "input_json_delta":
+					if input, ok := toolInputs[event.Index]; ok {
+						input.WriteString(event.Delta.PartialJSON)
+					}
+					if !llm.SendEvent(ctx, events, llm.StreamEvent{
+						Type: llm.StreamEventToolCall,
+						ToolCall: &llm.ToolCallDelta{
+							Index:     event.Index,
+							Arguments: json.RawMessage(event.Delta.PartialJSON),
+						},
+					}) {
+						return ctx.Err()
+					}
+				}
+			case "content_block_stop":
+				if input, ok := toolInputs[event.Index]; ok && event.Index < len(msg.Content) {
+					raw := input.String()
+					if raw == "" {
+						raw = "{}"
+					}
+					msg.Content[event.Index].Input = json.RawMessage(raw)
+				}
+			case "message_delta":
+				if event.Delta != nil && event.Delta.StopReason != nil {
+					msg.StopReason = event.Delta.StopReason
+				}
+				if event.Usage != nil {
+					msg.Usage.OutputTokens = event.Usage.OutputTokens
+				}
+			case "error":
+				if event.Error != nil {
+					return fmt.Errorf("%s: %s", event.Error.Type, event.Error.Message)
+				}
+				return fmt.Errorf("unknown stream error")
+			}
+			return nil
+		})
+		if err != nil {
+			llm.SendEvent(ctx, events, llm.StreamEvent{Type: llm.StreamEventError, Err: err})
+			return
+		}
+
+		if !llm.SendEvent(ctx, events, llm.StreamEvent{
+			Type:         llm.StreamEventUsage,
+			InputTokens:  msg.Usage.InputTokens,
+			OutputTokens: msg.Usage.OutputTokens,
+		}) {
+			return
+		}
+		llm.SendEvent(ctx, events, llm.StreamEvent{
+			Type:    llm.StreamEventDone,
+			Message: &Message{Msg: msg},
+		})
+	}()
+
+	return events, nil
+}
+
+// buildRequest converts the conversation into an Anthropic request
+func (p *Provider) buildRequest(
+	prompt string,
+	messages []llm.Message,
+	tools []llm.Tool,
+) CreateRequest {
 	log.Debug("creating message",
 		"prompt", prompt,
 		"num_messages", len(messages),
@@ -81,6 +222,7 @@ func (p *Provider) CreateMessage(
 							Type:      "tool_result",
 							ToolUseID: block.ToolUseID,
 							Content:   block.Content,
+							IsError:   block.IsError,
 						})
 					}
 				}
@@ -131,19 +273,13 @@ func (p *Provider) CreateMessage(
 		"messages", anthropicMessages,
 		"num_tools", len(tools))
 
-	// Make the API call
-	resp, err := p.client.CreateMessage(ctx, CreateRequest{
+	return CreateRequest{
 		Model:     p.model,
 		Messages:  anthropicMessages,
-		MaxTokens: 4096,
+		MaxTokens: llm.DefaultMaxTokens,
 		Tools:     anthropicTools,
 		System:    p.systemPrompt,
-	})
-	if err != nil {
-		return nil, err
 	}
-
-	return &Message{Msg: *resp}, nil
 }
 
 func (p *Provider) SupportsTools() bool {
@@ -15,6 +15,7 @@ type CreateRequest struct {
 	MaxTokens int            `json:"max_tokens"`
 	System    string         `json:"system,omitempty"`
 	Tools     []Tool         `json:"tools,omitempty"`
+	Stream    bool           `json:"stream,omitempty"`
 }
 
 type MessageParam struct {
@@ -30,6 +31,7 @@ type ContentBlock struct {
 	Name      string          `json:"name,omitempty"`
 	Input     json.RawMessage `json:"input,omitempty"`
 	Content   interface{}     `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
 }
 
 type Tool struct {
@@ -60,6 +62,28 @@ type Usage struct {
 	OutputTokens int `json:"output_tokens"`
 }
 
+// StreamEvent is a single server-sent event of a streaming response
+type StreamEvent struct {
+	Type         string        `json:"type"`
+	Index        int           `json:"index"`
+	Message      *APIMessage   `json:"message,omitempty"`
+	ContentBlock *ContentBlock `json:"content_block,omitempty"`
+	Delta        *StreamDelta  `json:"delta,omitempty"`
+	Usage        *Usage        `json:"usage,omitempty"`
+	Error        *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// StreamDelta holds the incremental part of content_block_delta and message_delta events
+type StreamDelta struct {
+	Type        string  `json:"type"`
+	Text        string  `json:"text,omitempty"`
+	PartialJSON string  `json:"partial_json,omitempty"`
+	StopReason  *string `json:"stop_reason,omitempty"`
+}
+
 // Message implements the llm.Message interface
 type Message struct {
 	Msg APIMessage
@@ -0,0 +1,52 @@
+package llm
+
+import "strings"
+
+// DefaultMaxTokens is the completion budget the providers request per response
+const DefaultMaxTokens = 4096
+
+// contextWindows maps model name prefixes to their context window in tokens.
+// Longer prefixes are listed before shorter ones that they extend.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"claude-3", 200000},
+	{"claude", 200000},
+	{"gpt-4o", 128000},
+	{"gpt-4.1", 1047576},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"gemini-1.5", 1048576},
+	{"gemini-2", 1048576},
+	{"gemini", 32768},
+}
+
+// defaultContextWindows is used when the model is not listed above
+var defaultContextWindows = map[string]int{
+	"anthropic": 200000,
+	"openai":    128000,
+	"google":    32768,
+	// Ollama runs models with a 2048 token context unless num_ctx is raised
+	"ollama": 2048,
+}
+
+// ContextWindow returns the context window in tokens for a "provider:model" string
+func ContextWindow(modelString string) int {
+	provider, model, _ := strings.Cut(modelString, ":")
+	if provider != "ollama" {
+		for _, w := range contextWindows {
+			if strings.HasPrefix(model, w.prefix) {
+				return w.tokens
+			}
+		}
+	}
+	if tokens, ok := defaultContextWindows[provider]; ok {
+		return tokens
+	}
+	return 8192
+}
@@ -2,20 +2,29 @@ package google
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/google/generative-ai-go/genai"
 	"github.com/mark3labs/mcphost/pkg/history"
 	"github.com/mark3labs/mcphost/pkg/llm"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
+// Provider is safe for concurrent use. The chat session and the tool call counter are
+// shared between calls, so calls are serialized: a streaming call holds the lock until
+// its event channel is closed.
 type Provider struct {
-	client *genai.Client
-	model  *genai.GenerativeModel
-	chat   *genai.ChatSession
+	client    *genai.Client
+	modelName string
 
+	// mu guards model, chat and toolCallID
+	mu         sync.Mutex
+	model      *genai.GenerativeModel
+	chat       *genai.ChatSession
 	toolCallID int
 }
 
@@ -30,13 +39,127 @@ func NewProvider(ctx context.Context, apiKey, model, systemPrompt string) (*Prov
 		m.SystemInstruction = genai.NewUserContent(genai.Text(systemPrompt))
 	}
 	return &Provider{
-		client: client,
-		model:  m,
-		chat:   m.StartChat(),
+		client:    client,
+		model:     m,
+		modelName: model,
+		chat:      m.StartChat(),
 	}, nil
 }
 
 func (p *Provider) CreateMessage(ctx context.Context, prompt string, messages []llm.Message, tools []llm.Tool) (llm.Message, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prepareChat(messages, tools)
+	// The provided messages slice (and thus history) already includes the new prompt,
+	// so we just call SendMessage with an empty string that will be trimmed by the server.
+	resp, err := p.chat.SendMessage(ctx, genai.Text(""))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Candidates) == 0 {
+		return nil, fmt.Errorf("no response from model")
+	}
+
+	// The library enforces a generation config with 1 candidate.
+	m := &Message{
+		Candidate:  resp.Candidates[0],
+		toolCallID: p.toolCallID,
+	}
+
+	p.toolCallID += len(m.Candidate.FunctionCalls())
+	return m, nil
+}
+
+func (p *Provider) StreamMessage(ctx context.Context, prompt string, messages []llm.Message, tools []llm.Tool) (<-chan llm.StreamEvent, error) {
+	p.mu.Lock()
+	p.prepareChat(messages, tools)
+	iter := p.chat.SendMessageStream(ctx, genai.Text(""))
+	// Tool call IDs continue from the counter as it was when the lock was taken
+	firstID := p.toolCallID
+
+	events := make(chan llm.StreamEvent)
+	go func() {
+		defer p.mu.Unlock()
+		defer close(events)
+
+		var text strings.Builder
+		var calls []genai.Part
+		var usage *genai.UsageMetadata
+		for {
+			resp, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				llm.SendEvent(ctx, events, llm.StreamEvent{Type: llm.StreamEventError, Err: err})
+				return
+			}
+			if resp.UsageMetadata != nil {
+				usage = resp.UsageMetadata
+			}
+			if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+				continue
+			}
+
+			for _, part := range resp.Candidates[0].Content.Parts {
+				event := llm.StreamEvent{}
+				switch v := part.(type) {
+				case genai.Text:
+					text.WriteString(string(v))
+					event = llm.StreamEvent{Type: llm.StreamEventText, Text: string(v)}
+				case genai.FunctionCall:
+					args, _ := json.Marshal(v.Args)
+					event = llm.StreamEvent{
+						Type: llm.StreamEventToolCall,
+						ToolCall: &llm.ToolCallDelta{
+							Index:     len(calls),
+							ID:        fmt.Sprintf("Tool<%d>", firstID+len(calls)),
+							Name:      v.Name,
+							Arguments: args,
+						},
+					}
+					calls = append(calls, v)
+				default:
+					continue
+				}
+				if !llm.SendEvent(ctx, events, event) {
+					return
+				}
+			}
+		}
+
+		candidate := &genai.Candidate{Content: &genai.Content{Role: roleModel}}
+		if text.Len() > 0 {
+			candidate.Content.Parts = append(candidate.Content.Parts, genai.Text(text.String()))
+		}
+		candidate.Content.Parts = append(candidate.Content.Parts, calls...)
+
+		m := &Message{
+			Candidate:  candidate,
+			toolCallID: firstID,
+		}
+		p.toolCallID = firstID + len(calls)
+
+		if usage != nil {
+			if !llm.SendEvent(ctx, events, llm.StreamEvent{
+				Type:         llm.StreamEventUsage,
+				InputTokens:  int(usage.PromptTokenCount),
+				OutputTokens: int(usage.CandidatesTokenCount),
+			}) {
+				return
+			}
+		}
+		llm.SendEvent(ctx, events, llm.StreamEvent{Type: llm.StreamEventDone, Message: m})
+	}()
+
+	return events, nil
+}
+
+// prepareChat loads the conversation history and tool declarations into the chat session.
+// The caller must hold p.mu.
+func (p *Provider) prepareChat(messages []llm.Message, tools []llm.Tool) {
 	var hist []*genai.Content
 	for _, msg := range messages {
 		for _, call := range msg.GetToolCalls() {
@@ -86,25 +209,40 @@ func (p *Provider) CreateMessage(ctx context.Context, prompt string, messages []
 	}
 
 	p.chat.History = hist
-	// The provided messages slice (and thus history) already includes the new prompt,
-	// so we just call SendMessage with an empty string that will be trimmed by the server.
-	resp, err := p.chat.SendMessage(ctx, genai.Text(""))
+}
+
+// Embed implements llm.Embedder using a batch embedContents request
+func (p *Provider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	em := p.client.EmbeddingModel(p.modelName)
+	batch := em.NewBatch()
+	for _, text := range texts {
+		batch.AddContent(genai.Text(text))
+	}
+	resp, err := em.BatchEmbedContents(ctx, batch)
 	if err != nil {
 		return nil, err
 	}
-
-	if len(resp.Candidates) == 0 {
-		return nil, fmt.Errorf("no response from model")
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Embeddings))
 	}
 
-	// The library enforces a generation config with 1 candidate.
-	m := &Message{
-		Candidate:  resp.Candidates[0],
-		toolCallID: p.toolCallID,
+	vectors := make([][]float32, len(resp.Embeddings))
+	for i, e := range resp.Embeddings {
+		if e == nil {
+			return nil, fmt.Errorf("no embedding returned for input %d", i)
+		}
+		vectors[i] = e.Values
 	}
+	return vectors, nil
+}
 
-	p.toolCallID += len(m.Candidate.FunctionCalls())
-	return m, nil
+// Close releases the underlying genai client
+func (p *Provider) Close() error {
+	return p.client.Close()
 }
 
 func (p *Provider) CreateToolResponse(toolCallID string, content any) (llm.Message, error) {
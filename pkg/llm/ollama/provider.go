@@ -42,6 +42,103 @@ func (p *Provider) CreateMessage(
 	messages []llm.Message,
 	tools []llm.Tool,
 ) (llm.Message, error) {
+	req := p.buildRequest(prompt, messages, tools)
+	req.Stream = boolPtr(false)
+
+	var response api.Message
+	err := p.client.Chat(ctx, req, func(r api.ChatResponse) error {
+		if r.Done {
+			response = r.Message
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &OllamaMessage{Message: response}, nil
+}
+
+func (p *Provider) StreamMessage(
+	ctx context.Context,
+	prompt string,
+	messages []llm.Message,
+	tools []llm.Tool,
+) (<-chan llm.StreamEvent, error) {
+	req := p.buildRequest(prompt, messages, tools)
+	req.Stream = boolPtr(true)
+
+	events := make(chan llm.StreamEvent)
+	go func() {
+		defer close(events)
+
+		response := api.Message{Role: "assistant"}
+		var content strings.Builder
+		var inputTokens, outputTokens int
+
+		// Chat invokes the callback once per streamed chunk
+		err := p.client.Chat(ctx, req, func(r api.ChatResponse) error {
+			if r.Message.Role != "" {
+				response.Role = r.Message.Role
+			}
+			if r.Message.Content != "" {
+				content.WriteString(r.Message.Content)
+				if !llm.SendEvent(ctx, events, llm.StreamEvent{
+					Type: llm.StreamEventText,
+					Text: r.Message.Content,
+				}) {
+					return ctx.Err()
+				}
+			}
+			// Ollama delivers each tool call complete within a single chunk
+			for _, call := range r.Message.ToolCalls {
+				args, _ := json.Marshal(call.Function.Arguments)
+				if !llm.SendEvent(ctx, events, llm.StreamEvent{
+					Type: llm.StreamEventToolCall,
+					ToolCall: &llm.ToolCallDelta{
+						Index:     len(response.ToolCalls),
+						Name:      call.Function.Name,
+						Arguments: args,
+					},
+				}) {
+					return ctx.Err()
+				}
+				response.ToolCalls = append(response.ToolCalls, call)
+			}
+			if r.Done {
+				inputTokens, outputTokens = r.PromptEvalCount, r.EvalCount
+			}
+			return nil
+		})
+		if err != nil {
+			llm.SendEvent(ctx, events, llm.StreamEvent{Type: llm.StreamEventError, Err: err})
+			return
+		}
+
+		response.Content = content.String()
+		if !llm.SendEvent(ctx, events, llm.StreamEvent{
+			Type:         llm.StreamEventUsage,
+			InputTokens:  inputTokens,
+			OutputTokens: outputTokens,
+		}) {
+			return
+		}
+		llm.SendEvent(ctx, events, llm.StreamEvent{
+			Type:    llm.StreamEventDone,
+			Message: &OllamaMessage{Message: response},
+		})
+	}()
+
+	return events, nil
+}
+
+// buildRequest converts the conversation into an Ollama chat request
+func (p *Provider) buildRequest(
+	prompt string,
+	messages []llm.Message,
+	tools []llm.Tool,
+) *api.ChatRequest {
 	log.Debug("creating message",
 		"prompt", prompt,
 		"num_messages", len(messages),
@@ -155,33 +252,34 @@ func (p *Provider) CreateMessage(
 		}
 	}
 
-	var response api.Message
-	log.Debug("creating message",
-		"prompt", prompt,
-		"num_messages", len(messages),
-		"num_tools", len(tools))
-
 	log.Debug("sending messages to Ollama",
 		"messages", ollamaMessages,
 		"num_tools", len(tools))
 
-	err := p.client.Chat(ctx, &api.ChatRequest{
+	return &api.ChatRequest{
 		Model:    p.model,
 		Messages: ollamaMessages,
 		Tools:    ollamaTools,
-		Stream:   boolPtr(false),
-	}, func(r api.ChatResponse) error {
-		if r.Done {
-			response = r.Message
-		}
-		return nil
-	})
+	}
+}
 
+// Embed implements llm.Embedder using the Ollama embed API
+func (p *Provider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	resp, err := p.client.Embed(ctx, &api.EmbedRequest{
+		Model: p.model,
+		Input: texts,
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	return &OllamaMessage{Message: response}, nil
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Embeddings))
+	}
+	return resp.Embeddings, nil
 }
 
 func (p *Provider) SupportsTools() bool {
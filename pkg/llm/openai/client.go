@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 )
 
@@ -26,6 +27,49 @@ func NewClient(apiKey string, baseURL string) *Client {
 }
 
 func (c *Client) CreateChatCompletion(ctx context.Context, req CreateRequest) (*APIResponse, error) {
+	resp, err := c.post(ctx, "/chat/completions", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response APIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// CreateChatCompletionStream sends a streaming request and returns the SSE response body.
+// The caller is responsible for closing it.
+func (c *Client) CreateChatCompletionStream(ctx context.Context, req CreateRequest) (io.ReadCloser, error) {
+	req.Stream = true
+	req.StreamOptions = &StreamOptions{IncludeUsage: true}
+	resp, err := c.post(ctx, "/chat/completions", req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// CreateEmbeddings calls the /embeddings endpoint
+func (c *Client) CreateEmbeddings(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
+	resp, err := c.post(ctx, "/embeddings", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response EmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+
+	return &response, nil
+}
+
+func (c *Client) post(ctx context.Context, path string, req interface{}) (*http.Response, error) {
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling request: %w", err)
@@ -34,7 +78,7 @@ func (c *Client) CreateChatCompletion(ctx context.Context, req CreateRequest) (*
 	httpReq, err := http.NewRequestWithContext(
 		ctx,
 		"POST",
-		fmt.Sprintf("%s/chat/completions", c.baseURL),
+		c.baseURL+path,
 		bytes.NewReader(body),
 	)
 	if err != nil {
@@ -48,9 +92,9 @@ func (c *Client) CreateChatCompletion(ctx context.Context, req CreateRequest) (*
 	if err != nil {
 		return nil, fmt.Errorf("error making request: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
 		var errResp struct {
 			Error struct {
 				Message string `json:"message"`
@@ -64,10 +108,5 @@ func (c *Client) CreateChatCompletion(ctx context.Context, req CreateRequest) (*
 		return nil, fmt.Errorf("%s: %s", errResp.Error.Type, errResp.Error.Message)
 	}
 
-	var response APIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("error decoding response: %w", err)
-	}
-
-	return &response, nil
+	return resp, nil
 }
@@ -45,6 +45,144 @@ func (p *Provider) CreateMessage(
 	messages []llm.Message,
 	tools []llm.Tool,
 ) (llm.Message, error) {
+	req, err := p.buildRequest(prompt, messages, tools)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make the API call
+	resp, err := p.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in response")
+	}
+
+	return &Message{Resp: resp, Choice: &resp.Choices[0]}, nil
+}
+
+func (p *Provider) StreamMessage(
+	ctx context.Context,
+	prompt string,
+	messages []llm.Message,
+	tools []llm.Tool,
+) (<-chan llm.StreamEvent, error) {
+	req, err := p.buildRequest(prompt, messages, tools)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan llm.StreamEvent)
+	go func() {
+		defer close(events)
+		defer body.Close()
+
+		resp := &APIResponse{Object: "chat.completion"}
+		choice := Choice{Message: MessageParam{Role: "assistant"}}
+		var content strings.Builder
+		var toolCalls []ToolCall
+
+		err := llm.ReadSSE(body, func(_, data string) error {
+			if data == "[DONE]" {
+				return nil
+			}
+
+			var chunk StreamChunk
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				return fmt.Errorf("error decoding stream chunk: %w", err)
+			}
+			resp.ID, resp.Created, resp.Model = chunk.ID, chunk.Created, chunk.Model
+			if chunk.Usage != nil {
+				resp.Usage = *chunk.Usage
+			}
+
+			for _, c := range chunk.Choices {
+				if c.Index != 0 {
+					continue
+				}
+				if c.FinishReason != nil {
+					choice.FinishReason = *c.FinishReason
+				}
+				if c.Delta.Role != "" {
+					choice.Message.Role = c.Delta.Role
+				}
+				if c.Delta.Content != nil && *c.Delta.Content != "" {
+					content.WriteString(*c.Delta.Content)
+					if !llm.SendEvent(ctx, events, llm.StreamEvent{
+						Type: llm.StreamEventText,
+						Text: *c.Delta.Content,
+					}) {
+						return ctx.Err()
+					}
+				}
+				for _, call := range c.Delta.ToolCalls {
+					for len(toolCalls) <= call.Index {
+						toolCalls = append(toolCalls, ToolCall{Type: "function"})
+					}
+					tc := &toolCalls[call.Index]
+					if call.ID != "" {
+						tc.ID = call.ID
+					}
+					if call.Function.Name != "" {
+						tc.Function.Name += call.Function.Name
+					}
+					tc.Function.Arguments += call.Function.Arguments
+					if !llm.SendEvent(ctx, events, llm.StreamEvent{
+						Type: llm.StreamEventToolCall,
+						ToolCall: &llm.ToolCallDelta{
+							Index:     call.Index,
+							ID:        call.ID,
+							Name:      call.Function.Name,
+							Arguments: json.RawMessage(call.Function.Arguments),
+						},
+					}) {
+						return ctx.Err()
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			llm.SendEvent(ctx, events, llm.StreamEvent{Type: llm.StreamEventError, Err: err})
+			return
+		}
+
+		if content.Len() > 0 {
+			text := content.String()
+			choice.Message.Content = &text
+		}
+		choice.Message.ToolCalls = toolCalls
+		resp.Choices = []Choice{choice}
+
+		if !llm.SendEvent(ctx, events, llm.StreamEvent{
+			Type:         llm.StreamEventUsage,
+			InputTokens:  resp.Usage.PromptTokens,
+			OutputTokens: resp.Usage.CompletionTokens,
+		}) {
+			return
+		}
+		llm.SendEvent(ctx, events, llm.StreamEvent{
+			Type:    llm.StreamEventDone,
+			Message: &Message{Resp: resp, Choice: &resp.Choices[0]},
+		})
+	}()
+
+	return events, nil
+}
+
+// buildRequest converts the conversation into an OpenAI chat completion request
+func (p *Provider) buildRequest(
+	prompt string,
+	messages []llm.Message,
+	tools []llm.Tool,
+) (CreateRequest, error) {
 	log.Debug("creating message",
 		"prompt", prompt,
 		"num_messages", len(messages),
@@ -86,7 +224,7 @@ func (p *Provider) CreateMessage(
 			for i, call := range toolCalls {
 				args, err := json.Marshal(call.GetArguments())
 				if err != nil {
-					return nil, fmt.Errorf(
+					return CreateRequest{}, fmt.Errorf(
 						"error marshaling function arguments: %w",
 						err,
 					)
@@ -176,23 +314,43 @@ func (p *Provider) CreateMessage(
 		}
 	}
 
-	// Make the API call
-	resp, err := p.client.CreateChatCompletion(ctx, CreateRequest{
+	return CreateRequest{
 		Model:       p.model,
 		Messages:    openaiMessages,
 		Tools:       openaiTools,
-		MaxTokens:   4096,
+		MaxTokens:   llm.DefaultMaxTokens,
 		Temperature: 0.7,
+	}, nil
+}
+
+// Embed implements llm.Embedder using the /embeddings endpoint
+func (p *Provider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	resp, err := p.client.CreateEmbeddings(ctx, EmbeddingRequest{
+		Model:          p.model,
+		Input:          texts,
+		EncodingFormat: "float",
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no choices in response")
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
 	}
-
-	return &Message{Resp: resp, Choice: &resp.Choices[0]}, nil
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("no embedding returned for input %d", i)
+		}
+	}
+	return vectors, nil
 }
 
 func (p *Provider) SupportsTools() bool {
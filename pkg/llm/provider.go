@@ -1,6 +1,10 @@
 package llm
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
 
 // Message represents a message in the conversation
 type Message interface {
@@ -54,6 +58,10 @@ type Provider interface {
 	// CreateMessage sends a message to the LLM and returns the response
 	CreateMessage(ctx context.Context, prompt string, messages []Message, tools []Tool) (Message, error)
 
+	// StreamMessage sends a message to the LLM and streams the response as events.
+	// The returned channel is closed after a StreamEventDone or StreamEventError event.
+	StreamMessage(ctx context.Context, prompt string, messages []Message, tools []Tool) (<-chan StreamEvent, error)
+
 	// CreateToolResponse creates a message representing a tool response
 	CreateToolResponse(toolCallID string, content interface{}) (Message, error)
 
@@ -63,3 +71,85 @@ type Provider interface {
 	// Name returns the provider's name
 	Name() string
 }
+
+// Embedder is implemented by providers that can turn text into embedding vectors.
+// Not every provider supports it, so callers should type-assert a Provider.
+type Embedder interface {
+	// Embed returns one vector per input text, in the same order as texts
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// StreamEventType identifies the kind of a streaming event
+type StreamEventType string
+
+const (
+	// StreamEventText carries a fragment of the response text
+	StreamEventText StreamEventType = "text"
+	// StreamEventToolCall carries a fragment of a tool call
+	StreamEventToolCall StreamEventType = "tool_call"
+	// StreamEventUsage carries token usage statistics
+	StreamEventUsage StreamEventType = "usage"
+	// StreamEventDone carries the fully assembled response message
+	StreamEventDone StreamEventType = "done"
+	// StreamEventError carries an error that terminated the stream
+	StreamEventError StreamEventType = "error"
+)
+
+// StreamEvent is a single incremental update emitted while a response is generated
+type StreamEvent struct {
+	Type StreamEventType
+
+	// Text is the text delta for StreamEventText
+	Text string
+
+	// ToolCall is the tool call fragment for StreamEventToolCall
+	ToolCall *ToolCallDelta
+
+	// InputTokens and OutputTokens are set for StreamEventUsage
+	InputTokens  int
+	OutputTokens int
+
+	// Message is the complete response for StreamEventDone
+	Message Message
+
+	// Err is set for StreamEventError
+	Err error
+}
+
+// ToolCallDelta is a fragment of a tool call produced while streaming.
+// Fragments with the same Index belong to the same tool call.
+type ToolCallDelta struct {
+	Index int
+	// ID and Name are only set on the first fragment of a tool call
+	ID   string
+	Name string
+	// Arguments is a partial JSON encoding of the tool arguments
+	Arguments json.RawMessage
+}
+
+// SendEvent delivers an event to a stream, giving up when ctx is done
+func SendEvent(ctx context.Context, events chan<- StreamEvent, event StreamEvent) bool {
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// CollectStream drains a stream and returns the final message
+func CollectStream(events <-chan StreamEvent) (Message, error) {
+	var message Message
+	for event := range events {
+		switch event.Type {
+		case StreamEventDone:
+			message = event.Message
+		case StreamEventError:
+			return nil, event.Err
+		}
+	}
+	if message == nil {
+		return nil, errors.New("stream ended without a message")
+	}
+	return message, nil
+}
@@ -0,0 +1,478 @@
+// Package mcpconn 提供基于可替换传输层的 MCP 客户端。与 mcp-go 自带的客户端不同，
+// 它可以处理服务器发给客户端的请求（例如 sampling/createMessage 和 ping），
+// 因此能够向服务器声明 sampling 等客户端能力。
+package mcpconn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/charmbracelet/log"
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var _ mcpclient.MCPClient = (*Client)(nil)
+
+// JSON-RPC 错误码
+const (
+	CodeMethodNotFound = -32601
+	CodeInternalError  = -32603
+	// CodeUserRejected 表示用户拒绝了服务器的请求，MCP 规范建议 sampling 被拒绝时使用
+	CodeUserRejected = -1
+)
+
+// Error 是 JSON-RPC 错误。请求处理函数返回 *Error 时按其错误码回复服务器，
+// 其他错误按 CodeInternalError 回复；服务器返回的错误也以 *Error 的形式返回给调用方。
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// RequestHandler 处理服务器发来的请求，返回值序列化后作为 result 回复
+type RequestHandler func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// Transport 在客户端和服务器之间收发 JSON-RPC 消息
+type Transport interface {
+	// Start 建立连接，之后收到的每条消息都交给 handle。handle 不会被并发调用。
+	Start(ctx context.Context, handle func(msg []byte)) error
+	// Send 发送一条消息
+	Send(ctx context.Context, msg []byte) error
+	// Close 断开连接并释放资源
+	Close() error
+	// Done 返回的通道在连接断开（例如子进程退出）后关闭。没有持久连接的传输层返回 nil。
+	Done() <-chan struct{}
+}
+
+// ErrDisconnected 表示与服务器的连接已断开，等待中的请求不会再收到响应
+var ErrDisconnected = errors.New("与 MCP 服务器的连接已断开")
+
+// message 是收到的 JSON-RPC 消息，可能是请求、通知或响应
+type message struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+// reply 是客户端对服务器请求的响应
+type reply struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Client 通过 Transport 与 MCP 服务器通信的客户端
+type Client struct {
+	transport Transport
+	requestID atomic.Int64
+
+	// ctx 在 Close 时取消，用于中止仍在处理的服务器请求
+	ctx    context.Context
+	cancel context.CancelFunc
+	// done 在传输层的连接断开后关闭
+	done chan struct{}
+
+	mu            sync.RWMutex
+	pending       map[int64]chan *message
+	handlers      map[string]RequestHandler
+	notifications []func(mcp.JSONRPCNotification)
+	initialized   bool
+	closed        bool
+	disconnected  bool
+}
+
+// NewClient 创建使用 transport 的客户端，调用 Start 后才会建立连接
+func NewClient(transport Transport) *Client {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Client{
+		transport: transport,
+		ctx:       ctx,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		pending:   make(map[int64]chan *message),
+		handlers:  make(map[string]RequestHandler),
+	}
+}
+
+// Start 建立连接。请求处理函数应在 Start 之前注册，避免漏掉连接后立即到达的请求。
+func (c *Client) Start(ctx context.Context) error {
+	if err := c.transport.Start(ctx, c.handleMessage); err != nil {
+		return err
+	}
+	if done := c.transport.Done(); done != nil {
+		go func() {
+			select {
+			case <-done:
+				c.mu.Lock()
+				c.disconnected = true
+				c.mu.Unlock()
+				close(c.done)
+			case <-c.ctx.Done():
+			}
+		}()
+	}
+	return nil
+}
+
+// Done 返回的通道在与服务器的连接断开后关闭，之后的请求都会返回 ErrDisconnected
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// OnRequest 注册服务器请求的处理函数，同一方法重复注册时以最后一次为准。
+// 服务器的 ping 请求由客户端自动回复，不需要注册。
+func (c *Client) OnRequest(method string, handler RequestHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[method] = handler
+}
+
+func (c *Client) OnNotification(handler func(notification mcp.JSONRPCNotification)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notifications = append(c.notifications, handler)
+}
+
+// handleMessage 分发收到的消息：响应交给等待中的请求，通知交给已注册的处理函数，
+// 服务器请求在新的 goroutine 中处理，处理函数等待用户确认时不会阻塞后续消息
+func (c *Client) handleMessage(data []byte) {
+	var msg message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		log.Debug("忽略无法解析的 MCP 消息", "error", err)
+		return
+	}
+
+	switch {
+	case msg.Method != "" && len(msg.ID) > 0:
+		go c.handleRequest(msg)
+	case msg.Method != "":
+		var notification mcp.JSONRPCNotification
+		if err := json.Unmarshal(data, &notification); err != nil {
+			log.Debug("忽略无法解析的 MCP 通知", "error", err)
+			return
+		}
+		c.mu.RLock()
+		handlers := append([]func(mcp.JSONRPCNotification){}, c.notifications...)
+		c.mu.RUnlock()
+		for _, handler := range handlers {
+			handler(notification)
+		}
+	default:
+		var id int64
+		if err := json.Unmarshal(msg.ID, &id); err != nil {
+			return
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[id]
+		delete(c.pending, id)
+		c.mu.Unlock()
+		if ok {
+			ch <- &msg
+		}
+	}
+}
+
+// handleRequest 调用服务器请求的处理函数并回复结果
+func (c *Client) handleRequest(msg message) {
+	resp := reply{JSONRPC: mcp.JSONRPC_VERSION, ID: msg.ID}
+
+	c.mu.RLock()
+	handler, ok := c.handlers[msg.Method]
+	c.mu.RUnlock()
+	switch {
+	case msg.Method == string(mcp.MethodPing):
+		resp.Result = struct{}{}
+	case !ok:
+		resp.Error = &Error{Code: CodeMethodNotFound, Message: "Method not found: " + msg.Method}
+	default:
+		result, err := handler(c.ctx, msg.Params)
+		var rpcErr *Error
+		switch {
+		case errors.As(err, &rpcErr):
+			resp.Error = rpcErr
+		case err != nil:
+			resp.Error = &Error{Code: CodeInternalError, Message: err.Error()}
+		default:
+			resp.Result = result
+		}
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		log.Error("序列化 MCP 响应失败", "method", msg.Method, "error", err)
+		return
+	}
+	if err := c.transport.Send(c.ctx, data); err != nil {
+		log.Error("回复 MCP 请求失败", "method", msg.Method, "error", err)
+	}
+}
+
+// sendRequest 发送请求并等待响应，返回 result 字段的原始内容
+func (c *Client) sendRequest(ctx context.Context, method string, params interface{}) (*json.RawMessage, error) {
+	c.mu.RLock()
+	closed, initialized, disconnected := c.closed, c.initialized, c.disconnected
+	c.mu.RUnlock()
+	if closed {
+		return nil, errors.New("客户端已关闭")
+	}
+	if disconnected {
+		return nil, ErrDisconnected
+	}
+	if !initialized && method != string(mcp.MethodInitialize) {
+		return nil, errors.New("客户端尚未初始化")
+	}
+
+	id := c.requestID.Add(1)
+	request := mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      id,
+		Request: mcp.Request{Method: method},
+		Params:  params,
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	ch := make(chan *message, 1)
+	c.mu.Lock()
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.transport.Send(ctx, data); err != nil {
+		return nil, fmt.Errorf("发送请求失败: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.ctx.Done():
+		return nil, errors.New("客户端已关闭")
+	case <-c.done:
+		return nil, ErrDisconnected
+	case msg := <-ch:
+		if msg.Error != nil {
+			return nil, msg.Error
+		}
+		return &msg.Result, nil
+	}
+}
+
+// sendNotification 发送不需要响应的通知
+func (c *Client) sendNotification(ctx context.Context, method string) error {
+	notification := mcp.JSONRPCNotification{
+		JSONRPC:      mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{Method: method},
+	}
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("序列化通知失败: %w", err)
+	}
+	return c.transport.Send(ctx, data)
+}
+
+// call 发送请求并把结果解析到 result
+func (c *Client) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	response, err := c.sendRequest(ctx, method, params)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(*response, result); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) Initialize(ctx context.Context, request mcp.InitializeRequest) (*mcp.InitializeResult, error) {
+	var result mcp.InitializeResult
+	if err := c.call(ctx, string(mcp.MethodInitialize), request.Params, &result); err != nil {
+		return nil, err
+	}
+	if err := c.sendNotification(ctx, "notifications/initialized"); err != nil {
+		return nil, fmt.Errorf("发送 initialized 通知失败: %w", err)
+	}
+	c.mu.Lock()
+	c.initialized = true
+	c.mu.Unlock()
+	return &result, nil
+}
+
+func (c *Client) Ping(ctx context.Context) error {
+	return c.call(ctx, string(mcp.MethodPing), nil, nil)
+}
+
+func (c *Client) ListResourcesByPage(ctx context.Context, request mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
+	var result mcp.ListResourcesResult
+	if err := c.call(ctx, string(mcp.MethodResourcesList), request.Params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) ListResources(ctx context.Context, request mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
+	result, err := c.ListResourcesByPage(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	for result.NextCursor != "" {
+		request.Params.Cursor = result.NextCursor
+		page, err := c.ListResourcesByPage(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		result.Resources = append(result.Resources, page.Resources...)
+		result.NextCursor = page.NextCursor
+	}
+	return result, nil
+}
+
+func (c *Client) ListResourceTemplatesByPage(ctx context.Context, request mcp.ListResourceTemplatesRequest) (*mcp.ListResourceTemplatesResult, error) {
+	var result mcp.ListResourceTemplatesResult
+	if err := c.call(ctx, string(mcp.MethodResourcesTemplatesList), request.Params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) ListResourceTemplates(ctx context.Context, request mcp.ListResourceTemplatesRequest) (*mcp.ListResourceTemplatesResult, error) {
+	result, err := c.ListResourceTemplatesByPage(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	for result.NextCursor != "" {
+		request.Params.Cursor = result.NextCursor
+		page, err := c.ListResourceTemplatesByPage(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		result.ResourceTemplates = append(result.ResourceTemplates, page.ResourceTemplates...)
+		result.NextCursor = page.NextCursor
+	}
+	return result, nil
+}
+
+func (c *Client) ReadResource(ctx context.Context, request mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	response, err := c.sendRequest(ctx, string(mcp.MethodResourcesRead), request.Params)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.ParseReadResourceResult(response)
+}
+
+func (c *Client) Subscribe(ctx context.Context, request mcp.SubscribeRequest) error {
+	return c.call(ctx, "resources/subscribe", request.Params, nil)
+}
+
+func (c *Client) Unsubscribe(ctx context.Context, request mcp.UnsubscribeRequest) error {
+	return c.call(ctx, "resources/unsubscribe", request.Params, nil)
+}
+
+func (c *Client) ListPromptsByPage(ctx context.Context, request mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
+	var result mcp.ListPromptsResult
+	if err := c.call(ctx, string(mcp.MethodPromptsList), request.Params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) ListPrompts(ctx context.Context, request mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
+	result, err := c.ListPromptsByPage(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	for result.NextCursor != "" {
+		request.Params.Cursor = result.NextCursor
+		page, err := c.ListPromptsByPage(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		result.Prompts = append(result.Prompts, page.Prompts...)
+		result.NextCursor = page.NextCursor
+	}
+	return result, nil
+}
+
+func (c *Client) GetPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	response, err := c.sendRequest(ctx, string(mcp.MethodPromptsGet), request.Params)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.ParseGetPromptResult(response)
+}
+
+func (c *Client) ListToolsByPage(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	var result mcp.ListToolsResult
+	if err := c.call(ctx, string(mcp.MethodToolsList), request.Params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	result, err := c.ListToolsByPage(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	for result.NextCursor != "" {
+		request.Params.Cursor = result.NextCursor
+		page, err := c.ListToolsByPage(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		result.Tools = append(result.Tools, page.Tools...)
+		result.NextCursor = page.NextCursor
+	}
+	return result, nil
+}
+
+func (c *Client) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	response, err := c.sendRequest(ctx, string(mcp.MethodToolsCall), request.Params)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.ParseCallToolResult(response)
+}
+
+func (c *Client) SetLevel(ctx context.Context, request mcp.SetLevelRequest) error {
+	return c.call(ctx, "logging/setLevel", request.Params, nil)
+}
+
+func (c *Client) Complete(ctx context.Context, request mcp.CompleteRequest) (*mcp.CompleteResult, error) {
+	var result mcp.CompleteResult
+	if err := c.call(ctx, "completion/complete", request.Params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Close 中止仍在处理的服务器请求并关闭传输层
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+	c.cancel()
+	return c.transport.Close()
+}
@@ -0,0 +1,334 @@
+package mcpconn
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Streamable HTTP 传输层使用的请求头
+const (
+	headerSessionID   = "Mcp-Session-Id"
+	headerLastEventID = "Last-Event-ID"
+)
+
+const (
+	// streamRetryInitial 和 streamRetryMax 是 SSE 流断开后重连的初始和最大间隔
+	streamRetryInitial = time.Second
+	streamRetryMax     = 30 * time.Second
+	// httpCloseTimeout 是关闭时通知服务器结束会话的超时时间
+	httpCloseTimeout = 5 * time.Second
+)
+
+// ErrSessionExpired 表示服务器已不再识别当前会话（返回 404），需要重新初始化
+var ErrSessionExpired = errors.New("MCP 会话已失效，需要重新初始化")
+
+// StreamableHTTPTransport 实现 MCP 的 Streamable HTTP 传输：每条消息 POST 到同一个地址，
+// 服务器以 JSON 或 SSE 流返回响应；初始化完成后用 GET 打开 SSE 流接收服务器主动发送的消息。
+// 服务器返回的 Mcp-Session-Id 会附在之后的所有请求中；SSE 流中断时用最后收到的事件 ID
+// 通过 Last-Event-ID 恢复，不会丢失断开期间的消息。
+type StreamableHTTPTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	handleMu sync.Mutex
+	handle   func(msg []byte)
+
+	// ctx 在 Close 时取消，结束所有仍在读取的 SSE 流
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu        sync.Mutex
+	sessionID string
+	listening bool
+}
+
+// HTTPOption 是 NewStreamableHTTPTransport 的可选配置
+type HTTPOption func(*StreamableHTTPTransport)
+
+// WithHeaders 设置每个请求都附带的请求头，例如 Authorization
+func WithHeaders(headers map[string]string) HTTPOption {
+	return func(t *StreamableHTTPTransport) {
+		t.headers = headers
+	}
+}
+
+// WithHTTPClient 设置发送请求使用的 http.Client，例如 httptest.Server.Client()
+func WithHTTPClient(client *http.Client) HTTPOption {
+	return func(t *StreamableHTTPTransport) {
+		t.client = client
+	}
+}
+
+// NewStreamableHTTPTransport 创建连接到 url 的传输层
+func NewStreamableHTTPTransport(url string, opts ...HTTPOption) *StreamableHTTPTransport {
+	t := &StreamableHTTPTransport{url: url, client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+	return t
+}
+
+// SessionID 返回服务器分配的会话 ID，服务器不使用会话时为空
+func (t *StreamableHTTPTransport) SessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+// Start 只记录消息处理函数，连接在发送第一条消息时建立
+func (t *StreamableHTTPTransport) Start(ctx context.Context, handle func(msg []byte)) error {
+	t.handle = handle
+	return nil
+}
+
+// Send POST 一条消息。响应是 SSE 流时在后台读取，流中的消息陆续交给 handle。
+func (t *StreamableHTTPTransport) Send(ctx context.Context, msg []byte) error {
+	sessionID := t.SessionID()
+	req, err := t.newRequest(ctx, http.MethodPost, bytes.NewReader(msg))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	if id := resp.Header.Get(headerSessionID); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound && sessionID != "":
+		resp.Body.Close()
+		t.mu.Lock()
+		t.sessionID = ""
+		t.mu.Unlock()
+		return ErrSessionExpired
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("服务器返回 %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	case strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"):
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			t.readResponseStream(ctx, resp)
+		}()
+	default:
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("读取响应失败: %w", err)
+		}
+		if len(bytes.TrimSpace(body)) > 0 {
+			t.deliver(body)
+		}
+	}
+
+	if isInitializedNotification(msg) {
+		t.listen()
+	}
+	return nil
+}
+
+// readResponseStream 读取 POST 请求返回的 SSE 流，流意外中断时从最后收到的事件恢复
+func (t *StreamableHTTPTransport) readResponseStream(ctx context.Context, resp *http.Response) {
+	stop := context.AfterFunc(t.ctx, func() { resp.Body.Close() })
+	lastID, err := t.readStream(resp.Body, "")
+	stop()
+	resp.Body.Close()
+	if err == nil || lastID == "" || ctx.Err() != nil || t.ctx.Err() != nil {
+		return
+	}
+	log.Debug("MCP 响应流中断，正在恢复", "url", t.url, "last_event_id", lastID, "error", err)
+	t.stream(lastID, true)
+}
+
+// listen 在初始化完成后打开接收服务器消息的 GET 流，只打开一次
+func (t *StreamableHTTPTransport) listen() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.listening {
+		return
+	}
+	t.listening = true
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.stream("", false)
+	}()
+}
+
+// stream 用 GET 请求接收服务器发送的消息，lastID 不为空时带上 Last-Event-ID 从该事件之后恢复。
+// resume 为 true 时用于恢复中断的响应流，流正常结束即返回；否则断开后按退避间隔重连，
+// 直到传输层关闭。服务器不支持 GET 流（405）或会话失效时停止。
+func (t *StreamableHTTPTransport) stream(lastID string, resume bool) {
+	backoff := streamRetryInitial
+	for t.ctx.Err() == nil {
+		req, err := t.newRequest(t.ctx, http.MethodGet, nil)
+		if err != nil {
+			log.Error("创建 MCP 流请求失败", "url", t.url, "error", err)
+			return
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		if lastID != "" {
+			req.Header.Set(headerLastEventID, lastID)
+		}
+
+		resp, err := t.client.Do(req)
+		switch {
+		case err != nil:
+		case resp.StatusCode == http.StatusMethodNotAllowed:
+			resp.Body.Close()
+			log.Debug("MCP 服务器不支持 GET 流", "url", t.url)
+			return
+		case resp.StatusCode == http.StatusNotFound && t.SessionID() != "":
+			resp.Body.Close()
+			log.Warn("MCP 会话已失效，停止接收服务器消息", "url", t.url)
+			return
+		case resp.StatusCode != http.StatusOK:
+			resp.Body.Close()
+			err = fmt.Errorf("服务器返回 %s", resp.Status)
+		default:
+			backoff = streamRetryInitial
+			lastID, err = t.readStream(resp.Body, lastID)
+			resp.Body.Close()
+			if err == nil && resume {
+				return
+			}
+		}
+
+		if t.ctx.Err() != nil {
+			return
+		}
+		log.Debug("MCP 流已断开，稍后重连", "url", t.url, "last_event_id", lastID, "error", err, "backoff", backoff)
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, streamRetryMax)
+	}
+}
+
+// readStream 读取 SSE 流，把 message 事件的数据交给 handle，返回最后收到的事件 ID。
+// 流正常结束时返回 nil。
+func (t *StreamableHTTPTransport) readStream(r io.Reader, lastID string) (string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var event, id string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if id != "" {
+				lastID = id
+			}
+			if len(data) > 0 && (event == "" || event == "message") {
+				t.deliver([]byte(strings.Join(data, "\n")))
+			}
+			event, id, data = "", "", nil
+		case strings.HasPrefix(line, ":"):
+			// 注释行，服务器用作保活
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "id:"):
+			id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	return lastID, scanner.Err()
+}
+
+// deliver 把收到的消息交给 handle，JSON-RPC 批量消息拆开逐条处理
+func (t *StreamableHTTPTransport) deliver(data []byte) {
+	t.handleMu.Lock()
+	defer t.handleMu.Unlock()
+
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(data, &batch); err == nil {
+			for _, msg := range batch {
+				t.handle(msg)
+			}
+			return
+		}
+	}
+	t.handle(data)
+}
+
+// Done 返回 nil：每条消息都是独立的 HTTP 请求，没有需要监视的持久连接
+func (t *StreamableHTTPTransport) Done() <-chan struct{} {
+	return nil
+}
+
+// newRequest 创建附带会话 ID 和自定义请求头的请求
+func (t *StreamableHTTPTransport) newRequest(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.url, body)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	if id := t.SessionID(); id != "" {
+		req.Header.Set(headerSessionID, id)
+	}
+	return req, nil
+}
+
+// Close 结束所有 SSE 流，并用 DELETE 请求通知服务器结束会话
+func (t *StreamableHTTPTransport) Close() error {
+	t.cancel()
+	defer t.wg.Wait()
+	if t.SessionID() == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), httpCloseTimeout)
+	defer cancel()
+	req, err := t.newRequest(ctx, http.MethodDelete, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("结束 MCP 会话失败: %w", err)
+	}
+	resp.Body.Close()
+	// 服务器不允许客户端结束会话时返回 405，不视为错误
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("结束 MCP 会话失败: 服务器返回 %s", resp.Status)
+	}
+	return nil
+}
+
+// isInitializedNotification 判断 msg 是否为客户端的 notifications/initialized 通知
+func isInitializedNotification(msg []byte) bool {
+	var m struct {
+		Method string `json:"method"`
+	}
+	return json.Unmarshal(msg, &m) == nil && m.Method == "notifications/initialized"
+}
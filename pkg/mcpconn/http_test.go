@@ -0,0 +1,325 @@
+package mcpconn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitTimeout 是测试中等待消息到达的上限
+const waitTimeout = 5 * time.Second
+
+const (
+	initializeRequest  = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`
+	initializeResponse = `{"jsonrpc":"2.0","id":1,"result":{}}`
+	initializedNotice  = `{"jsonrpc":"2.0","method":"notifications/initialized"}`
+)
+
+// received 收集传输层交给 handle 的消息
+type received chan string
+
+func (r received) handle(msg []byte) {
+	r <- string(msg)
+}
+
+// next 等待下一条消息
+func (r received) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case msg := <-r:
+		return msg
+	case <-time.After(waitTimeout):
+		t.Fatal("没有收到消息")
+		return ""
+	}
+}
+
+// requestLog 记录服务器收到的请求
+type requestLog struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+// add 以“方法 会话ID”的形式记录请求
+func (l *requestLog) add(r *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.requests = append(l.requests, r.Method+" "+r.Header.Get(headerSessionID))
+}
+
+func (l *requestLog) list() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.requests...)
+}
+
+func newTestTransport(t *testing.T, handler http.HandlerFunc) (*StreamableHTTPTransport, received) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	transport := NewStreamableHTTPTransport(srv.URL, WithHTTPClient(srv.Client()), WithHeaders(map[string]string{"Authorization": "Bearer token"}))
+	msgs := make(received, 16)
+	if err := transport.Start(context.Background(), msgs.handle); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { transport.Close() })
+	return transport, msgs
+}
+
+// rpcMethod 返回请求体中 JSON-RPC 消息的 method
+func rpcMethod(t *testing.T, r *http.Request) string {
+	var m struct {
+		Method string `json:"method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		t.Errorf("解析请求体失败: %v", err)
+	}
+	return m.Method
+}
+
+// writeEvent 写入一个 SSE 事件并立即发送
+func writeEvent(w http.ResponseWriter, id, data string) {
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+	w.(http.Flusher).Flush()
+}
+
+func startEventStream(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+}
+
+func TestHTTPSessionID(t *testing.T) {
+	log := &requestLog{}
+	listening := make(chan struct{})
+	transport, msgs := newTestTransport(t, func(w http.ResponseWriter, r *http.Request) {
+		log.add(r)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("%s 请求缺少自定义请求头", r.Method)
+		}
+		switch r.Method {
+		case http.MethodPost:
+			if rpcMethod(t, r) == "initialize" {
+				w.Header().Set(headerSessionID, "s-1")
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, initializeResponse)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		case http.MethodGet:
+			// 保持流打开，直到客户端断开
+			startEventStream(w)
+			close(listening)
+			<-r.Context().Done()
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+
+	if err := transport.Send(context.Background(), []byte(initializeRequest)); err != nil {
+		t.Fatal(err)
+	}
+	if msg := msgs.next(t); msg != initializeResponse {
+		t.Fatalf("收到 %s", msg)
+	}
+	if transport.SessionID() != "s-1" {
+		t.Fatalf("SessionID = %q，期望 s-1", transport.SessionID())
+	}
+	// 初始化完成后打开 GET 流
+	if err := transport.Send(context.Background(), []byte(initializedNotice)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-listening:
+	case <-time.After(waitTimeout):
+		t.Fatal("初始化完成后没有打开 GET 流")
+	}
+
+	// GET 流仍然打开时 Close 也能返回，并用 DELETE 结束会话
+	done := make(chan error, 1)
+	go func() { done <- transport.Close() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(waitTimeout):
+		t.Fatal("Close 没有结束 GET 流")
+	}
+	want := []string{"POST ", "POST s-1", "GET s-1", "DELETE s-1"}
+	if got := log.list(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("服务器收到的请求 = %q，期望 %q", got, want)
+	}
+}
+
+func TestHTTPSendErrors(t *testing.T) {
+	var calls int
+	transport, _ := newTestTransport(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("不应发送 %s 请求", r.Method)
+			return
+		}
+		calls++
+		switch calls {
+		case 1:
+			w.Header().Set(headerSessionID, "s-1")
+			w.WriteHeader(http.StatusAccepted)
+		case 2:
+			http.Error(w, "内部错误", http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	if err := transport.Send(context.Background(), []byte(initializeRequest)); err != nil {
+		t.Fatal(err)
+	}
+	err := transport.Send(context.Background(), []byte(initializeRequest))
+	if err == nil || !strings.Contains(err.Error(), "内部错误") {
+		t.Fatalf("期望包含响应内容的错误，得到 %v", err)
+	}
+	// 其他错误不影响会话
+	if transport.SessionID() != "s-1" {
+		t.Fatalf("SessionID = %q", transport.SessionID())
+	}
+
+	// 有会话时返回 404 表示会话失效
+	if err := transport.Send(context.Background(), []byte(initializeRequest)); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("期望 ErrSessionExpired，得到 %v", err)
+	}
+	if transport.SessionID() != "" {
+		t.Fatalf("会话失效后 SessionID = %q", transport.SessionID())
+	}
+	// 没有会话时不发送 DELETE
+	if err := transport.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHTTPEventStreamResponse(t *testing.T) {
+	transport, msgs := newTestTransport(t, func(w http.ResponseWriter, r *http.Request) {
+		startEventStream(w)
+		fmt.Fprint(w, ": 保活\n\n")
+		fmt.Fprint(w, "event: ping\ndata: 忽略\n\n")
+		// 多行 data 拼接为一条消息，批量消息拆开逐条处理
+		fmt.Fprint(w, "event: message\ndata: [{\"id\":1},\ndata: {\"id\":2}]\n\n")
+		writeEvent(w, "", `{"id":3}`)
+	})
+
+	if err := transport.Send(context.Background(), []byte(initializeRequest)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`{"id":1}`, `{"id":2}`, `{"id":3}`} {
+		if got := msgs.next(t); got != want {
+			t.Fatalf("收到 %s，期望 %s", got, want)
+		}
+	}
+}
+
+func TestHTTPStreamResume(t *testing.T) {
+	tests := []struct {
+		name string
+		// msg 是客户端发送的消息，决定断开的是 POST 的响应流还是初始化后的 GET 流
+		msg string
+		// wantLastEventIDs 是 GET 请求依次带的 Last-Event-ID
+		wantLastEventIDs []string
+	}{
+		{"响应流", initializeRequest, []string{"1"}},
+		{"GET 流", initializedNotice, []string{"", "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var lastEventIDs []string
+			transport, msgs := newTestTransport(t, func(w http.ResponseWriter, r *http.Request) {
+				lastEventID := r.Header.Get(headerLastEventID)
+				switch {
+				case r.Method == http.MethodPost && rpcMethod(t, r) == "notifications/initialized":
+					w.WriteHeader(http.StatusAccepted)
+					return
+				case r.Method == http.MethodGet:
+					mu.Lock()
+					lastEventIDs = append(lastEventIDs, lastEventID)
+					mu.Unlock()
+				}
+				switch {
+				case r.Method == http.MethodPost || lastEventID == "":
+					// 发出第一个事件后连接中断
+					startEventStream(w)
+					writeEvent(w, "1", `{"id":1}`)
+					panic(http.ErrAbortHandler)
+				case lastEventID == "1":
+					startEventStream(w)
+					writeEvent(w, "2", `{"id":2}`)
+				default:
+					w.WriteHeader(http.StatusMethodNotAllowed)
+				}
+			})
+
+			if err := transport.Send(context.Background(), []byte(tt.msg)); err != nil {
+				t.Fatal(err)
+			}
+			// 断开前后的消息都收到，且没有重复
+			for _, want := range []string{`{"id":1}`, `{"id":2}`} {
+				if got := msgs.next(t); got != want {
+					t.Fatalf("收到 %s，期望 %s", got, want)
+				}
+			}
+			mu.Lock()
+			got := append([]string(nil), lastEventIDs...)
+			mu.Unlock()
+			if len(got) < len(tt.wantLastEventIDs) || fmt.Sprint(got[:len(tt.wantLastEventIDs)]) != fmt.Sprint(tt.wantLastEventIDs) {
+				t.Fatalf("GET 请求的 Last-Event-ID = %q，期望以 %q 开头", got, tt.wantLastEventIDs)
+			}
+		})
+	}
+}
+
+func TestHTTPCloseDeleteStatus(t *testing.T) {
+	tests := []struct {
+		status  int
+		wantErr bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusNoContent, false},
+		// 服务器不允许客户端结束会话或会话已不存在
+		{http.StatusMethodNotAllowed, false},
+		{http.StatusNotFound, false},
+		{http.StatusInternalServerError, true},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			log := &requestLog{}
+			transport, _ := newTestTransport(t, func(w http.ResponseWriter, r *http.Request) {
+				log.add(r)
+				if r.Method == http.MethodDelete {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Header().Set(headerSessionID, "s-1")
+				w.WriteHeader(http.StatusAccepted)
+			})
+			if err := transport.Send(context.Background(), []byte(initializeRequest)); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := transport.Close(); (err != nil) != tt.wantErr {
+				t.Fatalf("Close() 错误 = %v，期望出错 %v", err, tt.wantErr)
+			}
+			want := []string{"POST ", "DELETE s-1"}
+			if got := log.list(); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Fatalf("服务器收到的请求 = %q，期望 %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package mcpconn
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// stdioCloseTimeout 是关闭标准输入后等待子进程退出的时间，超时后强制结束
+const stdioCloseTimeout = 5 * time.Second
+
+// StdioTransport 启动本地子进程，通过标准输入输出收发按行分隔的 JSON-RPC 消息。
+// 子进程的标准错误输出写入调试日志。
+type StdioTransport struct {
+	command string
+	args    []string
+	env     []string
+
+	writeMu sync.Mutex
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	exited  chan struct{}
+}
+
+// NewStdioTransport 创建运行 command 的传输层，env 追加在当前进程的环境变量之后
+func NewStdioTransport(command string, env []string, args ...string) *StdioTransport {
+	return &StdioTransport{command: command, args: args, env: env}
+}
+
+func (t *StdioTransport) Start(ctx context.Context, handle func(msg []byte)) error {
+	cmd := exec.Command(t.command, t.args...)
+	cmd.Env = append(os.Environ(), t.env...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("创建标准输入管道失败: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("创建标准输出管道失败: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("创建标准错误管道失败: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("启动命令失败: %w", err)
+	}
+	t.cmd = cmd
+	t.stdin = stdin
+	t.exited = make(chan struct{})
+
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Debug("MCP 服务器输出", "command", t.command, "line", scanner.Text())
+		}
+	}()
+	go func() {
+		defer close(t.exited)
+		reader := bufio.NewReader(stdout)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				handle(line)
+			}
+			if err != nil {
+				if !errors.Is(err, io.EOF) && !errors.Is(err, os.ErrClosed) {
+					log.Debug("读取 MCP 服务器输出失败", "command", t.command, "error", err)
+				}
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (t *StdioTransport) Send(ctx context.Context, msg []byte) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if t.stdin == nil {
+		return errors.New("传输层尚未启动")
+	}
+	_, err := t.stdin.Write(append(msg, '\n'))
+	return err
+}
+
+// Done 返回的通道在子进程的标准输出关闭（通常是子进程退出）后关闭
+func (t *StdioTransport) Done() <-chan struct{} {
+	return t.exited
+}
+
+// Close 关闭子进程的标准输入并等待它退出，超时未退出时强制结束
+func (t *StdioTransport) Close() error {
+	if t.cmd == nil {
+		return nil
+	}
+	t.writeMu.Lock()
+	t.stdin.Close()
+	t.writeMu.Unlock()
+
+	select {
+	case <-t.exited:
+	case <-time.After(stdioCloseTimeout):
+		t.cmd.Process.Kill()
+	}
+	err := t.cmd.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && !exitErr.Exited() {
+		// 被强制结束的子进程不视为关闭失败
+		return nil
+	}
+	return err
+}
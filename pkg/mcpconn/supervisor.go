@@ -0,0 +1,425 @@
+package mcpconn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var _ mcpclient.MCPClient = (*Supervisor)(nil)
+
+// State 是 Supervisor 管理的服务器连接状态
+type State string
+
+const (
+	StateUp         State = "up"         // 连接正常
+	StateDegraded   State = "degraded"   // 最近的健康检查失败，仍在使用当前连接
+	StateRestarting State = "restarting" // 连接已断开，正在重新连接
+)
+
+// connectTimeout 是一次重新连接（启动并初始化）的超时时间
+const connectTimeout = 30 * time.Second
+
+// ConnectFunc 创建、启动并初始化一个新的客户端，Supervisor 用它重新连接服务器
+type ConnectFunc func(ctx context.Context) (mcpclient.MCPClient, *mcp.InitializeResult, error)
+
+// SupervisorOptions 是 NewSupervisor 的可选配置，零值字段使用默认值
+type SupervisorOptions struct {
+	PingInterval   time.Duration // 健康检查间隔，默认 30 秒
+	PingTimeout    time.Duration // 每次健康检查的超时时间，默认 10 秒
+	MaxFailures    int           // 连续多少次健康检查失败后重新连接，默认 2
+	InitialBackoff time.Duration // 第一次重新连接前的等待时间，默认 1 秒，连续重连时逐次翻倍
+	MaxBackoff     time.Duration // 重新连接等待时间的上限，默认 1 分钟
+
+	// OnReconnect 在重新连接并初始化成功后调用，用于刷新工具列表等与连接相关的状态。
+	// 调用时 Supervisor 已处于 up 状态，可以直接通过它发送请求。
+	OnReconnect func(result *mcp.InitializeResult)
+}
+
+// Status 是 Supervisor 的当前状态
+type Status struct {
+	State     State
+	Since     time.Time // 进入当前状态的时间
+	LastError error     // 最近一次健康检查或重新连接失败的原因，up 状态下为 nil
+	Restarts  int       // 成功重新连接的次数
+}
+
+// Supervisor 包装一个已初始化的客户端：定期发送 ping 检查服务器是否正常，
+// 连接断开、会话失效或连续多次健康检查失败时用 ConnectFunc 按退避间隔重新连接。
+// 它本身实现 mcpclient.MCPClient，重新连接后调用方持有的引用和注册的通知处理函数仍然有效；
+// 重新连接期间的请求直接返回错误。
+type Supervisor struct {
+	name    string
+	connect ConnectFunc
+	opts    SupervisorOptions
+
+	// ctx 在 Close 时取消，结束健康检查和重新连接
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu            sync.RWMutex
+	client        mcpclient.MCPClient
+	status        Status
+	failures      int
+	backoff       time.Duration
+	notifications []func(mcp.JSONRPCNotification)
+	closed        bool
+}
+
+// NewSupervisor 开始监视 client。client 必须已经初始化，name 用于日志。
+func NewSupervisor(name string, client mcpclient.MCPClient, connect ConnectFunc, opts SupervisorOptions) *Supervisor {
+	if opts.PingInterval <= 0 {
+		opts.PingInterval = 30 * time.Second
+	}
+	if opts.PingTimeout <= 0 {
+		opts.PingTimeout = 10 * time.Second
+	}
+	if opts.MaxFailures <= 0 {
+		opts.MaxFailures = 2
+	}
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Minute
+	}
+
+	s := &Supervisor{
+		name:    name,
+		connect: connect,
+		opts:    opts,
+		client:  client,
+		status:  Status{State: StateUp, Since: time.Now()},
+		backoff: opts.InitialBackoff,
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	client.OnNotification(s.forward)
+
+	s.mu.Lock()
+	s.watch(client)
+	s.wg.Add(1)
+	s.mu.Unlock()
+	go s.monitor()
+	return s
+}
+
+// Status 返回当前状态
+func (s *Supervisor) Status() Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// setState 切换状态，调用方需持有 s.mu
+func (s *Supervisor) setState(state State, err error) {
+	s.status.State = state
+	s.status.Since = time.Now()
+	s.status.LastError = err
+}
+
+// forward 把当前连接收到的通知转交给通过 Supervisor 注册的处理函数
+func (s *Supervisor) forward(notification mcp.JSONRPCNotification) {
+	s.mu.RLock()
+	handlers := append([]func(mcp.JSONRPCNotification){}, s.notifications...)
+	s.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(notification)
+	}
+}
+
+// watch 在客户端能报告连接断开时（例如子进程退出）立即重新连接，调用方需持有 s.mu
+func (s *Supervisor) watch(client mcpclient.MCPClient) {
+	conn, ok := client.(interface{ Done() <-chan struct{} })
+	if !ok {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		select {
+		case <-conn.Done():
+			s.restart(client, ErrDisconnected)
+		case <-s.ctx.Done():
+		}
+	}()
+}
+
+// monitor 定期检查服务器是否正常
+func (s *Supervisor) monitor() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.opts.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.check()
+		}
+	}
+}
+
+// check 发送一次 ping。服务器返回 JSON-RPC 错误也说明连接正常。
+func (s *Supervisor) check() {
+	s.mu.RLock()
+	client, state := s.client, s.status.State
+	s.mu.RUnlock()
+	if state == StateRestarting {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, s.opts.PingTimeout)
+	err := client.Ping(ctx)
+	cancel()
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		err = nil
+	}
+
+	s.mu.Lock()
+	if s.closed || s.client != client || s.status.State == StateRestarting {
+		s.mu.Unlock()
+		return
+	}
+	if err == nil {
+		s.failures = 0
+		if s.status.State == StateDegraded {
+			s.setState(StateUp, nil)
+			log.Info("MCP 服务器已恢复", "server", s.name)
+		}
+		s.mu.Unlock()
+		return
+	}
+	s.failures++
+	failures := s.failures
+	if s.status.State == StateUp {
+		s.setState(StateDegraded, err)
+		log.Warn("MCP 服务器健康检查失败", "server", s.name, "error", err)
+	} else {
+		s.status.LastError = err
+	}
+	s.mu.Unlock()
+
+	if failures >= s.opts.MaxFailures {
+		s.restart(client, fmt.Errorf("连续 %d 次健康检查失败: %w", failures, err))
+	}
+}
+
+// restart 关闭 old 并在后台重新连接。old 已不是当前连接或已在重新连接时不做任何事。
+func (s *Supervisor) restart(old mcpclient.MCPClient, reason error) {
+	s.mu.Lock()
+	if s.closed || s.client != old || s.status.State == StateRestarting {
+		s.mu.Unlock()
+		return
+	}
+	// 连接稳定运行超过最大退避时间后重新计算退避，否则沿用上次的等待时间，避免反复崩溃时频繁重启
+	if time.Since(s.status.Since) > s.opts.MaxBackoff {
+		s.backoff = s.opts.InitialBackoff
+	}
+	s.setState(StateRestarting, reason)
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	log.Warn("MCP 服务器连接异常，正在重新连接", "server", s.name, "reason", reason)
+	go func() {
+		defer s.wg.Done()
+		old.Close()
+		s.reconnect()
+	}()
+}
+
+// reconnect 按退避间隔重试 ConnectFunc，直到成功或 Supervisor 关闭
+func (s *Supervisor) reconnect() {
+	for {
+		s.mu.Lock()
+		wait := s.backoff
+		s.backoff = min(s.backoff*2, s.opts.MaxBackoff)
+		s.mu.Unlock()
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		ctx, cancel := context.WithTimeout(s.ctx, connectTimeout)
+		client, result, err := s.connect(ctx)
+		cancel()
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return
+			}
+			s.mu.Lock()
+			s.status.LastError = err
+			next := s.backoff
+			s.mu.Unlock()
+			log.Warn("重新连接 MCP 服务器失败", "server", s.name, "error", err, "retry_in", next)
+			continue
+		}
+		client.OnNotification(s.forward)
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			client.Close()
+			return
+		}
+		s.client = client
+		s.failures = 0
+		s.status.Restarts++
+		s.setState(StateUp, nil)
+		s.watch(client)
+		restarts := s.status.Restarts
+		s.mu.Unlock()
+
+		log.Info("MCP 服务器已重新连接", "server", s.name, "restarts", restarts)
+		if s.opts.OnReconnect != nil {
+			s.opts.OnReconnect(result)
+		}
+		return
+	}
+}
+
+// current 返回当前连接，重新连接期间返回错误
+func (s *Supervisor) current() (mcpclient.MCPClient, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, errors.New("客户端已关闭")
+	}
+	if s.status.State == StateRestarting {
+		return nil, fmt.Errorf("MCP 服务器 %s 正在重新连接", s.name)
+	}
+	return s.client, nil
+}
+
+// call 通过当前连接执行 fn。连接断开或会话失效时立即开始重新连接，不等下一次健康检查。
+func call[T any](s *Supervisor, fn func(client mcpclient.MCPClient) (T, error)) (T, error) {
+	client, err := s.current()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	result, err := fn(client)
+	if errors.Is(err, ErrDisconnected) || errors.Is(err, ErrSessionExpired) {
+		s.restart(client, err)
+	}
+	return result, err
+}
+
+// callErr 是 call 的无返回值版本
+func callErr(s *Supervisor, fn func(client mcpclient.MCPClient) error) error {
+	_, err := call(s, func(client mcpclient.MCPClient) (struct{}, error) {
+		return struct{}{}, fn(client)
+	})
+	return err
+}
+
+func (s *Supervisor) Initialize(ctx context.Context, request mcp.InitializeRequest) (*mcp.InitializeResult, error) {
+	return call(s, func(c mcpclient.MCPClient) (*mcp.InitializeResult, error) { return c.Initialize(ctx, request) })
+}
+
+func (s *Supervisor) Ping(ctx context.Context) error {
+	return callErr(s, func(c mcpclient.MCPClient) error { return c.Ping(ctx) })
+}
+
+func (s *Supervisor) ListResourcesByPage(ctx context.Context, request mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
+	return call(s, func(c mcpclient.MCPClient) (*mcp.ListResourcesResult, error) {
+		return c.ListResourcesByPage(ctx, request)
+	})
+}
+
+func (s *Supervisor) ListResources(ctx context.Context, request mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
+	return call(s, func(c mcpclient.MCPClient) (*mcp.ListResourcesResult, error) { return c.ListResources(ctx, request) })
+}
+
+func (s *Supervisor) ListResourceTemplatesByPage(ctx context.Context, request mcp.ListResourceTemplatesRequest) (*mcp.ListResourceTemplatesResult, error) {
+	return call(s, func(c mcpclient.MCPClient) (*mcp.ListResourceTemplatesResult, error) {
+		return c.ListResourceTemplatesByPage(ctx, request)
+	})
+}
+
+func (s *Supervisor) ListResourceTemplates(ctx context.Context, request mcp.ListResourceTemplatesRequest) (*mcp.ListResourceTemplatesResult, error) {
+	return call(s, func(c mcpclient.MCPClient) (*mcp.ListResourceTemplatesResult, error) {
+		return c.ListResourceTemplates(ctx, request)
+	})
+}
+
+func (s *Supervisor) ReadResource(ctx context.Context, request mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	return call(s, func(c mcpclient.MCPClient) (*mcp.ReadResourceResult, error) { return c.ReadResource(ctx, request) })
+}
+
+func (s *Supervisor) Subscribe(ctx context.Context, request mcp.SubscribeRequest) error {
+	return callErr(s, func(c mcpclient.MCPClient) error { return c.Subscribe(ctx, request) })
+}
+
+func (s *Supervisor) Unsubscribe(ctx context.Context, request mcp.UnsubscribeRequest) error {
+	return callErr(s, func(c mcpclient.MCPClient) error { return c.Unsubscribe(ctx, request) })
+}
+
+func (s *Supervisor) ListPromptsByPage(ctx context.Context, request mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
+	return call(s, func(c mcpclient.MCPClient) (*mcp.ListPromptsResult, error) { return c.ListPromptsByPage(ctx, request) })
+}
+
+func (s *Supervisor) ListPrompts(ctx context.Context, request mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
+	return call(s, func(c mcpclient.MCPClient) (*mcp.ListPromptsResult, error) { return c.ListPrompts(ctx, request) })
+}
+
+func (s *Supervisor) GetPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return call(s, func(c mcpclient.MCPClient) (*mcp.GetPromptResult, error) { return c.GetPrompt(ctx, request) })
+}
+
+func (s *Supervisor) ListToolsByPage(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	return call(s, func(c mcpclient.MCPClient) (*mcp.ListToolsResult, error) { return c.ListToolsByPage(ctx, request) })
+}
+
+func (s *Supervisor) ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	return call(s, func(c mcpclient.MCPClient) (*mcp.ListToolsResult, error) { return c.ListTools(ctx, request) })
+}
+
+func (s *Supervisor) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return call(s, func(c mcpclient.MCPClient) (*mcp.CallToolResult, error) { return c.CallTool(ctx, request) })
+}
+
+func (s *Supervisor) SetLevel(ctx context.Context, request mcp.SetLevelRequest) error {
+	return callErr(s, func(c mcpclient.MCPClient) error { return c.SetLevel(ctx, request) })
+}
+
+func (s *Supervisor) Complete(ctx context.Context, request mcp.CompleteRequest) (*mcp.CompleteResult, error) {
+	return call(s, func(c mcpclient.MCPClient) (*mcp.CompleteResult, error) { return c.Complete(ctx, request) })
+}
+
+// OnNotification 注册通知处理函数，重新连接后继续有效
+func (s *Supervisor) OnNotification(handler func(notification mcp.JSONRPCNotification)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifications = append(s.notifications, handler)
+}
+
+// Close 停止健康检查和重新连接，并关闭当前连接
+func (s *Supervisor) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	client, restarting := s.client, s.status.State == StateRestarting
+	s.mu.Unlock()
+
+	s.cancel()
+	err := error(nil)
+	// 重新连接期间旧连接已经关闭
+	if !restarting {
+		err = client.Close()
+	}
+	s.wg.Wait()
+	return err
+}
@@ -0,0 +1,65 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+)
+
+// 配置中 backend 字段可选的知识库后端
+const (
+	// BackendGRPC 使用 vdatabase 的 gRPC 服务（Milvus + Python），是默认后端
+	BackendGRPC = "grpc"
+	// BackendLocal 使用 pkg/vectorstore 的嵌入式存储，不依赖任何外部服务
+	BackendLocal = "local"
+)
+
+// Backend 是内置 RAG 工具和自动检索使用的知识库
+type Backend interface {
+	// Query 检索与查询语句最相关的内容，结果按相关性从高到低排列
+	Query(ctx context.Context, query string, opts SearchOptions) ([]Hit, error)
+	// Ingest 读取指定路径的文件写入知识库，返回给用户看的结果说明
+	Ingest(ctx context.Context, path string) (string, error)
+	ListCollections(ctx context.Context) ([]Collection, error)
+	DeleteDocuments(ctx context.Context, opts DeleteOptions) (int64, error)
+	Close() error
+}
+
+var (
+	_ Backend = (*Client)(nil)
+	_ Backend = (*LocalBackend)(nil)
+
+	_ KeywordSearcher = (*LocalBackend)(nil)
+)
+
+// Open 按配置打开知识库后端。embedder 只在 local 后端使用，为 nil 时按 cfg.Embedding 创建内置嵌入器。
+func Open(cfg Config, embedder Embedder) (Backend, error) {
+	switch cfg.Backend {
+	case "", BackendGRPC:
+		return Dial(cfg)
+	case BackendLocal:
+		if embedder == nil {
+			var err error
+			if embedder, err = NewEmbedder(cfg.Embedding); err != nil {
+				return nil, err
+			}
+		}
+		return OpenLocal(cfg, embedder)
+	}
+	return nil, fmt.Errorf("不支持的 RAG 后端: %s（可选 %s、%s）", cfg.Backend, BackendGRPC, BackendLocal)
+}
+
+// Describe 返回后端的简短说明，用于日志和 /servers 展示
+func (c Config) Describe() string {
+	if c.Backend == BackendLocal {
+		embedding := c.Embedding
+		if embedding == "" {
+			embedding = "hash"
+		}
+		return fmt.Sprintf("local %s (%s)", c.Path, embedding)
+	}
+	address := c.Address
+	if address == "" {
+		address = DefaultAddress
+	}
+	return fmt.Sprintf("gRPC %s", address)
+}
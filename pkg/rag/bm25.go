@@ -0,0 +1,166 @@
+package rag
+
+import (
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 参数，取常用的默认值
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// bm25Index 是内存中的 BM25 倒排索引，用于按关键词检索。
+// 向量检索容易漏掉标识符、错误码、函数名这类字面匹配，关键词检索可以补上。
+type bm25Index struct {
+	hits     []Hit
+	lengths  []int
+	postings map[string][]posting
+	totalLen int
+}
+
+type posting struct {
+	doc  int
+	freq int
+}
+
+func newBM25Index() *bm25Index {
+	return &bm25Index{postings: make(map[string][]posting)}
+}
+
+// add 把一条内容加入索引
+func (idx *bm25Index) add(hit Hit) {
+	tokens := keywordTokens(keywordText(hit))
+	freq := make(map[string]int, len(tokens))
+	for _, t := range tokens {
+		freq[t]++
+	}
+
+	doc := len(idx.hits)
+	idx.hits = append(idx.hits, hit)
+	idx.lengths = append(idx.lengths, len(tokens))
+	idx.totalLen += len(tokens)
+	for t, n := range freq {
+		idx.postings[t] = append(idx.postings[t], posting{doc: doc, freq: n})
+	}
+}
+
+// search 返回与查询匹配的内容，按 BM25 分数从高到低排列，至多 topK 条。
+// match 不为 nil 时只返回满足条件的内容。
+func (idx *bm25Index) search(query string, topK int, match func(Hit) bool) []Hit {
+	if len(idx.hits) == 0 {
+		return nil
+	}
+
+	n := float64(len(idx.hits))
+	avgLen := float64(idx.totalLen) / n
+	if avgLen == 0 {
+		avgLen = 1
+	}
+
+	scores := make(map[int]float64)
+	seen := make(map[string]bool)
+	for _, t := range keywordTokens(query) {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		list := idx.postings[t]
+		if len(list) == 0 {
+			continue
+		}
+		df := float64(len(list))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for _, p := range list {
+			tf := float64(p.freq)
+			norm := 1 - bm25B + bm25B*float64(idx.lengths[p.doc])/avgLen
+			scores[p.doc] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+	}
+
+	docs := make([]int, 0, len(scores))
+	for doc := range scores {
+		if match == nil || match(idx.hits[doc]) {
+			docs = append(docs, doc)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		if scores[docs[i]] != scores[docs[j]] {
+			return scores[docs[i]] > scores[docs[j]]
+		}
+		return docs[i] < docs[j]
+	})
+	if topK > 0 && len(docs) > topK {
+		docs = docs[:topK]
+	}
+
+	hits := make([]Hit, 0, len(docs))
+	for _, doc := range docs {
+		hit := idx.hits[doc]
+		hit.Distance = -1
+		hit.Score = scores[doc]
+		hits = append(hits, hit)
+	}
+	return hits
+}
+
+// keywordText 返回参与关键词检索的文本：内容、问答的问题、章节标题和文件名
+func keywordText(hit Hit) string {
+	parts := []string{hit.Content}
+	if q := hit.Metadata["instruction"]; q != "" && !strings.Contains(hit.Content, q) {
+		parts = append(parts, q)
+	}
+	if h := hit.Metadata["heading"]; h != "" && !strings.Contains(hit.Content, h) {
+		parts = append(parts, h)
+	}
+	if hit.Source != "" {
+		parts = append(parts, filepath.Base(hit.Source))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// keywordTokens 切分出关键词检索的词项。在 hashFeatures 的基础上，
+// 保留 ERR_CONN_42、pkg.Func 这类带连接符的完整标识符，并拆开驼峰命名，
+// 使查询标识符本身或其中的单词都能命中。
+func keywordTokens(text string) []string {
+	tokens := hashFeatures(text)
+
+	isJoiner := func(r rune) bool { return r == '_' || r == '-' || r == '.' || r == '/' || r == ':' }
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || isJoiner(r)) || isIdeograph(r)
+	})
+	for _, field := range fields {
+		field = strings.TrimFunc(field, isJoiner)
+		if strings.IndexFunc(field, isJoiner) >= 0 {
+			tokens = append(tokens, strings.ToLower(field))
+		}
+		for _, part := range strings.FieldsFunc(field, isJoiner) {
+			if words := splitCamel(part); len(words) > 1 {
+				tokens = append(tokens, words...)
+			}
+		}
+	}
+	return tokens
+}
+
+// splitCamel 按大小写变化拆分驼峰命名，例如 getHTTPResponse 拆为 get、http、response
+func splitCamel(word string) []string {
+	runes := []rune(word)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		lowerToUpper := unicode.IsLower(prev) && unicode.IsUpper(cur)
+		acronymEnd := unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if lowerToUpper || acronymEnd {
+			words = append(words, strings.ToLower(string(runes[start:i])))
+			start = i
+		}
+	}
+	words = append(words, strings.ToLower(string(runes[start:])))
+	return words
+}
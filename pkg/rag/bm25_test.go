@@ -0,0 +1,90 @@
+package rag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeywordTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"带下划线的标识符", "ERR_CONN_42 失败", []string{"err", "conn", "42", "失", "败", "失败", "err_conn_42"}},
+		{"带包名的驼峰函数名", "pkg.GetHTTPResponse()", []string{"pkg", "gethttpresponse", "pkg.gethttpresponse", "get", "http", "response"}},
+		{"驼峰命名", "getUserID", []string{"getuserid", "get", "user", "id"}},
+		{"首尾的连接符被去掉", "/etc/hosts:", []string{"etc", "hosts", "etc/hosts"}},
+		{"中文按单字和相邻两字切分", "向量检索", []string{"向", "量", "向量", "检", "量检", "索", "检索"}},
+		{"中英混合", "调用Search接口", []string{"调", "用", "调用", "search", "接", "口", "接口"}},
+		{"空文本", "  ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keywordTokens(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("keywordTokens(%q) = %q，期望 %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func hitIDs(hits []Hit) []string {
+	ids := make([]string, len(hits))
+	for i, h := range hits {
+		ids[i] = h.ID
+	}
+	return ids
+}
+
+func TestBM25Search(t *testing.T) {
+	idx := newBM25Index()
+	for _, h := range []Hit{
+		{ID: "retry", Source: "docs/net.md", Content: "连接超时时返回 ERR_CONN_42，客户端会自动重试"},
+		{ID: "log", Source: "docs/log.md", Content: "日志中的 ERR_CONN_42 ERR_CONN_42 表示连接被拒绝"},
+		{ID: "faq", Source: "docs/faq.md", Content: "年假为 15 天，需要提前申请"},
+		{ID: "api", Source: "pkg/client.go", Content: "func (c *Client) GetHTTPResponse() 发送请求并读取响应"},
+		{ID: "heading", Source: "docs/guide.md", Content: "按步骤配置即可", Metadata: map[string]string{"heading": "部署指南"}},
+	} {
+		idx.add(h)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		topK  int
+		match func(Hit) bool
+		want  []string
+	}{
+		{"词频高的排在前面", "ERR_CONN_42", 0, nil, []string{"log", "retry"}},
+		// "请求" 中的单字 "请" 也会命中，但相邻两字都匹配的内容分数更高
+		{"中文查询", "年假申请", 0, nil, []string{"faq", "api"}},
+		{"罕见词的权重更高", "超时 连接", 0, nil, []string{"retry", "log"}},
+		{"驼峰拆分后的单词能命中", "http response", 0, nil, []string{"api"}},
+		{"完整标识符能命中", "GetHTTPResponse", 0, nil, []string{"api"}},
+		{"匹配章节标题", "部署", 0, nil, []string{"heading"}},
+		{"匹配文件名", "client.go", 0, nil, []string{"api"}},
+		{"topK 截断结果", "ERR_CONN_42", 1, nil, []string{"log"}},
+		{"按条件过滤", "ERR_CONN_42", 0, func(h Hit) bool { return h.Source == "docs/net.md" }, []string{"retry"}},
+		{"没有匹配", "kubernetes", 0, nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits := idx.search(tt.query, tt.topK, tt.match)
+			if got := hitIDs(hits); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("search(%q) = %q，期望 %q", tt.query, got, tt.want)
+			}
+			for i, h := range hits {
+				if h.Distance != -1 {
+					t.Fatalf("关键词检索结果不应带距离，得到 %v", h.Distance)
+				}
+				if h.Score <= 0 || (i > 0 && h.Score > hits[i-1].Score) {
+					t.Fatalf("分数应为正数且从高到低排列: %v", hits)
+				}
+			}
+		})
+	}
+
+	if hits := newBM25Index().search("任意", 5, nil); hits != nil {
+		t.Fatalf("空索引应返回 nil，得到 %v", hits)
+	}
+}
@@ -0,0 +1,140 @@
+// Package rag 封装 vdatabase 的 RAG gRPC 服务，并以内置 MCP 工具的形式提供检索和入库能力。
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcphost/pkg/rag/vertorpb"
+	"github.com/mark3labs/mcphost/pkg/rag/vertorv2pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	// DefaultAddress 是 vdatabase gRPC 服务的默认地址
+	DefaultAddress = "localhost:50051"
+	// DefaultTimeout 是单次 gRPC 调用的默认超时时间
+	DefaultTimeout = 30 * time.Second
+)
+
+// Config 是配置文件中 rag 部分的内容
+type Config struct {
+	Backend    string   `json:"backend,omitempty"`     // 知识库后端：grpc（默认）或 local
+	Address    string   `json:"address,omitempty"`     // grpc：服务地址，默认 localhost:50051
+	Timeout    Duration `json:"timeout,omitempty"`     // grpc：单次调用超时，例如 "30s"
+	Collection string   `json:"collection,omitempty"`  // 默认检索的集合，为空时使用后端的默认集合
+	Path       string   `json:"path,omitempty"`        // local：数据目录，默认为数据目录下的 rag
+	Metric     string   `json:"metric,omitempty"`      // local：距离度量，l2（默认）或 cosine
+	Index      string   `json:"index,omitempty"`       // local：索引类型，flat（默认）或 hnsw
+	Embedding  string   `json:"embedding,omitempty"`   // local：嵌入模型，hash（默认）或 provider:model，例如 ollama:nomic-embed-text
+	SearchMode string   `json:"search_mode,omitempty"` // 默认检索策略：hybrid（默认）、vector 或 keyword
+	Rerank     bool     `json:"rerank,omitempty"`      // 默认是否用当前对话模型重排检索结果
+}
+
+// Duration 支持在 JSON 中使用 "30s" 这样的字符串或秒数表示时长
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		parsed, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("无效的时长 %q: %w", s, err)
+		}
+		*d = Duration(parsed)
+		return nil
+	}
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return fmt.Errorf("无效的时长: %s", string(data))
+	}
+	*d = Duration(seconds * float64(time.Second))
+	return nil
+}
+
+// Client 是 DataManagement 服务的客户端
+type Client struct {
+	conn       *grpc.ClientConn
+	stub       vertorpb.DataManagementClient
+	v2         vertorv2pb.DataManagementClient
+	timeout    time.Duration
+	collection string
+}
+
+// Dial 按配置连接 RAG 服务。连接是惰性建立的，服务暂时不可用不会导致失败。
+func Dial(cfg Config) (*Client, error) {
+	address := cfg.Address
+	if address == "" {
+		address = DefaultAddress
+	}
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("连接 RAG 服务失败（%s）: %w", address, err)
+	}
+	client := NewClient(conn, time.Duration(cfg.Timeout))
+	client.conn = conn
+	client.collection = cfg.Collection
+	return client, nil
+}
+
+// NewClient 基于已有的连接创建客户端，便于接入自定义连接（例如测试用的内存连接）。
+// timeout 不大于 0 时使用 DefaultTimeout。
+func NewClient(cc grpc.ClientConnInterface, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Client{
+		stub:    vertorpb.NewDataManagementClient(cc),
+		v2:      vertorv2pb.NewDataManagementClient(cc),
+		timeout: timeout,
+	}
+}
+
+// Search 根据查询语句检索知识库，返回服务端给出的结果文本
+func (c *Client) Search(ctx context.Context, query string) (string, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", errors.New("查询内容不能为空")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	resp, err := c.stub.GetDatabyPrompt(ctx, &vertorpb.Request{Prompt: query})
+	if err != nil {
+		return "", fmt.Errorf("检索知识库失败: %w", err)
+	}
+	return resp.GetAnswer(), nil
+}
+
+// Ingest 请求服务端读取并入库指定路径的文件（路径需对服务端可见）
+func (c *Client) Ingest(ctx context.Context, path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", errors.New("文件路径不能为空")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	resp, err := c.stub.Updatabypath(ctx, &vertorpb.Request{Prompt: path})
+	if err != nil {
+		return "", fmt.Errorf("写入知识库失败: %w", err)
+	}
+	return resp.GetAnswer(), nil
+}
+
+// Close 关闭由 Dial 建立的连接
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
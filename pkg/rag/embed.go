@@ -0,0 +1,129 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcphost/pkg/llm"
+)
+
+// DefaultHashDimension 是内置哈希嵌入的默认维度
+const DefaultHashDimension = 256
+
+// Embedder 把文本转换为向量，与模型提供方实现的 llm.Embedder 相同
+type Embedder = llm.Embedder
+
+// IsBuiltinEmbedding 判断配置中的 embedding 是否为内置的哈希嵌入（为空时也使用哈希嵌入）。
+// 其余取值是 provider:model 形式的模型，需要由调用方创建对应的模型提供方。
+func IsBuiltinEmbedding(spec string) bool {
+	name, _, _ := strings.Cut(spec, ":")
+	return name == "" || name == "hash"
+}
+
+// NewEmbedder 创建内置嵌入器，spec 为 "hash" 或 "hash:<维度>"，为空时使用 hash
+func NewEmbedder(spec string) (Embedder, error) {
+	name, arg, _ := strings.Cut(spec, ":")
+	switch name {
+	case "", "hash":
+		dim := DefaultHashDimension
+		if arg != "" {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("无效的哈希嵌入维度: %s", arg)
+			}
+			dim = n
+		}
+		return HashEmbedder{Dimension: dim}, nil
+	}
+	return nil, fmt.Errorf("不是内置的嵌入模型: %s", spec)
+}
+
+// HashEmbedder 用特征哈希把词和汉字片段映射为向量，不依赖模型或外部服务。
+// 它只反映字面重合程度，不理解语义，适合作为没有嵌入模型时的默认选项。
+type HashEmbedder struct {
+	Dimension int
+}
+
+func (e HashEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	dim := e.Dimension
+	if dim <= 0 {
+		dim = DefaultHashDimension
+	}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, dim)
+		for _, feature := range hashFeatures(text) {
+			h := fnv.New64a()
+			h.Write([]byte(feature))
+			sum := h.Sum64()
+			// 用哈希的最高位决定符号，减少不同特征落到同一维度时的相互抵消偏差
+			if sum>>63 == 1 {
+				v[sum%uint64(dim)]--
+			} else {
+				v[sum%uint64(dim)]++
+			}
+		}
+		normalize(v)
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+// hashFeatures 切分出文本的特征：连续的字母数字组成一个词，
+// 汉字等没有空格分隔的文字取单字和相邻两字。
+func hashFeatures(text string) []string {
+	var features []string
+	var word []rune
+	var prevIdeograph rune
+	flushWord := func() {
+		if len(word) > 0 {
+			features = append(features, string(word))
+			word = word[:0]
+		}
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isIdeograph(r):
+			flushWord()
+			features = append(features, string(r))
+			if prevIdeograph != 0 {
+				features = append(features, string([]rune{prevIdeograph, r}))
+			}
+			prevIdeograph = r
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			flushWord()
+		}
+		prevIdeograph = 0
+	}
+	flushWord()
+	return features
+}
+
+func isIdeograph(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// normalize 把向量缩放为单位长度，使 L2 和余弦距离的排序一致
+func normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+}
@@ -0,0 +1,203 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/log"
+)
+
+// 检索策略，可在配置中设置默认值，也可以在每次查询时指定
+const (
+	// ModeVector 只做向量检索
+	ModeVector = "vector"
+	// ModeKeyword 只做 BM25 关键词检索
+	ModeKeyword = "keyword"
+	// ModeHybrid 同时做向量和关键词检索，用倒数排名融合（RRF）合并结果，是默认策略
+	ModeHybrid = "hybrid"
+)
+
+const (
+	// DefaultTopK 是关键词和混合检索未指定 top-k 时返回的结果数
+	DefaultTopK = 5
+	// rrfK 是倒数排名融合的平滑常数，取论文中的经验值
+	rrfK = 60
+	// candidateFactor 是每一路检索相对最终结果数多取的倍数，为融合和重排留出候选
+	candidateFactor = 4
+)
+
+// ParseMode 校验检索策略，空字符串表示默认的 hybrid
+func ParseMode(s string) (string, error) {
+	switch s {
+	case "":
+		return ModeHybrid, nil
+	case ModeVector, ModeKeyword, ModeHybrid:
+		return s, nil
+	}
+	return "", fmt.Errorf("不支持的检索策略: %s（可选 %s、%s、%s）", s, ModeVector, ModeKeyword, ModeHybrid)
+}
+
+// KeywordSearcher 由自带关键词索引的后端实现。
+// 未实现它的后端（例如 gRPC）在关键词和混合检索时，只在向量检索的候选结果中按关键词排序。
+type KeywordSearcher interface {
+	KeywordQuery(ctx context.Context, query string, opts SearchOptions) ([]Hit, error)
+}
+
+// Searcher 在 Backend 之上按检索策略组合向量检索、关键词检索和重排
+type Searcher struct {
+	backend  Backend
+	reranker Reranker
+	mode     string
+	rerank   bool
+}
+
+// NewSearcher 返回基于 b 检索的 Searcher，查询未指定策略时使用 cfg 中的 search_mode 和 rerank。
+// reranker 为 nil 时忽略重排。
+func NewSearcher(b Backend, cfg Config, reranker Reranker) (*Searcher, error) {
+	mode, err := ParseMode(cfg.SearchMode)
+	if err != nil {
+		return nil, err
+	}
+	return &Searcher{backend: b, reranker: reranker, mode: mode, rerank: cfg.Rerank}, nil
+}
+
+// Backend 返回底层的知识库后端
+func (s *Searcher) Backend() Backend {
+	return s.backend
+}
+
+// DefaultMode 返回查询未指定策略时使用的检索策略
+func (s *Searcher) DefaultMode() string {
+	return s.mode
+}
+
+// DefaultRerank 返回查询未指定时是否重排
+func (s *Searcher) DefaultRerank() bool {
+	return s.rerank
+}
+
+// Search 按 opts.Mode 检索，opts.Mode 为空时使用默认策略；opts.Rerank 为 true 时用 reranker 重排候选结果，
+// 重排失败时保留融合后的顺序
+func (s *Searcher) Search(ctx context.Context, query string, opts SearchOptions) ([]Hit, error) {
+	mode := opts.Mode
+	if mode == "" {
+		mode = s.mode
+	}
+	mode, err := ParseMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	rerank := opts.Rerank && s.reranker != nil
+
+	// 纯向量检索且不重排时直接交给后端，保持后端对 top-k 的默认处理
+	if mode == ModeVector && !rerank {
+		return s.backend.Query(ctx, query, opts)
+	}
+
+	topK := opts.TopK
+	if topK <= 0 {
+		topK = DefaultTopK
+	}
+	candidates := opts
+	candidates.TopK = topK * candidateFactor
+
+	var hits []Hit
+	switch mode {
+	case ModeVector:
+		hits, err = s.backend.Query(ctx, query, candidates)
+	case ModeKeyword:
+		hits, err = s.keywordQuery(ctx, query, candidates, nil)
+	case ModeHybrid:
+		var vectorHits, keywordHits []Hit
+		if vectorHits, err = s.backend.Query(ctx, query, candidates); err != nil {
+			return nil, err
+		}
+		if keywordHits, err = s.keywordQuery(ctx, query, candidates, vectorHits); err != nil {
+			return nil, err
+		}
+		hits = fuseRRF(vectorHits, keywordHits)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if rerank {
+		// 重排失败（例如小模型没有按要求返回分数）时保留融合后的顺序，不让整次检索失败
+		reranked, err := s.reranker.Rerank(ctx, query, hits)
+		switch {
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		case err != nil:
+			log.Warn("重排失败，使用原有顺序", "query", query, "error", err)
+		default:
+			hits = reranked
+		}
+	}
+	if len(hits) > topK {
+		hits = hits[:topK]
+	}
+	return hits, nil
+}
+
+// keywordQuery 用后端的关键词索引检索；后端不支持时，在向量检索的候选结果中按 BM25 排序。
+// vectorHits 是已经取到的向量候选结果，为 nil 时按需检索。
+func (s *Searcher) keywordQuery(ctx context.Context, query string, opts SearchOptions, vectorHits []Hit) ([]Hit, error) {
+	if ks, ok := s.backend.(KeywordSearcher); ok {
+		return ks.KeywordQuery(ctx, query, opts)
+	}
+	if vectorHits == nil {
+		var err error
+		if vectorHits, err = s.backend.Query(ctx, query, opts); err != nil {
+			return nil, err
+		}
+	}
+	idx := newBM25Index()
+	for _, h := range vectorHits {
+		idx.add(h)
+	}
+	return idx.search(query, opts.TopK, nil), nil
+}
+
+// fuseRRF 用倒数排名融合合并多路检索结果：每条结果的分数为它在各路结果中 1/(rrfK+排名) 之和。
+// 只依赖排名，不需要把向量距离和 BM25 分数换算到同一尺度。
+func fuseRRF(lists ...[]Hit) []Hit {
+	type fused struct {
+		hit   Hit
+		score float64
+	}
+	byKey := make(map[string]*fused)
+	var order []*fused
+	for _, list := range lists {
+		for rank, h := range list {
+			key := hitKey(h)
+			f, ok := byKey[key]
+			if !ok {
+				f = &fused{hit: h}
+				byKey[key] = f
+				order = append(order, f)
+			} else if f.hit.Distance < 0 && h.Distance >= 0 {
+				// 保留向量检索给出的距离，便于按距离阈值过滤
+				f.hit.Distance = h.Distance
+			}
+			f.score += 1 / float64(rrfK+rank+1)
+		}
+	}
+
+	// order 按首次出现排列，稳定排序使分数相同时先出现的在前
+	sort.SliceStable(order, func(i, j int) bool { return order[i].score > order[j].score })
+	hits := make([]Hit, 0, len(order))
+	for _, f := range order {
+		f.hit.Score = f.score
+		hits = append(hits, f.hit)
+	}
+	return hits
+}
+
+// hitKey 标识同一条内容：优先使用 ID，没有 ID 时使用来源和内容
+func hitKey(h Hit) string {
+	if h.ID != "" {
+		return h.ID
+	}
+	return h.Source + "\x00" + h.Content
+}
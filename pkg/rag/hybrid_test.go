@@ -0,0 +1,163 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestFuseRRF(t *testing.T) {
+	rrf := func(ranks ...int) float64 {
+		var score float64
+		for _, rank := range ranks {
+			score += 1 / float64(rrfK+rank)
+		}
+		return score
+	}
+
+	tests := []struct {
+		name       string
+		lists      [][]Hit
+		want       []string
+		wantScores []float64
+	}{
+		{
+			name:       "不相交的结果按排名交错",
+			lists:      [][]Hit{{{ID: "a"}, {ID: "b"}}, {{ID: "c"}, {ID: "d"}}},
+			want:       []string{"a", "c", "b", "d"},
+			wantScores: []float64{rrf(1), rrf(1), rrf(2), rrf(2)},
+		},
+		{
+			name:       "重叠的结果分数相加",
+			lists:      [][]Hit{{{ID: "a"}, {ID: "b"}, {ID: "c"}}, {{ID: "c"}, {ID: "a"}}},
+			want:       []string{"a", "c", "b"},
+			wantScores: []float64{rrf(1, 2), rrf(3, 1), rrf(2)},
+		},
+		{
+			name:       "两路都靠后的结果可以超过只在一路靠前的结果",
+			lists:      [][]Hit{{{ID: "a"}, {ID: "b"}, {ID: "c"}}, {{ID: "d"}, {ID: "e"}, {ID: "c"}}},
+			want:       []string{"c", "a", "d", "b", "e"},
+			wantScores: []float64{rrf(3, 3), rrf(1), rrf(1), rrf(2), rrf(2)},
+		},
+		{
+			name:       "没有 ID 时按来源和内容识别同一条结果",
+			lists:      [][]Hit{{{Source: "x.md", Content: "甲"}, {Source: "y.md", Content: "甲"}}, {{Source: "y.md", Content: "甲"}}},
+			want:       []string{"y.md", "x.md"},
+			wantScores: []float64{rrf(2, 1), rrf(1)},
+		},
+		{
+			name:  "空列表",
+			lists: [][]Hit{nil, {}},
+			want:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits := fuseRRF(tt.lists...)
+			got := make([]string, len(hits))
+			for i, h := range hits {
+				got[i] = h.ID
+				if h.ID == "" {
+					got[i] = h.Source
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("fuseRRF = %q，期望 %q", got, tt.want)
+			}
+			for i, want := range tt.wantScores {
+				if math.Abs(hits[i].Score-want) > 1e-12 {
+					t.Fatalf("%s 的分数 = %v，期望 %v", got[i], hits[i].Score, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFuseRRFKeepsVectorDistance(t *testing.T) {
+	keyword := []Hit{{ID: "a", Distance: -1, Score: 3.2}}
+	vector := []Hit{{ID: "b", Distance: 0.1}, {ID: "a", Distance: 0.4}}
+
+	hits := fuseRRF(keyword, vector)
+	if len(hits) != 2 || hits[0].ID != "a" {
+		t.Fatalf("fuseRRF = %+v", hits)
+	}
+	// 关键词检索没有距离，融合后保留向量检索给出的距离
+	if hits[0].Distance != 0.4 || hits[1].Distance != 0.1 {
+		t.Fatalf("距离 = %v、%v，期望 0.4、0.1", hits[0].Distance, hits[1].Distance)
+	}
+}
+
+// stubBackend 的向量检索总是按固定顺序返回 hits
+type stubBackend struct {
+	hits []Hit
+}
+
+func (b *stubBackend) Query(context.Context, string, SearchOptions) ([]Hit, error) {
+	return append([]Hit(nil), b.hits...), nil
+}
+
+func (b *stubBackend) Ingest(context.Context, string) (string, error) {
+	return "", errors.New("不支持写入")
+}
+
+func (b *stubBackend) ListCollections(context.Context) ([]Collection, error) {
+	return nil, nil
+}
+
+func (b *stubBackend) DeleteDocuments(context.Context, DeleteOptions) (int64, error) {
+	return 0, nil
+}
+
+func (b *stubBackend) Close() error {
+	return nil
+}
+
+func TestSearchRerankFallback(t *testing.T) {
+	backend := &stubBackend{hits: []Hit{{ID: "a", Content: "年假"}, {ID: "b", Content: "病假"}, {ID: "c", Content: "调休"}}}
+
+	tests := []struct {
+		name     string
+		provider *fakeProvider
+		want     []string
+	}{
+		{"重排成功", &fakeProvider{reply: "[1, 9, 5]"}, []string{"b", "c", "a"}},
+		// 小模型没有按格式返回分数或调用失败时保留原有顺序，检索本身不失败
+		{"回复无法解析", &fakeProvider{reply: "都很相关"}, []string{"a", "b", "c"}},
+		{"调用模型失败", &fakeProvider{err: errors.New("超时")}, []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSearcher(backend, Config{SearchMode: ModeVector}, &LLMReranker{Provider: tt.provider})
+			if err != nil {
+				t.Fatal(err)
+			}
+			hits, err := s.Search(context.Background(), "假期", SearchOptions{Rerank: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(hits))
+			for i, h := range hits {
+				got[i] = h.ID
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Search = %q，期望 %q", got, tt.want)
+			}
+			if tt.provider.calls != 1 {
+				t.Fatalf("调用模型 %d 次，期望 1 次", tt.provider.calls)
+			}
+		})
+	}
+
+	// 查询被取消时返回错误，而不是当作重排失败继续使用原有顺序
+	s, err := NewSearcher(backend, Config{SearchMode: ModeVector}, &LLMReranker{Provider: &fakeProvider{reply: "都很相关"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.Search(ctx, "假期", SearchOptions{Rerank: true}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("期望 context.Canceled，得到 %v", err)
+	}
+}
@@ -0,0 +1,422 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcphost/pkg/ingest"
+	"github.com/mark3labs/mcphost/pkg/vectorstore"
+)
+
+const (
+	// DefaultCollection 是 local 后端的默认集合，与 vdatabase 的 qa_collection 对应
+	DefaultCollection = "qa_collection"
+
+	collectionExt = ".jsonl"
+	// metaHash 是分块元数据中记录内容哈希的键
+	metaHash = "hash"
+	// embedBatchSize 是单次调用嵌入器的最大文本数
+	embedBatchSize = 64
+)
+
+// LocalBackend 是基于 pkg/vectorstore 的知识库，每个集合对应数据目录下的一个存储文件
+type LocalBackend struct {
+	dir        string
+	opts       vectorstore.Options
+	embedder   Embedder
+	collection string
+
+	mu      sync.Mutex
+	stores  map[string]*vectorstore.DiskStore
+	keyword map[string]*bm25Index // 各集合的关键词索引，首次关键词检索时建立，写入后失效
+}
+
+// OpenLocal 打开 cfg.Path 下的本地知识库，目录不存在时创建
+func OpenLocal(cfg Config, embedder Embedder) (*LocalBackend, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("local 后端需要指定数据目录（path）")
+	}
+	metric, err := vectorstore.ParseMetric(cfg.Metric)
+	if err != nil {
+		return nil, err
+	}
+	indexType, err := vectorstore.ParseIndexType(cfg.Index)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(cfg.Path, 0700); err != nil {
+		return nil, fmt.Errorf("创建知识库目录失败: %w", err)
+	}
+
+	collection := cfg.Collection
+	if collection == "" {
+		collection = DefaultCollection
+	}
+	return &LocalBackend{
+		dir:        cfg.Path,
+		opts:       vectorstore.Options{Metric: metric, Index: indexType},
+		embedder:   embedder,
+		collection: collection,
+		stores:     make(map[string]*vectorstore.DiskStore),
+		keyword:    make(map[string]*bm25Index),
+	}, nil
+}
+
+// store 返回集合对应的存储。create 为 false 且集合不存在时返回 nil。
+func (b *LocalBackend) store(name string, create bool) (*vectorstore.DiskStore, error) {
+	if name == "" {
+		name = b.collection
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("无效的集合名: %q", name)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if s, ok := b.stores[name]; ok {
+		return s, nil
+	}
+	path := filepath.Join(b.dir, name+collectionExt)
+	if !create {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+	s, err := vectorstore.Open(path, b.opts)
+	if err != nil {
+		return nil, err
+	}
+	b.stores[name] = s
+	return s, nil
+}
+
+// embed 分批调用嵌入器
+func (b *LocalBackend) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embedBatchSize {
+		end := min(start+embedBatchSize, len(texts))
+		batch, err := b.embedder.Embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("生成向量失败: %w", err)
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("生成向量失败: 期望 %d 个结果，实际 %d 个", end-start, len(batch))
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+func (b *LocalBackend) Query(ctx context.Context, query string, opts SearchOptions) ([]Hit, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("查询内容不能为空")
+	}
+	s, err := b.store(opts.Collection, false)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		if opts.Collection != "" && opts.Collection != b.collection {
+			return nil, fmt.Errorf("集合 %q 不存在", opts.Collection)
+		}
+		return nil, nil
+	}
+
+	vectors, err := b.embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.Search(ctx, vectors[0], vectorstore.SearchOptions{
+		TopK:        opts.TopK,
+		MaxDistance: opts.MaxDistance,
+		Filters:     opts.Filters,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("检索知识库失败: %w", err)
+	}
+
+	hits := make([]Hit, 0, len(results))
+	for _, r := range results {
+		hits = append(hits, Hit{
+			ID:       r.ID,
+			Distance: r.Distance,
+			Score:    r.Score,
+			Source:   r.Source,
+			Content:  r.Content,
+			Metadata: r.Metadata,
+		})
+	}
+	return hits, nil
+}
+
+// KeywordQuery 用 BM25 关键词索引检索，实现 KeywordSearcher
+func (b *LocalBackend) KeywordQuery(ctx context.Context, query string, opts SearchOptions) ([]Hit, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("查询内容不能为空")
+	}
+	name := opts.Collection
+	if name == "" {
+		name = b.collection
+	}
+	s, err := b.store(name, false)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		if name != b.collection {
+			return nil, fmt.Errorf("集合 %q 不存在", name)
+		}
+		return nil, nil
+	}
+
+	idx := b.keywordIndex(name, s)
+	topK := opts.TopK
+	if topK <= 0 {
+		topK = 1
+	}
+	var match func(Hit) bool
+	if len(opts.Filters) > 0 {
+		match = func(h Hit) bool {
+			return vectorstore.MatchFilters(&vectorstore.Document{Source: h.Source, Metadata: h.Metadata}, opts.Filters)
+		}
+	}
+	return idx.search(query, topK, match), nil
+}
+
+// keywordIndex 返回集合的关键词索引，不存在时从存储中的全部记录建立
+func (b *LocalBackend) keywordIndex(name string, s *vectorstore.DiskStore) *bm25Index {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if idx, ok := b.keyword[name]; ok {
+		return idx
+	}
+	idx := newBM25Index()
+	s.Each(func(doc vectorstore.Document) bool {
+		idx.add(Hit{ID: doc.ID, Source: doc.Source, Content: doc.Content, Metadata: doc.Metadata})
+		return true
+	})
+	b.keyword[name] = idx
+	return idx
+}
+
+// invalidateKeyword 在集合写入后丢弃它的关键词索引，下次关键词检索时重建
+func (b *LocalBackend) invalidateKeyword(name string) {
+	if name == "" {
+		name = b.collection
+	}
+	b.mu.Lock()
+	delete(b.keyword, name)
+	b.mu.Unlock()
+}
+
+// IngestStats 是一次入库的统计结果
+type IngestStats struct {
+	Added      int // 新写入的分块数
+	Unchanged  int // 内容未变、直接保留的分块数
+	Removed    int // 文件中已不存在而被删除的旧分块数
+	Duplicates int // 与其他文档内容完全相同而跳过的分块数
+}
+
+func (s IngestStats) String() string {
+	return fmt.Sprintf("新增 %d，未变 %d，删除 %d，重复跳过 %d", s.Added, s.Unchanged, s.Removed, s.Duplicates)
+}
+
+// Ingest 读取文件、切分后写入默认集合，实现 Backend
+func (b *LocalBackend) Ingest(ctx context.Context, path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", errors.New("文件路径不能为空")
+	}
+	stats, err := b.IngestFile(ctx, "", path, ingest.DefaultOptions())
+	if err != nil {
+		return "", err
+	}
+	return "存储成功：" + stats.String(), nil
+}
+
+// IngestFile 读取文件、按 opts 切分后写入集合，collection 为空时使用默认集合
+func (b *LocalBackend) IngestFile(ctx context.Context, collection, path string, opts ingest.Options) (IngestStats, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return IngestStats{}, fmt.Errorf("解析文件路径失败: %w", err)
+	}
+	chunks, err := ingest.LoadFile(absPath, opts)
+	if err != nil {
+		return IngestStats{}, err
+	}
+	return b.IngestChunks(ctx, collection, absPath, chunks)
+}
+
+// IngestChunks 用 chunks 替换来源为 source 的全部内容：
+// 内容未变的分块直接保留，不再重新生成向量；文件中已不存在的旧分块被删除；
+// 与集合中其他文档内容完全相同的分块跳过，避免重复。
+func (b *LocalBackend) IngestChunks(ctx context.Context, collection, source string, chunks []ingest.Chunk) (IngestStats, error) {
+	var stats IngestStats
+	s, err := b.store(collection, true)
+	if err != nil {
+		return stats, err
+	}
+
+	// 当前文档已有的分块，以及其他文档的内容哈希
+	existing := make(map[string]vectorstore.Document)
+	otherHashes := make(map[string]bool)
+	s.Each(func(doc vectorstore.Document) bool {
+		if doc.Source == source {
+			existing[doc.ID] = doc
+		} else if h := doc.Metadata[metaHash]; h != "" {
+			otherHashes[h] = true
+		}
+		return true
+	})
+
+	keep := make(map[string]bool)
+	var docs []vectorstore.Document // 需要写入的记录，其中新分块的向量稍后生成
+	var texts []string
+	for _, c := range chunks {
+		hash := c.Hash()
+		id := chunkID(source, hash)
+		if keep[id] {
+			continue
+		}
+		metadata := map[string]string{metaHash: hash}
+		for k, v := range c.Metadata {
+			metadata[k] = v
+		}
+		if old, ok := existing[id]; ok {
+			keep[id] = true
+			stats.Unchanged++
+			// 内容未变但位置（行号等）变化时，沿用原向量更新元数据
+			if !maps.Equal(old.Metadata, metadata) {
+				old.Metadata = metadata
+				docs = append(docs, old)
+			}
+			continue
+		}
+		if otherHashes[hash] {
+			stats.Duplicates++
+			continue
+		}
+		keep[id] = true
+
+		content := c.Content
+		if content == "" {
+			content = c.Text
+		}
+		docs = append(docs, vectorstore.Document{ID: id, Content: content, Source: source, Metadata: metadata})
+		texts = append(texts, c.Text)
+	}
+
+	// 先生成向量，失败时不改动已有内容
+	if len(texts) > 0 {
+		vectors, err := b.embed(ctx, texts)
+		if err != nil {
+			return stats, err
+		}
+		next := 0
+		for i := range docs {
+			if docs[i].Vector == nil {
+				docs[i].Vector = vectors[next]
+				next++
+			}
+		}
+	}
+
+	// 先写入新分块再删除不再出现的旧分块，写入失败时文档原有的内容仍在知识库中
+	defer b.invalidateKeyword(collection)
+	if err := s.Upsert(ctx, docs...); err != nil {
+		return stats, fmt.Errorf("写入知识库失败: %w", err)
+	}
+	stats.Added = len(texts)
+	var stale []string
+	for id := range existing {
+		if !keep[id] {
+			stale = append(stale, id)
+		}
+	}
+	if stats.Removed, err = s.Delete(ctx, stale...); err != nil {
+		return stats, fmt.Errorf("删除旧分块失败: %w", err)
+	}
+	return stats, nil
+}
+
+// chunkID 由来源和内容哈希生成分块 ID，同一文档中内容不变的分块 ID 也不变
+func chunkID(source, hash string) string {
+	sum := sha256.Sum256([]byte(source + "\x00" + hash))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (b *LocalBackend) ListCollections(ctx context.Context) ([]Collection, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取知识库目录失败: %w", err)
+	}
+
+	var collections []Collection
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), collectionExt) {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), collectionExt)
+		s, err := b.store(name, false)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			collections = append(collections, Collection{Name: name, NumEntities: int64(s.Len())})
+		}
+	}
+	sort.Slice(collections, func(i, j int) bool { return collections[i].Name < collections[j].Name })
+	return collections, nil
+}
+
+func (b *LocalBackend) DeleteDocuments(ctx context.Context, opts DeleteOptions) (int64, error) {
+	if len(opts.IDs) == 0 && opts.Source == "" {
+		return 0, errors.New("必须指定要删除的 ID 或来源文档")
+	}
+	s, err := b.store(opts.Collection, false)
+	if err != nil || s == nil {
+		return 0, err
+	}
+
+	defer b.invalidateKeyword(opts.Collection)
+	var deleted int
+	if len(opts.IDs) > 0 {
+		deleted, err = s.Delete(ctx, opts.IDs...)
+	} else {
+		source := opts.Source
+		if abs, absErr := filepath.Abs(source); absErr == nil {
+			source = abs
+		}
+		deleted, err = s.DeleteBySource(ctx, source)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("删除知识库内容失败: %w", err)
+	}
+	return int64(deleted), nil
+}
+
+// Close 关闭所有已打开的集合
+func (b *LocalBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var errs []error
+	for name, s := range b.stores {
+		if err := s.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(b.stores, name)
+		delete(b.keyword, name)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,96 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcphost/pkg/history"
+	"github.com/mark3labs/mcphost/pkg/llm"
+)
+
+// maxRerankPassageChars 是重排时每段内容最多发送给模型的字符数
+const maxRerankPassageChars = 1000
+
+// Reranker 对候选结果重新排序
+type Reranker interface {
+	// Rerank 返回按与查询的相关程度从高到低重新排列的结果
+	Rerank(ctx context.Context, query string, hits []Hit) ([]Hit, error)
+}
+
+// LLMReranker 让对话模型给每条候选结果打分并按分数重新排序，适用于所有 provider。
+// Provider 为 nil 时不重排，按原顺序返回。
+type LLMReranker struct {
+	Provider llm.Provider
+}
+
+const rerankInstruction = `你是检索结果的相关性评估器。请评估下面每段资料对回答查询的帮助程度，给出 0 到 10 的整数分：
+10 表示直接回答了查询，5 表示部分相关，0 表示无关。
+只输出一个 JSON 数组，按资料编号顺序给出分数，例如 [8, 0, 5]，不要输出其他内容。`
+
+func (r *LLMReranker) Rerank(ctx context.Context, query string, hits []Hit) ([]Hit, error) {
+	if r == nil || r.Provider == nil || len(hits) < 2 {
+		return hits, nil
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString(rerankInstruction)
+	prompt.WriteString("\n\n查询：")
+	prompt.WriteString(query)
+	prompt.WriteString("\n")
+	for i, h := range hits {
+		content := strings.TrimSpace(h.Content)
+		if runes := []rune(content); len(runes) > maxRerankPassageChars {
+			content = string(runes[:maxRerankPassageChars]) + "…"
+		}
+		fmt.Fprintf(&prompt, "\n[%d] 来源: %s\n%s\n", i+1, h.Source, content)
+	}
+
+	request := []llm.Message{&history.HistoryMessage{
+		Role:    "user",
+		Content: []history.ContentBlock{{Type: "text", Text: prompt.String()}},
+	}}
+	message, err := r.Provider.CreateMessage(ctx, "", request, nil)
+	if err != nil {
+		return nil, fmt.Errorf("重排检索结果失败: %w", err)
+	}
+	scores, err := parseRerankScores(message.GetContent())
+	if err != nil {
+		return nil, fmt.Errorf("重排检索结果失败: %w", err)
+	}
+
+	// 模型漏掉的结果不丢弃，排在所有已打分的结果之后
+	reranked := make([]Hit, len(hits))
+	copy(reranked, hits)
+	for i := range reranked {
+		if i < len(scores) {
+			reranked[i].Score = scores[i] / 10
+		} else {
+			reranked[i].Score = -1
+		}
+	}
+	sort.SliceStable(reranked, func(i, j int) bool { return reranked[i].Score > reranked[j].Score })
+	return reranked, nil
+}
+
+// parseRerankScores 从模型回复中取出分数数组，容忍数组前后的说明文字和代码块标记。
+// 超出 0 到 10 的分数按边界值处理，避免个别异常分数把结果挤到最前或最后。
+func parseRerankScores(reply string) ([]float64, error) {
+	start := strings.Index(reply, "[")
+	end := strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil, errors.New("模型没有返回分数数组")
+	}
+	var scores []float64
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &scores); err != nil {
+		return nil, fmt.Errorf("无法解析模型返回的分数: %w", err)
+	}
+	for i, score := range scores {
+		scores[i] = math.Min(10, math.Max(0, score))
+	}
+	return scores, nil
+}
@@ -0,0 +1,128 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcphost/pkg/history"
+	"github.com/mark3labs/mcphost/pkg/llm"
+)
+
+func TestParseRerankScores(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		want    []float64
+		wantErr bool
+	}{
+		{"纯数组", "[8, 0, 5]", []float64{8, 0, 5}, false},
+		{"代码块", "```json\n[3, 7]\n```", []float64{3, 7}, false},
+		{"前后有说明文字", "评分如下：[10, 2]。以上。", []float64{10, 2}, false},
+		{"小数分数", "[7.5, 2.5]", []float64{7.5, 2.5}, false},
+		{"超出范围的分数按边界处理", "[15, -3, 10, 0]", []float64{10, 0, 10, 0}, false},
+		{"空数组", "[]", []float64{}, false},
+		{"没有数组", "都很相关", nil, true},
+		{"数组没有结束", "[8, 0,", nil, true},
+		{"不是数字", `["高", "低"]`, nil, true},
+		{"数组之外还有方括号", "[1] 最相关，其次是 [2]", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRerankScores(tt.reply)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRerankScores(%q) 错误 = %v，期望出错 %v", tt.reply, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseRerankScores(%q) = %v，期望 %v", tt.reply, got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeProvider 对每次请求返回固定的回复
+type fakeProvider struct {
+	reply string
+	err   error
+	calls int
+}
+
+func (p *fakeProvider) CreateMessage(context.Context, string, []llm.Message, []llm.Tool) (llm.Message, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &history.HistoryMessage{
+		Role:    "assistant",
+		Content: []history.ContentBlock{{Type: "text", Text: p.reply}},
+	}, nil
+}
+
+func (p *fakeProvider) StreamMessage(context.Context, string, []llm.Message, []llm.Tool) (<-chan llm.StreamEvent, error) {
+	return nil, errors.New("不支持流式输出")
+}
+
+func (p *fakeProvider) CreateToolResponse(string, interface{}) (llm.Message, error) {
+	return nil, errors.New("不支持工具")
+}
+
+func (p *fakeProvider) SupportsTools() bool { return false }
+func (p *fakeProvider) Name() string        { return "fake" }
+
+func TestLLMRerank(t *testing.T) {
+	hits := []Hit{{ID: "a", Score: 0.9}, {ID: "b", Score: 0.8}, {ID: "c", Score: 0.7}}
+
+	tests := []struct {
+		name       string
+		reply      string
+		want       []string
+		wantScores []float64
+	}{
+		{"按分数重新排序", "[2, 9, 5]", []string{"b", "c", "a"}, []float64{0.9, 0.5, 0.2}},
+		{"分数相同时保持原顺序", "[5, 5, 5]", []string{"a", "b", "c"}, []float64{0.5, 0.5, 0.5}},
+		{"漏掉的结果排在最后", "[2, 9]", []string{"b", "a", "c"}, []float64{0.9, 0.2, -1}},
+		{"多余的分数被忽略", "[1, 2, 3, 10]", []string{"c", "b", "a"}, []float64{0.3, 0.2, 0.1}},
+		{"超出范围的分数按边界处理", "[99, 3, -5]", []string{"a", "b", "c"}, []float64{1, 0.3, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &LLMReranker{Provider: &fakeProvider{reply: tt.reply}}
+			got, err := r.Rerank(context.Background(), "查询", hits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]string, len(got))
+			scores := make([]float64, len(got))
+			for i, h := range got {
+				ids[i], scores[i] = h.ID, h.Score
+			}
+			if !reflect.DeepEqual(ids, tt.want) || !reflect.DeepEqual(scores, tt.wantScores) {
+				t.Fatalf("Rerank = %q %v，期望 %q %v", ids, scores, tt.want, tt.wantScores)
+			}
+		})
+	}
+	if hits[0].ID != "a" || hits[0].Score != 0.9 {
+		t.Fatalf("Rerank 不应修改传入的结果: %+v", hits)
+	}
+}
+
+func TestLLMRerankErrors(t *testing.T) {
+	hits := []Hit{{ID: "a"}, {ID: "b"}}
+	for _, p := range []*fakeProvider{{reply: "都很相关"}, {err: errors.New("超时")}} {
+		r := &LLMReranker{Provider: p}
+		if _, err := r.Rerank(context.Background(), "查询", hits); err == nil || !strings.Contains(err.Error(), "重排检索结果失败") {
+			t.Fatalf("期望重排失败，得到 %v", err)
+		}
+	}
+
+	// 不足两条结果或没有模型时不调用模型，按原顺序返回
+	p := &fakeProvider{reply: "[1]"}
+	if got, err := (&LLMReranker{Provider: p}).Rerank(context.Background(), "查询", hits[:1]); err != nil || len(got) != 1 || p.calls != 0 {
+		t.Fatalf("单条结果: %v, %v, 调用 %d 次", got, err, p.calls)
+	}
+	if got, err := (&LLMReranker{}).Rerank(context.Background(), "查询", hits); err != nil || !reflect.DeepEqual(got, hits) {
+		t.Fatalf("没有模型: %v, %v", got, err)
+	}
+}
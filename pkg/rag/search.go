@@ -0,0 +1,171 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcphost/pkg/rag/vertorv2pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SearchOptions 是 v2 检索接口的参数，零值表示使用服务端默认值
+type SearchOptions struct {
+	TopK        int               // 最多返回的结果数
+	MaxDistance float64           // L2 距离阈值，超过阈值的结果被丢弃；不大于 0 时不过滤
+	Collection  string            // 要检索的集合，为空时使用配置中的集合或服务端默认集合
+	Filters     map[string]string // 元数据过滤条件，所有条件都满足的结果才会返回
+	Mode        string            // 检索策略：vector、keyword 或 hybrid，为空时使用默认策略；只对 Searcher 生效
+	Rerank      bool              // 是否用模型重排候选结果；只对 Searcher 生效
+}
+
+// noMatchAnswer 是 vdatabase 服务在没有匹配结果时返回的固定文本
+const noMatchAnswer = "未找到匹配的结果"
+
+// Hit 是一条检索结果
+type Hit struct {
+	ID       string            `json:"id"`
+	Distance float64           `json:"distance"` // 与查询的距离，越小越相关；负数表示服务端未提供
+	Score    float64           `json:"score"`    // 归一化的相关性分数，越大越相关
+	Source   string            `json:"source"`   // 来源文档
+	Content  string            `json:"content"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Offset 返回内容在来源文档中的位置：有行号时为 "L<起>-<止>"，问答文件为记录序号，否则为所属章节标题
+func (h Hit) Offset() string {
+	switch {
+	case h.Metadata["lines"] != "":
+		lines := h.Metadata["lines"]
+		// 只有一行时写作 L12 而不是 L12-12
+		if start, end, ok := strings.Cut(lines, "-"); ok && start == end {
+			lines = start
+		}
+		return "L" + lines
+	case h.Metadata["record"] != "":
+		return "record-" + h.Metadata["record"]
+	default:
+		return h.Metadata["heading"]
+	}
+}
+
+// Collection 是知识库中的一个集合
+type Collection struct {
+	Name        string `json:"name"`
+	NumEntities int64  `json:"num_entities"`
+	Description string `json:"description,omitempty"`
+}
+
+// DeleteOptions 指定要删除的内容，IDs 和 Source 至少设置一个
+type DeleteOptions struct {
+	Collection string   // 为空时使用配置中的集合或服务端默认集合
+	IDs        []string // 按 ID 删除
+	Source     string   // 删除该来源文档的全部内容
+}
+
+// IsUnimplemented 判断错误是否由服务端不支持该接口引起，例如只部署了 v1 接口的旧服务
+func IsUnimplemented(err error) bool {
+	return status.Code(err) == codes.Unimplemented
+}
+
+// collectionOrDefault 返回指定的集合，为空时使用配置中的默认集合
+func (c *Client) collectionOrDefault(collection string) string {
+	if collection != "" {
+		return collection
+	}
+	return c.collection
+}
+
+// SearchHits 通过 v2 接口检索知识库，结果按相关性从高到低排列
+func (c *Client) SearchHits(ctx context.Context, query string, opts SearchOptions) ([]Hit, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("查询内容不能为空")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	resp, err := c.v2.Search(ctx, &vertorv2pb.SearchRequest{
+		Query:       query,
+		TopK:        int32(opts.TopK),
+		MaxDistance: float32(opts.MaxDistance),
+		Collection:  c.collectionOrDefault(opts.Collection),
+		Filters:     opts.Filters,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("检索知识库失败: %w", err)
+	}
+
+	hits := make([]Hit, 0, len(resp.GetHits()))
+	for _, h := range resp.GetHits() {
+		hits = append(hits, Hit{
+			ID:       h.GetId(),
+			Distance: float64(h.GetDistance()),
+			Score:    float64(h.GetScore()),
+			Source:   h.GetSource(),
+			Content:  h.GetContent(),
+			Metadata: h.GetMetadata(),
+		})
+	}
+	return hits, nil
+}
+
+// Query 实现 Backend。优先使用 v2 接口；服务端只提供 v1 接口时退回 v1，
+// v1 只返回最相近的一条结果并在服务端按固定阈值过滤，不提供距离，因此距离为 -1。
+func (c *Client) Query(ctx context.Context, query string, opts SearchOptions) ([]Hit, error) {
+	hits, err := c.SearchHits(ctx, query, opts)
+	if !IsUnimplemented(err) {
+		return hits, err
+	}
+
+	answer, err := c.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" || answer == noMatchAnswer {
+		return nil, nil
+	}
+	return []Hit{{Source: "vdatabase", Content: answer, Distance: -1}}, nil
+}
+
+// ListCollections 列出知识库中的集合
+func (c *Client) ListCollections(ctx context.Context) ([]Collection, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	resp, err := c.v2.ListCollections(ctx, &vertorv2pb.ListCollectionsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("获取集合列表失败: %w", err)
+	}
+
+	collections := make([]Collection, 0, len(resp.GetCollections()))
+	for _, col := range resp.GetCollections() {
+		collections = append(collections, Collection{
+			Name:        col.GetName(),
+			NumEntities: col.GetNumEntities(),
+			Description: col.GetDescription(),
+		})
+	}
+	return collections, nil
+}
+
+// DeleteDocuments 按 ID 或来源文档删除知识库中的内容，返回删除的条数
+func (c *Client) DeleteDocuments(ctx context.Context, opts DeleteOptions) (int64, error) {
+	if len(opts.IDs) == 0 && opts.Source == "" {
+		return 0, errors.New("必须指定要删除的 ID 或来源文档")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	resp, err := c.v2.DeleteDocuments(ctx, &vertorv2pb.DeleteDocumentsRequest{
+		Collection: c.collectionOrDefault(opts.Collection),
+		Ids:        opts.IDs,
+		Source:     opts.Source,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("删除知识库内容失败: %w", err)
+	}
+	return resp.GetDeleted(), nil
+}
@@ -0,0 +1,139 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mark3labs/mcphost/pkg/history"
+)
+
+// ServerName 是内置 RAG 工具在 mcpClients 中使用的服务器名，工具名因此为 rag__search 和 rag__ingest
+const ServerName = "rag"
+
+// NewServer 创建提供 search 和 ingest 工具的 MCP 服务器，search 按 searcher 的检索策略检索
+func NewServer(searcher *Searcher) *server.MCPServer {
+	backend := searcher.Backend()
+	s := server.NewMCPServer(ServerName, "0.1.0", server.WithToolCapabilities(false))
+
+	s.AddTool(mcp.NewTool("search",
+		mcp.WithDescription("在向量知识库中检索与查询语句最相关的内容。回答需要依据内部文档或知识库资料时使用。"),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("检索用的查询语句，使用完整的自然语言描述要查找的内容"),
+		),
+		mcp.WithNumber("top_k",
+			mcp.Description("最多返回的结果数，默认由服务端决定"),
+		),
+		mcp.WithString("collection",
+			mcp.Description("要检索的集合名，默认使用配置中的集合"),
+		),
+		mcp.WithString("mode",
+			mcp.Description(fmt.Sprintf("检索策略：vector 按语义检索，keyword 按关键词精确匹配（适合标识符、错误码、函数名），"+
+				"hybrid 合并两者的结果。默认 %s", searcher.DefaultMode())),
+			mcp.Enum(ModeHybrid, ModeVector, ModeKeyword),
+		),
+		mcp.WithBoolean("rerank",
+			mcp.Description("是否用模型对候选结果重新排序，更准确但更慢；没有可用模型时忽略"),
+			mcp.DefaultBool(searcher.DefaultRerank()),
+		),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		query, _ := request.Params.Arguments["query"].(string)
+		topK, _ := request.Params.Arguments["top_k"].(float64)
+		collection, _ := request.Params.Arguments["collection"].(string)
+		mode, _ := request.Params.Arguments["mode"].(string)
+		rerank, ok := request.Params.Arguments["rerank"].(bool)
+		if !ok {
+			rerank = searcher.DefaultRerank()
+		}
+
+		hits, err := searcher.Search(ctx, query, SearchOptions{
+			TopK:       int(topK),
+			Collection: collection,
+			Mode:       mode,
+			Rerank:     rerank,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		result := mcp.NewToolResultText(formatHits(hits))
+		if len(hits) > 0 {
+			result.Meta = map[string]interface{}{SourcesMetaKey: hitSources(hits)}
+		}
+		return result, nil
+	})
+
+	s.AddTool(mcp.NewTool("ingest",
+		mcp.WithDescription("将文件写入向量知识库。使用 gRPC 后端时，路径必须是 RAG 服务所在机器上可以访问的文件路径。"),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("要入库的文件路径"),
+		),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path, _ := request.Params.Arguments["path"].(string)
+		answer, err := backend.Ingest(ctx, path)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(answer), nil
+	})
+
+	return s
+}
+
+// SourcesMetaKey 是 search 工具结果 _meta 中的键，值为与结果编号 [n] 一一对应的来源列表，
+// 主机据此把回答中的引用关联到文档路径和位置
+const SourcesMetaKey = "mcphost/sources"
+
+// hitSources 返回与 formatHits 编号对应的来源列表
+func hitSources(hits []Hit) []history.Source {
+	sources := make([]history.Source, len(hits))
+	for i, h := range hits {
+		sources[i] = history.Source{Index: i + 1, ID: h.ID, Path: h.Source, Offset: h.Offset()}
+	}
+	return sources
+}
+
+// SourcesFromMeta 从工具结果的 _meta 中取出来源列表，没有或格式不对时返回 nil
+func SourcesFromMeta(meta map[string]interface{}) []history.Source {
+	value, ok := meta[SourcesMetaKey]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var sources []history.Source
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return nil
+	}
+	return sources
+}
+
+// formatHits 把检索结果格式化为带序号、来源和分数的文本
+func formatHits(hits []Hit) string {
+	if len(hits) == 0 {
+		return noMatchAnswer
+	}
+	var sb strings.Builder
+	for i, src := range hitSources(hits) {
+		h := hits[i]
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		if h.Distance >= 0 {
+			sb.WriteString(fmt.Sprintf("[%d] 来源: %s（分数 %.4f，距离 %.4f）\n", src.Index, src.Location(), h.Score, h.Distance))
+		} else if h.Score > 0 {
+			sb.WriteString(fmt.Sprintf("[%d] 来源: %s（分数 %.4f）\n", src.Index, src.Location(), h.Score))
+		} else {
+			sb.WriteString(fmt.Sprintf("[%d] 来源: %s\n", src.Index, src.Location()))
+		}
+		sb.WriteString(strings.TrimSpace(h.Content))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
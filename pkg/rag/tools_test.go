@@ -0,0 +1,260 @@
+package rag
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcphost/pkg/inprocess"
+	"github.com/mark3labs/mcphost/pkg/rag/vertorpb"
+	"github.com/mark3labs/mcphost/pkg/rag/vertorv2pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeDataManagement 是 v1 DataManagement 服务的假实现，记录收到的请求并返回预设的结果
+type fakeDataManagement struct {
+	vertorpb.UnimplementedDataManagementServer
+
+	mu      sync.Mutex
+	answer  string
+	err     error
+	prompts []string
+	paths   []string
+}
+
+func (f *fakeDataManagement) GetDatabyPrompt(_ context.Context, req *vertorpb.Request) (*vertorpb.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.prompts = append(f.prompts, req.GetPrompt())
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &vertorpb.Response{Answer: f.answer}, nil
+}
+
+func (f *fakeDataManagement) Updatabypath(_ context.Context, req *vertorpb.Request) (*vertorpb.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paths = append(f.paths, req.GetPrompt())
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &vertorpb.Response{Answer: f.answer}, nil
+}
+
+// fakeDataManagementV2 是 v2 DataManagement 服务的假实现，只实现 Search
+type fakeDataManagementV2 struct {
+	vertorv2pb.UnimplementedDataManagementServer
+
+	mu   sync.Mutex
+	hits []*vertorv2pb.Hit
+	last *vertorv2pb.SearchRequest
+}
+
+func (f *fakeDataManagementV2) Search(_ context.Context, req *vertorv2pb.SearchRequest) (*vertorv2pb.SearchResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.last = req
+	return &vertorv2pb.SearchResponse{Hits: f.hits}, nil
+}
+
+// newTestClient 在内存连接上启动假服务并返回连接到它的客户端，v2 为 nil 时只注册 v1 接口
+func newTestClient(t *testing.T, v1 *fakeDataManagement, v2 *fakeDataManagementV2) *Client {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	vertorpb.RegisterDataManagementServer(srv, v1)
+	if v2 != nil {
+		vertorv2pb.RegisterDataManagementServer(srv, v2)
+	}
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewClient(conn, 5*time.Second)
+}
+
+// callTool 通过进程内 MCP 客户端调用 RAG 服务器的工具
+func callTool(t *testing.T, client *Client, name string, args map[string]interface{}) *mcp.CallToolResult {
+	t.Helper()
+	searcher, err := NewSearcher(client, Config{SearchMode: ModeVector}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mcpClient, err := inprocess.NewClient(NewServer(searcher))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mcpClient.Close()
+
+	ctx := context.Background()
+	if _, err := mcpClient.Initialize(ctx, mcp.InitializeRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	req := mcp.CallToolRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	result, err := mcpClient.CallTool(ctx, req)
+	if err != nil {
+		t.Fatalf("调用 %s 失败: %v", name, err)
+	}
+	return result
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	var texts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func TestSearchToolFallsBackToV1(t *testing.T) {
+	v1 := &fakeDataManagement{answer: "  年假为 15 天  "}
+	client := newTestClient(t, v1, nil)
+
+	result := callTool(t, client, "search", map[string]interface{}{"query": " 年假有几天 "})
+	if result.IsError {
+		t.Fatalf("不应返回错误: %s", resultText(result))
+	}
+	text := resultText(result)
+	if !strings.Contains(text, "[1] 来源: vdatabase\n年假为 15 天") {
+		t.Fatalf("结果 = %q", text)
+	}
+	if len(v1.prompts) != 1 || v1.prompts[0] != "年假有几天" {
+		t.Fatalf("v1 收到的查询 = %q", v1.prompts)
+	}
+	sources := SourcesFromMeta(result.Meta)
+	if len(sources) != 1 || sources[0].Index != 1 || sources[0].Path != "vdatabase" {
+		t.Fatalf("来源 = %+v", sources)
+	}
+}
+
+func TestSearchToolNoMatch(t *testing.T) {
+	for _, answer := range []string{noMatchAnswer, "", "  "} {
+		client := newTestClient(t, &fakeDataManagement{answer: answer}, nil)
+		result := callTool(t, client, "search", map[string]interface{}{"query": "不存在的内容"})
+		if result.IsError {
+			t.Fatalf("answer=%q: 不应返回错误", answer)
+		}
+		if text := resultText(result); text != noMatchAnswer {
+			t.Fatalf("answer=%q: 结果 = %q，期望 %q", answer, text, noMatchAnswer)
+		}
+		if result.Meta != nil {
+			t.Fatalf("answer=%q: 没有结果时不应带来源，得到 %v", answer, result.Meta)
+		}
+	}
+}
+
+func TestSearchToolErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		query string
+		want  string
+	}{
+		{"服务端错误", status.Error(codes.Internal, "milvus 不可用"), "年假", "检索知识库失败"},
+		{"空查询", nil, "   ", "查询内容不能为空"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v1 := &fakeDataManagement{err: tt.err}
+			client := newTestClient(t, v1, nil)
+			result := callTool(t, client, "search", map[string]interface{}{"query": tt.query})
+			if !result.IsError {
+				t.Fatalf("期望返回错误，得到 %q", resultText(result))
+			}
+			if text := resultText(result); !strings.Contains(text, tt.want) {
+				t.Fatalf("错误 = %q，期望包含 %q", text, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchToolUsesV2(t *testing.T) {
+	v1 := &fakeDataManagement{answer: "不应使用 v1"}
+	v2 := &fakeDataManagementV2{hits: []*vertorv2pb.Hit{
+		{Id: "a", Distance: 0.25, Score: 0.8, Source: "docs/leave.md", Content: "年假为 15 天", Metadata: map[string]string{"lines": "3-5"}},
+		{Id: "b", Distance: 0.5, Score: 0.6, Source: "docs/faq.md", Content: "病假需要证明"},
+	}}
+	client := newTestClient(t, v1, v2)
+	client.collection = "handbook"
+
+	result := callTool(t, client, "search", map[string]interface{}{"query": "年假", "top_k": 2})
+	if result.IsError {
+		t.Fatalf("不应返回错误: %s", resultText(result))
+	}
+	if len(v1.prompts) != 0 {
+		t.Fatalf("v2 可用时不应调用 v1，得到 %q", v1.prompts)
+	}
+	if v2.last.GetTopK() != 2 || v2.last.GetCollection() != "handbook" || v2.last.GetQuery() != "年假" {
+		t.Fatalf("v2 请求 = %+v", v2.last)
+	}
+	text := resultText(result)
+	if !strings.Contains(text, "[1] 来源: docs/leave.md:L3-5") || !strings.Contains(text, "[2] 来源: docs/faq.md") {
+		t.Fatalf("结果 = %q", text)
+	}
+	sources := SourcesFromMeta(result.Meta)
+	if len(sources) != 2 || sources[0].ID != "a" || sources[1].Path != "docs/faq.md" {
+		t.Fatalf("来源 = %+v", sources)
+	}
+}
+
+func TestIngestTool(t *testing.T) {
+	v1 := &fakeDataManagement{answer: "已写入 3 条记录"}
+	client := newTestClient(t, v1, nil)
+
+	result := callTool(t, client, "ingest", map[string]interface{}{"path": " /data/handbook.md "})
+	if result.IsError {
+		t.Fatalf("不应返回错误: %s", resultText(result))
+	}
+	if text := resultText(result); text != "已写入 3 条记录" {
+		t.Fatalf("结果 = %q", text)
+	}
+	if len(v1.paths) != 1 || v1.paths[0] != "/data/handbook.md" {
+		t.Fatalf("v1 收到的路径 = %q", v1.paths)
+	}
+}
+
+func TestIngestToolErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		path string
+		want string
+	}{
+		{"服务端错误", status.Error(codes.NotFound, "文件不存在"), "/data/missing.md", "写入知识库失败"},
+		{"空路径", nil, "", "文件路径不能为空"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, &fakeDataManagement{err: tt.err}, nil)
+			result := callTool(t, client, "ingest", map[string]interface{}{"path": tt.path})
+			if !result.IsError {
+				t.Fatalf("期望返回错误，得到 %q", resultText(result))
+			}
+			if text := resultText(result); !strings.Contains(text, tt.want) {
+				t.Fatalf("错误 = %q，期望包含 %q", text, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+// Package vertorpb 是 vdatabase RAG 服务（vdatabase/allgrpc/allproto/protos.proto）的 Go gRPC 代码。
+package vertorpb
+
+//go:generate protoc --proto_path=../../../vdatabase/allgrpc/allproto --go_out=. --go_opt=paths=source_relative --go_opt=Mprotos.proto=github.com/mark3labs/mcphost/pkg/rag/vertorpb --go-grpc_out=. --go-grpc_opt=paths=source_relative --go-grpc_opt=Mprotos.proto=github.com/mark3labs/mcphost/pkg/rag/vertorpb protos.proto
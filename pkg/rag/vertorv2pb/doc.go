@@ -0,0 +1,4 @@
+// Package vertorv2pb 是 vdatabase RAG 服务 v2 接口（vdatabase/allgrpc/allproto/protos_v2.proto）的 Go gRPC 代码。
+package vertorv2pb
+
+//go:generate protoc --proto_path=../../../vdatabase/allgrpc/allproto --go_out=. --go_opt=paths=source_relative --go_opt=Mprotos_v2.proto=github.com/mark3labs/mcphost/pkg/rag/vertorv2pb --go-grpc_out=. --go-grpc_opt=paths=source_relative --go-grpc_opt=Mprotos_v2.proto=github.com/mark3labs/mcphost/pkg/rag/vertorv2pb protos_v2.proto
@@ -0,0 +1,195 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchYears 限制 Next 向后查找的范围，避免 2 月 30 日这类永远不会匹配的表达式陷入死循环
+const maxSearchYears = 5
+
+// Cron 是解析后的五段式 cron 表达式：分 时 日 月 周
+type Cron struct {
+	expr   string
+	minute uint64 // 0-59
+	hour   uint64 // 0-23
+	dom    uint64 // 1-31
+	month  uint64 // 1-12
+	dow    uint64 // 0-6，0 表示周日
+	anyDom bool   // 日字段以 * 开头（包括 */2 这类步长）
+	anyDow bool   // 周字段以 * 开头（包括 */2 这类步长）
+}
+
+// cronField 描述 cron 表达式中一个字段的取值范围和可用的名称
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = cronField{name: "分钟", min: 0, max: 59}
+	hourField   = cronField{name: "小时", min: 0, max: 23}
+	domField    = cronField{name: "日", min: 1, max: 31}
+	monthField  = cronField{name: "月", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 周字段允许用 7 表示周日，解析后统一为 0
+	dowField = cronField{name: "周", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// cronMacros 是常用的 cron 简写
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron 解析 cron 表达式。支持五段式的 "分 时 日 月 周"，
+// 每段可以是 *、数字、范围（1-5）、步长（*/15、0-30/10）和逗号分隔的列表，
+// 月和周可以使用英文缩写（jan、mon），也支持 @daily、@hourly 等简写。
+// 与标准 cron 相同，日和周都不以 * 开头时满足其一即可，否则两者都要满足。
+func ParseCron(expr string) (*Cron, error) {
+	spec := strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("无效的 cron 表达式 %q：需要 5 个字段（分 时 日 月 周）", expr)
+	}
+
+	c := &Cron{expr: expr, anyDom: strings.HasPrefix(fields[2], "*"), anyDow: strings.HasPrefix(fields[4], "*")}
+	var err error
+	for i, target := range []struct {
+		bits  *uint64
+		field cronField
+	}{
+		{&c.minute, minuteField},
+		{&c.hour, hourField},
+		{&c.dom, domField},
+		{&c.month, monthField},
+		{&c.dow, dowField},
+	} {
+		if *target.bits, err = parseCronField(fields[i], target.field); err != nil {
+			return nil, fmt.Errorf("无效的 cron 表达式 %q：%w", expr, err)
+		}
+	}
+	// 7 和 0 都表示周日
+	if c.dow&(1<<7) != 0 {
+		c.dow = c.dow&^(1<<7) | 1
+	}
+	return c, nil
+}
+
+// parseCronField 把一个字段解析为取值的位图
+func parseCronField(s string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s字段的步长无效: %q", f.name, part)
+			}
+			rangePart, step = part[:i], n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s字段的范围无效: %q", f.name, rangePart)
+			}
+		default:
+			v, err := f.value(rangePart)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// "5/10" 表示从 5 开始每隔 10 取一次
+			if step == 1 {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value 解析字段中的单个取值，可以是数字或名称
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s字段的取值无效: %q（范围 %d-%d）", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String 返回原始表达式
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// Next 返回 after 之后（不含）第一个匹配的时间，精确到分钟，使用 after 的时区。
+// 表达式永远不会匹配时（例如 2 月 30 日）返回零值。
+func (c *Cron) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches 判断日期是否满足日和周字段。与 Vixie cron 相同，两个字段都不以 * 开头时满足其一即可；
+// 只要有一个以 * 开头就要求两者都满足，因此 "*/10" 这类以 * 开头的步长仍然限制日期。
+func (c *Cron) dayMatches(t time.Time) bool {
+	domOK := c.dom&(1<<uint(t.Day())) != 0
+	dowOK := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.anyDom || c.anyDow {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
@@ -0,0 +1,167 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// bits 返回包含 values 的位图，用于对比解析结果
+func bits(values ...int) uint64 {
+	var b uint64
+	for _, v := range values {
+		b |= 1 << uint(v)
+	}
+	return b
+}
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  string
+		field cronField
+		want  uint64
+	}{
+		{"单个值", "5", minuteField, bits(5)},
+		{"范围", "1-4", hourField, bits(1, 2, 3, 4)},
+		{"全部", "*", monthField, bits(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12)},
+		{"全部加步长", "*/15", minuteField, bits(0, 15, 30, 45)},
+		{"范围加步长", "1-10/3", minuteField, bits(1, 4, 7, 10)},
+		{"起点加步长", "5/20", minuteField, bits(5, 25, 45)},
+		{"列表", "1,15,31", domField, bits(1, 15, 31)},
+		{"列表中混合范围和步长", "0,10-12,*/20", minuteField, bits(0, 10, 11, 12, 20, 40)},
+		{"月份名称", "jan-mar,DEC", monthField, bits(1, 2, 3, 12)},
+		{"星期名称", "mon-fri", dowField, bits(1, 2, 3, 4, 5)},
+		{"步长大于范围", "3-5/10", hourField, bits(3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCronField(tt.spec, tt.field)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("parseCronField(%q) = %b，期望 %b", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronSunday(t *testing.T) {
+	tests := []struct {
+		expr string
+		want uint64
+	}{
+		{"0 0 * * 7", bits(0)},
+		{"0 0 * * 0,7", bits(0)},
+		{"0 0 * * 5-7", bits(0, 5, 6)},
+		{"0 0 * * */2", bits(0, 2, 4, 6)},
+		{"0 0 * * sun", bits(0)},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.dow != tt.want {
+			t.Errorf("ParseCron(%q) 的周字段 = %b，期望 %b", tt.expr, c.dow, tt.want)
+		}
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@every 5m",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"1- * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"* * * * mon-",
+		"1,,2 * * * *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) 应该失败", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 2024-01-01 是周一
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"每分钟", "* * * * *", at(1, 1, 10, 7), at(1, 1, 10, 8)},
+		{"不包含 after 本身", "7 10 * * *", at(1, 1, 10, 7), at(1, 2, 10, 7)},
+		{"秒数被舍去", "5,10,55 * * * *", at(1, 1, 10, 10).Add(30 * time.Second), at(1, 1, 10, 55)},
+		{"步长", "*/15 * * * *", at(1, 1, 10, 7), at(1, 1, 10, 15)},
+		{"范围加步长", "0-30/10 9 * * *", at(1, 1, 9, 25), at(1, 1, 9, 30)},
+		{"范围加步长跨天", "0-30/10 9 * * *", at(1, 1, 9, 30), at(1, 2, 9, 0)},
+		{"小时列表", "0 8,12,18 * * *", at(1, 1, 12, 0), at(1, 1, 18, 0)},
+		{"工作日跳过周末", "0 9 * * 1-5", at(1, 5, 10, 0), at(1, 8, 9, 0)},
+		{"周末", "0 9 * * sat,sun", at(1, 1, 0, 0), at(1, 6, 9, 0)},
+		{"7 表示周日", "0 0 * * 7", at(1, 1, 0, 0), at(1, 7, 0, 0)},
+		{"指定日期", "0 0 15 * *", at(1, 20, 0, 0), at(2, 15, 0, 0)},
+		{"跳过没有 31 日的月份", "0 0 31 * *", at(4, 1, 0, 0), at(5, 31, 0, 0)},
+		{"指定月份跨年", "0 0 1 feb *", at(3, 5, 0, 0), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"闰日", "0 0 29 2 *", at(3, 1, 0, 0), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// 日和周都有限制时满足其一即可：13 日或周五
+		{"日或周：先到周五", "0 0 13 * 5", at(1, 1, 0, 0), at(1, 5, 0, 0)},
+		{"日或周：周五之后是 13 日", "0 0 13 * 5", at(1, 12, 0, 0), at(1, 13, 0, 0)},
+		// 日字段以 * 开头时两者都要满足，*/10 仍然限制日期
+		{"日步长", "0 0 */10 * *", at(1, 1, 0, 0), at(1, 11, 0, 0)},
+		{"日步长且是周日", "0 0 */10 * 0", at(1, 1, 0, 0), at(1, 21, 0, 0)},
+		// */3 是周日、周三和周六，2024 年第一个落在这几天的 15 日是 5 月 15 日（周三）
+		{"周步长且是 15 日", "0 0 15 * */3", at(1, 1, 0, 0), at(5, 15, 0, 0)},
+		{"@hourly", "@hourly", at(1, 1, 10, 0), at(1, 1, 11, 0)},
+		{"@weekly", "@weekly", at(1, 1, 0, 0), at(1, 7, 0, 0)},
+		{"简写不区分大小写", "@DAILY", at(1, 1, 0, 0), at(1, 2, 0, 0)},
+		{"@yearly", "@yearly", at(1, 1, 0, 0), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"永远不会匹配", "0 0 30 2 *", at(1, 1, 0, 0), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.Next(tt.after); !got.Equal(tt.want) {
+				t.Fatalf("Next(%s) = %s，期望 %s", tt.after.Format(time.RFC3339), got.Format(time.RFC3339), tt.want.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestCronNextUsesLocation(t *testing.T) {
+	cst := time.FixedZone("CST", 8*3600)
+	c, err := ParseCron("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := time.Date(2024, 1, 1, 8, 59, 0, 0, cst)
+	want := time.Date(2024, 1, 1, 9, 0, 0, 0, cst)
+	if got := c.Next(after); !got.Equal(want) || got.Location() != cst {
+		t.Fatalf("Next = %s，期望 %s", got, want)
+	}
+	// 同一时刻在 UTC 下是 00:59，下一次运行是 UTC 的 09:00
+	if got := c.Next(after.UTC()); !got.Equal(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Next(UTC) = %s", got)
+	}
+}
@@ -0,0 +1,202 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// 错过运行时间后的处理方式
+const (
+	MissedOnce = "once" // 启动后立即补执行一次（默认）
+	MissedSkip = "skip" // 不补执行，等待下一次运行时间
+)
+
+// Config 是配置文件 schedules 中的一个定时计划
+type Config struct {
+	Cron     string     `json:"cron"`               // cron 表达式，例如 "0 9 * * 1-5" 或 "@daily"
+	Prompt   string     `json:"prompt"`             // 每次运行提交的问题
+	Model    string     `json:"model,omitempty"`    // 使用的模型，为空时使用执行进程的 --model
+	Sink     SinkConfig `json:"sink,omitempty"`     // 运行结果的输出位置，默认 stdout
+	Missed   string     `json:"missed,omitempty"`   // 执行进程没有运行而错过运行时间时的处理方式：once（默认）或 skip
+	Disabled bool       `json:"disabled,omitempty"` // 暂停该计划，run-now 仍可手动运行
+}
+
+// Validate 检查配置是否有效，返回解析后的 cron 表达式
+func (c Config) Validate() (*Cron, error) {
+	if strings.TrimSpace(c.Prompt) == "" {
+		return nil, errors.New("prompt 不能为空")
+	}
+	switch c.Missed {
+	case "", MissedOnce, MissedSkip:
+	default:
+		return nil, fmt.Errorf("不支持的 missed 取值: %q（可选 once、skip）", c.Missed)
+	}
+	if err := c.Sink.Validate(); err != nil {
+		return nil, err
+	}
+	return ParseCron(c.Cron)
+}
+
+// Entry 记录一个计划的运行情况，保存在状态文件中
+type Entry struct {
+	LastRun  time.Time `json:"last_run"`            // 最近一次运行或跳过错过的运行的时间；首次加载计划时为加载时间
+	LastTask string    `json:"last_task,omitempty"` // 最近一次运行提交的任务 ID
+}
+
+// State 是所有计划的运行情况，按计划名称索引
+type State map[string]Entry
+
+// LoadState 读取 path 处的状态文件，文件不存在时返回空状态
+func LoadState(path string) (State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return State{}, nil
+		}
+		return nil, fmt.Errorf("读取定时计划状态失败: %w", err)
+	}
+	state := State{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析定时计划状态失败: %w", err)
+	}
+	return state, nil
+}
+
+// Save 先写临时文件再替换，崩溃时不会留下写了一半的状态文件
+func (s State) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("创建定时计划目录失败: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化定时计划状态失败: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("写入定时计划状态失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("写入定时计划状态失败: %w", err)
+	}
+	return nil
+}
+
+// SubmitFunc 为计划提交一次运行，返回提交的任务 ID
+type SubmitFunc func(name string, cfg Config) (string, error)
+
+// plan 是调度器中的一个计划
+type plan struct {
+	name string
+	cfg  Config
+	cron *Cron
+	next time.Time
+}
+
+// Scheduler 在计划的运行时间到达时调用 SubmitFunc，并把运行时间记录到状态文件，
+// 进程重启后据此判断是否错过了运行时间
+type Scheduler struct {
+	statePath string
+	submit    SubmitFunc
+
+	mu    sync.Mutex
+	plans []*plan
+	state State
+}
+
+// NewScheduler 校验所有计划并加载状态文件，已暂停的计划被忽略
+func NewScheduler(configs map[string]Config, statePath string, submit SubmitFunc) (*Scheduler, error) {
+	state, err := LoadState(statePath)
+	if err != nil {
+		return nil, err
+	}
+	s := &Scheduler{statePath: statePath, submit: submit, state: state}
+	for name, cfg := range configs {
+		cron, err := cfg.Validate()
+		if err != nil {
+			return nil, fmt.Errorf("定时计划 %s: %w", name, err)
+		}
+		if !cfg.Disabled {
+			s.plans = append(s.plans, &plan{name: name, cfg: cfg, cron: cron})
+		}
+	}
+	sort.Slice(s.plans, func(i, j int) bool { return s.plans[i].name < s.plans[j].name })
+	return s, nil
+}
+
+// Len 返回启用的计划数
+func (s *Scheduler) Len() int {
+	return len(s.plans)
+}
+
+// Start 计算每个计划的下一次运行时间。上次运行之后已经错过了运行时间的计划，
+// missed 为 once 时立即补执行一次（多次错过也只补一次），为 skip 时只记录日志。
+// 首次出现的计划从现在开始计时。
+func (s *Scheduler) Start(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.plans {
+		entry, ok := s.state[p.name]
+		if !ok {
+			s.state[p.name] = Entry{LastRun: now}
+		} else if missed := p.cron.Next(entry.LastRun); !missed.IsZero() && !missed.After(now) {
+			if p.cfg.Missed == MissedSkip {
+				log.Info("跳过错过的定时运行", "schedule", p.name, "missed", missed.Format("2006-01-02 15:04"))
+				entry.LastRun = now
+				s.state[p.name] = entry
+			} else {
+				log.Info("补执行错过的定时运行", "schedule", p.name, "missed", missed.Format("2006-01-02 15:04"))
+				s.run(p, now)
+			}
+		}
+		p.next = p.cron.Next(now)
+		log.Info("定时计划已启用", "schedule", p.name, "cron", p.cron, "next", p.next.Format("2006-01-02 15:04"))
+	}
+	return s.state.Save(s.statePath)
+}
+
+// Tick 运行所有已到运行时间的计划，并计算它们的下一次运行时间。
+// 进程挂起等原因导致一次跳过多个运行时间时，只运行一次。
+func (s *Scheduler) Tick(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ran := false
+	for _, p := range s.plans {
+		if p.next.IsZero() || p.next.After(now) {
+			continue
+		}
+		s.run(p, p.next)
+		ran = true
+		p.next = p.cron.Next(now)
+	}
+	if !ran {
+		return nil
+	}
+	return s.state.Save(s.statePath)
+}
+
+// run 提交一次运行并记录运行时间，调用方需持有 s.mu。
+// 提交失败只记录日志，不重试，下一次运行时间照常计算。
+func (s *Scheduler) run(p *plan, at time.Time) {
+	entry := s.state[p.name]
+	entry.LastRun = at
+	id, err := s.submit(p.name, p.cfg)
+	if err != nil {
+		log.Error("提交定时运行失败", "schedule", p.name, "error", err)
+	} else {
+		entry.LastTask = id
+		log.Info("已提交定时运行", "schedule", p.name, "task", id)
+	}
+	s.state[p.name] = entry
+}
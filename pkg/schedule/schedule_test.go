@@ -0,0 +1,215 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// submitRecorder 记录提交的运行，err 不为 nil 时提交失败
+type submitRecorder struct {
+	names []string
+	err   error
+}
+
+func (r *submitRecorder) submit(name string, _ Config) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+	r.names = append(r.names, name)
+	return fmt.Sprintf("task-%d", len(r.names)), nil
+}
+
+func newTestScheduler(t *testing.T, configs map[string]Config, state State) (*Scheduler, *submitRecorder, string) {
+	t.Helper()
+	statePath := filepath.Join(t.TempDir(), "schedules.json")
+	if state != nil {
+		if err := state.Save(statePath); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recorder := &submitRecorder{}
+	s, err := NewScheduler(configs, statePath, recorder.submit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, recorder, statePath
+}
+
+func loadTestState(t *testing.T, path string) State {
+	t.Helper()
+	state, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func TestStartMissedRuns(t *testing.T) {
+	// 每天 09:00 运行，进程在 2024-01-03 12:00 启动
+	now := time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC)
+	daily := time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		missed      string
+		lastRun     time.Time // 零值表示状态文件中没有该计划
+		wantSubmits int
+	}{
+		{"首次出现的计划从现在开始计时", "", time.Time{}, 0},
+		{"没有错过", "", daily, 0},
+		{"错过一次补执行", "", daily.Add(-time.Hour), 1},
+		{"错过多次只补一次", MissedOnce, daily.AddDate(0, 0, -5), 1},
+		{"skip 不补执行", MissedSkip, daily.AddDate(0, 0, -5), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var state State
+			if !tt.lastRun.IsZero() {
+				state = State{"report": {LastRun: tt.lastRun, LastTask: "old"}}
+			}
+			configs := map[string]Config{"report": {Cron: "0 9 * * *", Prompt: "生成日报", Missed: tt.missed}}
+			s, recorder, statePath := newTestScheduler(t, configs, state)
+			if err := s.Start(now); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(recorder.names) != tt.wantSubmits {
+				t.Fatalf("提交了 %d 次，期望 %d 次", len(recorder.names), tt.wantSubmits)
+			}
+			entry := loadTestState(t, statePath)["report"]
+			wantLastRun := tt.lastRun
+			if tt.lastRun.IsZero() || tt.wantSubmits > 0 || tt.missed == MissedSkip {
+				wantLastRun = now
+			}
+			if !entry.LastRun.Equal(wantLastRun) {
+				t.Fatalf("LastRun = %s，期望 %s", entry.LastRun, wantLastRun)
+			}
+			if tt.wantSubmits > 0 && entry.LastTask != "task-1" {
+				t.Fatalf("LastTask = %q，期望 task-1", entry.LastTask)
+			}
+			// 补执行和跳过都不影响下一次运行时间
+			if want := daily.AddDate(0, 0, 1); !s.plans[0].next.Equal(want) {
+				t.Fatalf("下一次运行时间 = %s，期望 %s", s.plans[0].next, want)
+			}
+
+			// 重新启动时不会重复补执行
+			s, recorder, _ = newTestScheduler(t, configs, loadTestState(t, statePath))
+			if err := s.Start(now.Add(time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+			if len(recorder.names) != 0 {
+				t.Fatalf("重新启动后又提交了 %d 次", len(recorder.names))
+			}
+		})
+	}
+}
+
+func TestTick(t *testing.T) {
+	start := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	configs := map[string]Config{
+		"hourly": {Cron: "0 * * * *", Prompt: "每小时"},
+		"daily":  {Cron: "0 9 * * *", Prompt: "每天"},
+		"paused": {Cron: "* * * * *", Prompt: "暂停", Disabled: true},
+	}
+	s, recorder, statePath := newTestScheduler(t, configs, nil)
+	if s.Len() != 2 {
+		t.Fatalf("Len = %d，已暂停的计划不应启用", s.Len())
+	}
+	if err := s.Start(start); err != nil {
+		t.Fatal(err)
+	}
+
+	// 没有到运行时间
+	if err := s.Tick(start.Add(30 * time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.names) != 0 {
+		t.Fatalf("未到运行时间就提交了 %v", recorder.names)
+	}
+
+	// 09:00 两个计划都到了运行时间，按名称顺序提交
+	nine := time.Date(2024, 1, 1, 9, 0, 5, 0, time.UTC)
+	if err := s.Tick(nine); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(recorder.names) != "[daily hourly]" {
+		t.Fatalf("提交 = %v", recorder.names)
+	}
+	state := loadTestState(t, statePath)
+	if want := nine.Truncate(time.Minute); !state["hourly"].LastRun.Equal(want) || state["hourly"].LastTask != "task-2" {
+		t.Fatalf("hourly 的状态 = %+v", state["hourly"])
+	}
+
+	// 进程挂起了三个多小时，错过的多次运行只补一次，之后按当前时间计算下一次
+	recorder.names = nil
+	late := time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC)
+	if err := s.Tick(late); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(recorder.names) != "[hourly]" {
+		t.Fatalf("提交 = %v", recorder.names)
+	}
+	for _, p := range s.plans {
+		if p.name == "hourly" && !p.next.Equal(time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC)) {
+			t.Fatalf("hourly 的下一次运行时间 = %s", p.next)
+		}
+	}
+}
+
+func TestTickSubmitError(t *testing.T) {
+	start := time.Date(2024, 1, 1, 8, 30, 0, 0, time.UTC)
+	configs := map[string]Config{"hourly": {Cron: "0 * * * *", Prompt: "每小时"}}
+	s, recorder, statePath := newTestScheduler(t, configs, State{"hourly": {LastRun: start, LastTask: "old"}})
+	if err := s.Start(start); err != nil {
+		t.Fatal(err)
+	}
+
+	// 提交失败不重试，运行时间照常记录，下一次运行时间照常计算
+	recorder.err = errors.New("队列已关闭")
+	nine := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	if err := s.Tick(nine); err != nil {
+		t.Fatal(err)
+	}
+	entry := loadTestState(t, statePath)["hourly"]
+	if !entry.LastRun.Equal(nine) || entry.LastTask != "old" {
+		t.Fatalf("状态 = %+v", entry)
+	}
+	recorder.err = nil
+	if err := s.Tick(nine.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.names) != 0 {
+		t.Fatalf("提交失败后不应重试，得到 %v", recorder.names)
+	}
+	if !s.plans[0].next.Equal(nine.Add(time.Hour)) {
+		t.Fatalf("下一次运行时间 = %s", s.plans[0].next)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		ok   bool
+	}{
+		{"有效", Config{Cron: "@daily", Prompt: "日报"}, true},
+		{"skip", Config{Cron: "@daily", Prompt: "日报", Missed: MissedSkip}, true},
+		{"空 prompt", Config{Cron: "@daily", Prompt: "  "}, false},
+		{"无效的 missed", Config{Cron: "@daily", Prompt: "日报", Missed: "always"}, false},
+		{"无效的 cron", Config{Cron: "0 25 * * *", Prompt: "日报"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.cfg.Validate(); (err == nil) != tt.ok {
+				t.Fatalf("Validate() 错误 = %v，期望有效 %v", err, tt.ok)
+			}
+		})
+	}
+
+	if _, err := NewScheduler(map[string]Config{"bad": {Cron: "x", Prompt: "p"}}, filepath.Join(t.TempDir(), "s.json"), nil); err == nil {
+		t.Fatal("包含无效计划时 NewScheduler 应该失败")
+	}
+}
@@ -0,0 +1,159 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 运行结果的输出位置
+const (
+	SinkStdout  = "stdout"  // 输出到执行进程的标准输出（默认）
+	SinkFile    = "file"    // 追加到文件
+	SinkWebhook = "webhook" // 以 JSON POST 到指定地址
+)
+
+// webhookTimeout 是投递 webhook 的超时时间
+const webhookTimeout = 30 * time.Second
+
+// SinkConfig 是运行结果的输出位置
+type SinkConfig struct {
+	Type    string            `json:"type,omitempty"`    // stdout（默认）、file 或 webhook
+	Path    string            `json:"path,omitempty"`    // file：文件路径，可使用 {name} 和 {date} 占位符，例如 "digests/{name}-{date}.md"
+	URL     string            `json:"url,omitempty"`     // webhook：接收结果的地址
+	Headers map[string]string `json:"headers,omitempty"` // webhook：额外的请求头，例如 Authorization
+}
+
+// Validate 检查输出位置的配置是否完整
+func (c SinkConfig) Validate() error {
+	switch c.Type {
+	case "", SinkStdout:
+	case SinkFile:
+		if c.Path == "" {
+			return fmt.Errorf("file 类型的 sink 需要 path")
+		}
+	case SinkWebhook:
+		if c.URL == "" {
+			return fmt.Errorf("webhook 类型的 sink 需要 url")
+		}
+	default:
+		return fmt.Errorf("不支持的 sink 类型: %q（可选 stdout、file、webhook）", c.Type)
+	}
+	return nil
+}
+
+// Describe 返回输出位置的简短描述，用于列表展示
+func (c SinkConfig) Describe() string {
+	switch c.Type {
+	case SinkFile:
+		return "file " + c.Path
+	case SinkWebhook:
+		return "webhook " + c.URL
+	default:
+		return SinkStdout
+	}
+}
+
+// Run 是一次运行的结果，webhook 收到的请求体即为它的 JSON
+type Run struct {
+	Schedule   string    `json:"schedule"`
+	Task       string    `json:"task"`
+	State      string    `json:"state"`
+	Result     string    `json:"result,omitempty"`
+	Sources    []string  `json:"sources,omitempty"` // 回答引用的来源，形如 "[1] docs/a.md:L3"
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// text 把运行结果格式化为 Markdown 片段
+func (r Run) text() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## %s · %s\n\n", r.Schedule, r.FinishedAt.Format("2006-01-02 15:04")))
+	if r.Error != "" {
+		sb.WriteString(fmt.Sprintf("运行失败（%s）：%s\n\n", r.State, r.Error))
+	}
+	if r.Result != "" {
+		sb.WriteString(strings.TrimSpace(r.Result) + "\n\n")
+	}
+	if len(r.Sources) > 0 {
+		sb.WriteString("Sources:\n")
+		for _, s := range r.Sources {
+			sb.WriteString("  " + s + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// Deliver 把运行结果写到配置的输出位置。stdout 类型写入 stdout，
+// file 类型的相对路径相对于 baseDir。
+func Deliver(ctx context.Context, c SinkConfig, run Run, stdout io.Writer, baseDir string) error {
+	switch c.Type {
+	case "", SinkStdout:
+		_, err := io.WriteString(stdout, run.text())
+		return err
+	case SinkFile:
+		return deliverFile(c, run, baseDir)
+	case SinkWebhook:
+		return deliverWebhook(ctx, c, run)
+	default:
+		return fmt.Errorf("不支持的 sink 类型: %q", c.Type)
+	}
+}
+
+func deliverFile(c SinkConfig, run Run, baseDir string) error {
+	path := strings.NewReplacer(
+		"{name}", run.Schedule,
+		"{date}", run.FinishedAt.Format("2006-01-02"),
+	).Replace(c.Path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("创建输出目录失败: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("打开输出文件失败: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(run.text()); err != nil {
+		return fmt.Errorf("写入输出文件失败: %w", err)
+	}
+	return nil
+}
+
+func deliverWebhook(ctx context.Context, c SinkConfig, run Run) error {
+	body, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("序列化运行结果失败: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建 webhook 请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("投递 webhook 失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook 返回 %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
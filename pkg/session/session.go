@@ -0,0 +1,330 @@
+package session
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcphost/pkg/history"
+	"github.com/mark3labs/mcphost/pkg/jsonl"
+)
+
+// ErrNotFound 表示指定的会话不存在
+var ErrNotFound = errors.New("会话不存在")
+
+const fileExt = ".jsonl"
+
+// 会话文件中每一行记录的类型
+const (
+	recordMeta    = "meta"
+	recordMessage = "message"
+	recordCompact = "compact" // 压缩后的完整消息列表，加载时替换之前的所有消息
+)
+
+// Meta 记录会话创建时的上下文信息，用于恢复会话时还原模型和 MCP 服务器
+type Meta struct {
+	ID        string    `json:"id"`
+	Model     string    `json:"model"`
+	Servers   []string  `json:"servers"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// record 是会话文件中的一行，文件采用只追加的 JSONL 格式，崩溃时最多丢失最后一行
+type record struct {
+	Type     string                   `json:"type"`
+	Time     time.Time                `json:"time"`
+	Meta     *Meta                    `json:"meta,omitempty"`
+	Message  *history.HistoryMessage  `json:"message,omitempty"`
+	Messages []history.HistoryMessage `json:"messages,omitempty"`
+}
+
+// Session 表示一个已加载到内存中的会话
+type Session struct {
+	Meta
+	UpdatedAt time.Time
+	Messages  []history.HistoryMessage
+
+	saved bool // 元数据是否已写入文件
+}
+
+// Summary 是会话列表中展示的摘要信息
+type Summary struct {
+	Meta
+	UpdatedAt    time.Time
+	MessageCount int
+	Title        string // 第一条用户消息的开头部分
+}
+
+// Store 管理数据目录下的会话文件，每个会话对应一个文件
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewStore 创建会话存储，目录不存在时自动创建
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建会话目录失败: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// NewID 生成形如 20060102-150405-a1b2c3 的会话 ID，按字典序即按时间排序
+func NewID() string {
+	buf := make([]byte, 3)
+	_, _ = rand.Read(buf)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(buf)
+}
+
+// Create 创建一个新会话，id 为空时自动生成。
+// 会话文件在第一次追加消息时才写入，避免留下空会话。
+func (s *Store) Create(id, model string, servers []string) (*Session, error) {
+	if id == "" {
+		id = NewID()
+	}
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(s.path(id)); err == nil {
+		return nil, fmt.Errorf("会话已存在: %s", id)
+	}
+
+	now := time.Now()
+	sess := &Session{
+		Meta: Meta{
+			ID:        id,
+			Model:     model,
+			Servers:   append([]string(nil), servers...),
+			CreatedAt: now,
+		},
+		UpdatedAt: now,
+	}
+	sort.Strings(sess.Servers)
+	return sess, nil
+}
+
+// Load 读取会话的完整消息历史
+func (s *Store) Load(id string) (*Session, error) {
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess := &Session{saved: true}
+	err := s.scan(id, func(r record) {
+		sess.UpdatedAt = r.Time
+		switch r.Type {
+		case recordMeta:
+			if r.Meta != nil {
+				sess.Meta = *r.Meta
+			}
+		case recordMessage:
+			if r.Message != nil {
+				sess.Messages = append(sess.Messages, *r.Message)
+			}
+		case recordCompact:
+			sess.Messages = append([]history.HistoryMessage(nil), r.Messages...)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// Append 将新产生的消息追加到会话文件
+func (s *Store) Append(sess *Session, messages ...history.HistoryMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	records := make([]record, 0, len(messages)+1)
+	if !sess.saved {
+		records = append(records, record{Type: recordMeta, Time: now, Meta: &sess.Meta})
+	}
+	for i := range messages {
+		records = append(records, record{Type: recordMessage, Time: now, Message: &messages[i]})
+	}
+	if err := s.appendRecords(sess.ID, records...); err != nil {
+		return err
+	}
+
+	sess.saved = true
+	sess.Messages = append(sess.Messages, messages...)
+	sess.UpdatedAt = now
+	return nil
+}
+
+// Compact 记录压缩后的消息列表，之后加载会话时以它替换此前的全部消息。
+// 原始消息仍保留在文件中，便于追溯。
+func (s *Store) Compact(sess *Session, messages []history.HistoryMessage) error {
+	now := time.Now()
+	records := make([]record, 0, 2)
+	if !sess.saved {
+		records = append(records, record{Type: recordMeta, Time: now, Meta: &sess.Meta})
+	}
+	records = append(records, record{Type: recordCompact, Time: now, Messages: messages})
+	if err := s.appendRecords(sess.ID, records...); err != nil {
+		return err
+	}
+
+	sess.saved = true
+	sess.Messages = append([]history.HistoryMessage(nil), messages...)
+	sess.UpdatedAt = now
+	return nil
+}
+
+// UpdateMeta 追加一条新的元数据记录，加载时以最后一条为准
+func (s *Store) UpdateMeta(sess *Session, model string, servers []string) error {
+	sess.Model = model
+	sess.Servers = append([]string(nil), servers...)
+	sort.Strings(sess.Servers)
+	sess.UpdatedAt = time.Now()
+	if !sess.saved {
+		return nil
+	}
+	return s.appendRecords(sess.ID, record{Type: recordMeta, Time: sess.UpdatedAt, Meta: &sess.Meta})
+}
+
+// List 返回所有会话的摘要，最近更新的排在前面
+func (s *Store) List() ([]Summary, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取会话目录失败: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var summaries []Summary
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileExt) {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), fileExt)
+
+		var summary Summary
+		err := s.scan(id, func(r record) {
+			summary.UpdatedAt = r.Time
+			switch r.Type {
+			case recordMeta:
+				if r.Meta != nil {
+					summary.Meta = *r.Meta
+				}
+			case recordMessage:
+				summary.MessageCount++
+				if summary.Title == "" && r.Message != nil && r.Message.Role == "user" {
+					summary.Title = truncate(r.Message.GetContent(), 60)
+				}
+			case recordCompact:
+				summary.MessageCount = len(r.Messages)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].UpdatedAt.After(summaries[j].UpdatedAt)
+	})
+	return summaries, nil
+}
+
+// Latest 返回最近更新的会话
+func (s *Store) Latest() (*Session, error) {
+	summaries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	if len(summaries) == 0 {
+		return nil, ErrNotFound
+	}
+	return s.Load(summaries[0].ID)
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+fileExt)
+}
+
+func (s *Store) appendRecords(id string, records ...record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path(id), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("打开会话文件失败: %w", err)
+	}
+	defer f.Close()
+
+	if err := jsonl.TerminateLastLine(f); err != nil {
+		return fmt.Errorf("修复会话文件失败: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("序列化会话记录失败: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("写入会话文件失败: %w", err)
+	}
+	return f.Sync()
+}
+
+// scan 逐行读取会话文件，跳过无法解析的行（例如崩溃时写了一半的最后一行）
+func (s *Store) scan(id string, fn func(record)) error {
+	f, err := os.Open(s.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		return fmt.Errorf("打开会话文件失败: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		fn(r)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取会话文件失败: %w", err)
+	}
+	return nil
+}
+
+// validateID 防止会话 ID 中包含路径分隔符而写到数据目录之外
+func validateID(id string) error {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return fmt.Errorf("无效的会话 ID: %q", id)
+	}
+	return nil
+}
+
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
@@ -0,0 +1,155 @@
+package session
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/mark3labs/mcphost/pkg/history"
+)
+
+func textMessage(role, text string) history.HistoryMessage {
+	return history.HistoryMessage{
+		Role:    role,
+		Content: []history.ContentBlock{{Type: "text", Text: text}},
+	}
+}
+
+func messageTexts(messages []history.HistoryMessage) []string {
+	texts := make([]string, len(messages))
+	for i, m := range messages {
+		texts[i] = m.GetContent()
+	}
+	return texts
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAppendLoadCompactRoundTrip(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess, err := store.Create("s1", "openai:gpt-4o", []string{"b", "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 没有消息时不写文件
+	if _, err := store.Load("s1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Load 空会话: 期望 ErrNotFound，得到 %v", err)
+	}
+
+	if err := store.Append(sess, textMessage("user", "你好"), textMessage("assistant", "你好！")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append(sess, textMessage("user", "第二个问题")); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := store.Load("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Model != "openai:gpt-4o" || !equalStrings(loaded.Servers, []string{"a", "b"}) {
+		t.Fatalf("元数据不一致: %+v", loaded.Meta)
+	}
+	want := []string{"你好", "你好！", "第二个问题"}
+	if got := messageTexts(loaded.Messages); !equalStrings(got, want) {
+		t.Fatalf("消息 = %q，期望 %q", got, want)
+	}
+
+	// 压缩记录替换之前的全部消息，之后追加的消息接在压缩结果后面
+	if err := store.Compact(sess, []history.HistoryMessage{textMessage("user", "摘要")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append(sess, textMessage("assistant", "继续")); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = store.Load("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"摘要", "继续"}
+	if got := messageTexts(loaded.Messages); !equalStrings(got, want) {
+		t.Fatalf("压缩后消息 = %q，期望 %q", got, want)
+	}
+	if got := messageTexts(sess.Messages); !equalStrings(got, want) {
+		t.Fatalf("内存中的消息 = %q，期望 %q", got, want)
+	}
+
+	// 列表中的标题取文件中第一条用户消息，不受压缩影响
+	summaries, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 1 || summaries[0].MessageCount != 2 || summaries[0].Title != "你好" {
+		t.Fatalf("List = %+v", summaries)
+	}
+}
+
+func TestLoadSkipsTruncatedLastLine(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess, err := store.Create("s1", "m", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append(sess, textMessage("user", "完整的消息")); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟写到一半时崩溃
+	f, err := os.OpenFile(store.path("s1"), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"type":"message","message":{"role":"assistant","content":[{"ty`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	loaded, err := store.Load("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := messageTexts(loaded.Messages); !equalStrings(got, []string{"完整的消息"}) {
+		t.Fatalf("消息 = %q", got)
+	}
+
+	// 崩溃后继续追加的消息不能和写了一半的行连在一起
+	if err := store.Append(loaded, textMessage("user", "恢复后的消息")); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = store.Load("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := messageTexts(loaded.Messages); !equalStrings(got, []string{"完整的消息", "恢复后的消息"}) {
+		t.Fatalf("追加后消息 = %q", got)
+	}
+}
+
+func TestInvalidID(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"../x", `a\b`, ".."} {
+		if _, err := store.Create(id, "m", nil); err == nil {
+			t.Errorf("Create(%q) 应该失败", id)
+		}
+	}
+}
@@ -0,0 +1,470 @@
+package vectorstore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mark3labs/mcphost/pkg/jsonl"
+)
+
+// 存储文件中每一行记录的类型
+const (
+	recordMeta   = "meta"
+	recordUpsert = "upsert"
+	recordDelete = "delete"
+)
+
+// compactThreshold 是触发重写存储文件的失效记录数下限
+const compactThreshold = 1000
+
+// Options 是嵌入式存储的配置，零值使用默认值
+type Options struct {
+	Metric         Metric    // 距离度量，默认 L2；打开已有文件时为空表示沿用文件中的度量
+	Index          IndexType // 索引类型，默认 Flat
+	M              int       // HNSW 每个节点的邻居数，默认 16
+	EfConstruction int       // HNSW 建图时的候选集大小，默认 200
+	EfSearch       int       // HNSW 检索时的候选集大小，默认 64
+}
+
+func (o *Options) setDefaults() {
+	if o.Index == "" {
+		o.Index = Flat
+	}
+	if o.M <= 1 {
+		o.M = 16
+	}
+	if o.EfConstruction <= 0 {
+		o.EfConstruction = 200
+	}
+	if o.EfSearch <= 0 {
+		o.EfSearch = 64
+	}
+}
+
+// meta 记录存储创建时的不可变配置
+type meta struct {
+	Metric Metric `json:"metric"`
+}
+
+// record 是存储文件中的一行，文件采用只追加的 JSONL 格式，崩溃时最多丢失最后一行
+type record struct {
+	Type     string    `json:"type"`
+	Meta     *meta     `json:"meta,omitempty"`
+	Document *Document `json:"document,omitempty"`
+	IDs      []string  `json:"ids,omitempty"`
+}
+
+var _ VectorStore = (*DiskStore)(nil)
+
+// DiskStore 是保存在单个文件中的嵌入式向量存储。
+// 全部记录常驻内存，写操作追加到文件；索引在打开时根据记录重建。
+type DiskStore struct {
+	path string
+	opts Options
+	dist distanceFunc
+
+	mu      sync.RWMutex
+	file    *os.File
+	dim     int
+	docs    []*Document    // 按槽位保存的记录，已删除的槽位为 nil
+	vectors [][]float32    // 按槽位保存的向量，删除后保留到重建索引，供 HNSW 遍历已删除的节点
+	ids     map[string]int // 记录 ID 到槽位
+	index   index
+	garbage int // 文件中已被覆盖或删除的记录数
+}
+
+// Open 打开 path 处的存储，文件不存在时创建
+func Open(path string, opts Options) (*DiskStore, error) {
+	opts.setDefaults()
+	if opts.Metric != "" {
+		if _, err := ParseMetric(string(opts.Metric)); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := ParseIndexType(string(opts.Index)); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("创建向量存储目录失败: %w", err)
+	}
+
+	s := &DiskStore{path: path, ids: make(map[string]int)}
+	fileMeta, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case fileMeta == nil:
+		if opts.Metric == "" {
+			opts.Metric = L2
+		}
+	case opts.Metric == "":
+		opts.Metric = fileMeta.Metric
+	case opts.Metric != fileMeta.Metric:
+		return nil, fmt.Errorf("向量存储 %s 使用 %s 度量，与配置的 %s 不一致", path, fileMeta.Metric, opts.Metric)
+	}
+	s.opts = opts
+	s.dist = distanceFor(opts.Metric)
+	s.rebuildIndex()
+
+	s.file, err = os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("打开向量存储文件失败: %w", err)
+	}
+	if err := jsonl.TerminateLastLine(s.file); err != nil {
+		s.file.Close()
+		return nil, fmt.Errorf("修复向量存储文件失败: %w", err)
+	}
+	if fileMeta == nil {
+		if err := s.appendRecords(record{Type: recordMeta, Meta: &meta{Metric: opts.Metric}}); err != nil {
+			s.file.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// load 逐行回放存储文件，跳过无法解析的行（例如崩溃时写了一半的最后一行）
+func (s *DiskStore) load() (*meta, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("打开向量存储文件失败: %w", err)
+	}
+	defer f.Close()
+
+	var fileMeta *meta
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		switch r.Type {
+		case recordMeta:
+			fileMeta = r.Meta
+		case recordUpsert:
+			if r.Document != nil {
+				s.put(r.Document)
+			}
+		case recordDelete:
+			for _, id := range r.IDs {
+				s.drop(id)
+			}
+			s.garbage++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取向量存储文件失败: %w", err)
+	}
+	return fileMeta, nil
+}
+
+// put 在内存中写入记录，不更新索引
+func (s *DiskStore) put(doc *Document) int {
+	if s.dim == 0 {
+		s.dim = len(doc.Vector)
+	}
+	if old, ok := s.ids[doc.ID]; ok {
+		s.docs[old] = nil
+		s.garbage++
+		if s.index != nil {
+			s.index.remove(old)
+		}
+	}
+	node := len(s.docs)
+	s.docs = append(s.docs, doc)
+	s.vectors = append(s.vectors, doc.Vector)
+	s.ids[doc.ID] = node
+	return node
+}
+
+// drop 在内存中删除记录，返回是否存在
+func (s *DiskStore) drop(id string) bool {
+	node, ok := s.ids[id]
+	if !ok {
+		return false
+	}
+	s.docs[node] = nil
+	delete(s.ids, id)
+	s.garbage++
+	if s.index != nil {
+		s.index.remove(node)
+	}
+	return true
+}
+
+// rebuildIndex 丢弃已删除的槽位并重建索引
+func (s *DiskStore) rebuildIndex() {
+	docs := make([]*Document, 0, len(s.ids))
+	vectors := make([][]float32, 0, len(s.ids))
+	for _, doc := range s.docs {
+		if doc != nil {
+			s.ids[doc.ID] = len(docs)
+			docs = append(docs, doc)
+			vectors = append(vectors, doc.Vector)
+		}
+	}
+	s.docs, s.vectors = docs, vectors
+	s.index = newIndex(s.opts.Index, s.opts, func(node int) []float32 { return s.vectors[node] }, s.dist)
+	for node := range s.docs {
+		s.index.add(node)
+	}
+}
+
+func (s *DiskStore) appendRecords(records ...record) error {
+	w := bufio.NewWriter(s.file)
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("序列化向量记录失败: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("写入向量存储文件失败: %w", err)
+	}
+	return s.file.Sync()
+}
+
+// checkVector 校验向量非空且与存储中的维度一致
+func (s *DiskStore) checkVector(v []float32) error {
+	if len(v) == 0 {
+		return errors.New("向量不能为空")
+	}
+	if s.dim != 0 && len(v) != s.dim {
+		return fmt.Errorf("%w: 存储为 %d 维，收到 %d 维", ErrDimensionMismatch, s.dim, len(v))
+	}
+	return nil
+}
+
+func (s *DiskStore) Upsert(_ context.Context, docs ...Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return errors.New("向量存储已关闭")
+	}
+
+	records := make([]record, 0, len(docs))
+	dim := s.dim
+	for i := range docs {
+		if docs[i].ID == "" {
+			return errors.New("记录 ID 不能为空")
+		}
+		if dim == 0 {
+			dim = len(docs[i].Vector)
+		}
+		if len(docs[i].Vector) == 0 || len(docs[i].Vector) != dim {
+			return fmt.Errorf("%w: 记录 %s 为 %d 维，应为 %d 维", ErrDimensionMismatch, docs[i].ID, len(docs[i].Vector), dim)
+		}
+		doc := docs[i]
+		records = append(records, record{Type: recordUpsert, Document: &doc})
+	}
+	if err := s.appendRecords(records...); err != nil {
+		return err
+	}
+	for _, r := range records {
+		s.index.add(s.put(r.Document))
+	}
+	return s.maybeCompact()
+}
+
+func (s *DiskStore) Search(_ context.Context, vector []float32, opts SearchOptions) ([]Result, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.ids) == 0 {
+		return nil, nil
+	}
+	if err := s.checkVector(vector); err != nil {
+		return nil, err
+	}
+
+	k := opts.TopK
+	if k <= 0 {
+		k = 1
+	}
+	var found []candidate
+	if len(opts.Filters) > 0 {
+		// 带过滤条件时在满足条件的记录中精确检索，避免近似索引漏掉结果
+		for node, doc := range s.docs {
+			if doc != nil && MatchFilters(doc, opts.Filters) {
+				found = append(found, candidate{node: node, distance: s.dist(vector, doc.Vector)})
+			}
+		}
+		sortCandidates(found)
+		if len(found) > k {
+			found = found[:k]
+		}
+	} else {
+		found = s.index.search(vector, k)
+	}
+
+	results := make([]Result, 0, len(found))
+	for _, c := range found {
+		if opts.MaxDistance > 0 && c.distance > opts.MaxDistance {
+			continue
+		}
+		results = append(results, Result{
+			Document: *s.docs[c.node],
+			Distance: c.distance,
+			Score:    score(s.opts.Metric, c.distance),
+		})
+	}
+	return results, nil
+}
+
+func (s *DiskStore) Get(_ context.Context, id string) (Document, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	node, ok := s.ids[id]
+	if !ok {
+		return Document{}, false, nil
+	}
+	return *s.docs[node], true, nil
+}
+
+func (s *DiskStore) Delete(_ context.Context, ids ...string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.delete(ids)
+}
+
+func (s *DiskStore) DeleteBySource(_ context.Context, source string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []string
+	for _, doc := range s.docs {
+		if doc != nil && doc.Source == source {
+			ids = append(ids, doc.ID)
+		}
+	}
+	return s.delete(ids)
+}
+
+func (s *DiskStore) delete(ids []string) (int, error) {
+	if s.file == nil {
+		return 0, errors.New("向量存储已关闭")
+	}
+	var existing []string
+	for _, id := range ids {
+		if _, ok := s.ids[id]; ok {
+			existing = append(existing, id)
+		}
+	}
+	if len(existing) == 0 {
+		return 0, nil
+	}
+	if err := s.appendRecords(record{Type: recordDelete, IDs: existing}); err != nil {
+		return 0, err
+	}
+	for _, id := range existing {
+		s.drop(id)
+	}
+	s.garbage++
+	return len(existing), s.maybeCompact()
+}
+
+// Each 按写入顺序遍历全部记录，fn 返回 false 时停止。遍历期间持有读锁，fn 中不能写存储。
+func (s *DiskStore) Each(fn func(doc Document) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, doc := range s.docs {
+		if doc != nil && !fn(*doc) {
+			return
+		}
+	}
+}
+
+func (s *DiskStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.ids)
+}
+
+// maybeCompact 在失效记录过多时重写存储文件
+func (s *DiskStore) maybeCompact() error {
+	if s.garbage < compactThreshold || s.garbage < len(s.ids) {
+		return nil
+	}
+	return s.compact()
+}
+
+// Compact 只保留有效记录重写存储文件，并重建索引
+func (s *DiskStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return errors.New("向量存储已关闭")
+	}
+	return s.compact()
+}
+
+// compact 先写临时文件再替换，任何时刻磁盘上都有完整的存储文件
+func (s *DiskStore) compact() error {
+	tmpPath := s.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(record{Type: recordMeta, Meta: &meta{Metric: s.opts.Metric}})
+	for _, doc := range s.docs {
+		if err != nil {
+			break
+		}
+		if doc != nil {
+			err = enc.Encode(record{Type: recordUpsert, Document: doc})
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	tmp.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("重写向量存储文件失败: %w", err)
+	}
+
+	s.file.Close()
+	renameErr := os.Rename(tmpPath, s.path)
+	s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if renameErr != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("替换向量存储文件失败: %w", renameErr)
+	}
+	if err != nil {
+		return fmt.Errorf("打开向量存储文件失败: %w", err)
+	}
+	s.garbage = 0
+	s.rebuildIndex()
+	return nil
+}
+
+// Close 关闭存储文件，之后的写操作会返回错误
+func (s *DiskStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
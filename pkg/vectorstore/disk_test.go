@@ -0,0 +1,327 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func randomVector(rng *rand.Rand, dim int) []float32 {
+	v := make([]float32, dim)
+	for i := range v {
+		v[i] = rng.Float32()*2 - 1
+	}
+	return v
+}
+
+func randomDocs(rng *rand.Rand, n, dim int) []Document {
+	docs := make([]Document, n)
+	for i := range docs {
+		docs[i] = Document{
+			ID:      fmt.Sprintf("doc-%d", i),
+			Content: fmt.Sprintf("内容 %d", i),
+			Source:  fmt.Sprintf("file-%d.md", i%10),
+			Vector:  randomVector(rng, dim),
+		}
+	}
+	return docs
+}
+
+func openStore(t *testing.T, path string, opts Options) *DiskStore {
+	t.Helper()
+	store, err := Open(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func resultIDs(results []Result) []string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func TestHNSWRecall(t *testing.T) {
+	const (
+		n       = 1000
+		dim     = 16
+		queries = 50
+		k       = 10
+	)
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(42))
+	docs := randomDocs(rng, n, dim)
+
+	for _, metric := range []Metric{L2, Cosine} {
+		t.Run(string(metric), func(t *testing.T) {
+			dir := t.TempDir()
+			flat := openStore(t, filepath.Join(dir, "flat.jsonl"), Options{Metric: metric, Index: Flat})
+			hnsw := openStore(t, filepath.Join(dir, "hnsw.jsonl"), Options{Metric: metric, Index: HNSW})
+			if err := flat.Upsert(ctx, docs...); err != nil {
+				t.Fatal(err)
+			}
+			if err := hnsw.Upsert(ctx, docs...); err != nil {
+				t.Fatal(err)
+			}
+
+			// 以暴力检索的结果为准，统计 HNSW 找回的比例
+			var hit, total int
+			for i := 0; i < queries; i++ {
+				query := randomVector(rng, dim)
+				exact, err := flat.Search(ctx, query, SearchOptions{TopK: k})
+				if err != nil {
+					t.Fatal(err)
+				}
+				approx, err := hnsw.Search(ctx, query, SearchOptions{TopK: k})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(approx) != k {
+					t.Fatalf("HNSW 返回 %d 条结果，期望 %d 条", len(approx), k)
+				}
+				want := make(map[string]bool, k)
+				for _, r := range exact {
+					want[r.ID] = true
+				}
+				for _, r := range approx {
+					if want[r.ID] {
+						hit++
+					}
+				}
+				total += len(exact)
+			}
+			if recall := float64(hit) / float64(total); recall < 0.9 {
+				t.Fatalf("HNSW 召回率 %.3f 低于 0.9", recall)
+			}
+		})
+	}
+}
+
+func TestReloadAfterDelete(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(1))
+	docs := randomDocs(rng, 50, 8)
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+
+	for _, typ := range []IndexType{Flat, HNSW} {
+		t.Run(string(typ), func(t *testing.T) {
+			os.Remove(path)
+			store, err := Open(path, Options{Index: typ})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Upsert(ctx, docs...); err != nil {
+				t.Fatal(err)
+			}
+			if n, err := store.Delete(ctx, "doc-0", "doc-1", "不存在"); err != nil || n != 2 {
+				t.Fatalf("Delete = %d, %v，期望 2", n, err)
+			}
+			if n, err := store.DeleteBySource(ctx, "file-5.md"); err != nil || n != 5 {
+				t.Fatalf("DeleteBySource = %d, %v，期望 5", n, err)
+			}
+			// 覆盖写入的记录以最后一次为准
+			updated := docs[2]
+			updated.Content = "更新后的内容"
+			if err := store.Upsert(ctx, updated); err != nil {
+				t.Fatal(err)
+			}
+			store.Close()
+
+			store = openStore(t, path, Options{Index: typ})
+			if store.Len() != 43 {
+				t.Fatalf("重新打开后 Len = %d，期望 43", store.Len())
+			}
+			for _, id := range []string{"doc-0", "doc-1", "doc-5", "doc-45"} {
+				if _, ok, _ := store.Get(ctx, id); ok {
+					t.Fatalf("%s 已删除，重新打开后仍然存在", id)
+				}
+			}
+			if doc, ok, _ := store.Get(ctx, "doc-2"); !ok || doc.Content != "更新后的内容" {
+				t.Fatalf("doc-2 = %+v, %v", doc, ok)
+			}
+			// 已删除的记录不会出现在检索结果中
+			results, err := store.Search(ctx, docs[0].Vector, SearchOptions{TopK: 50})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != 43 {
+				t.Fatalf("检索到 %d 条，期望 43 条", len(results))
+			}
+			for _, r := range results {
+				if r.ID == "doc-0" || r.Source == "file-5.md" {
+					t.Fatalf("检索结果包含已删除的记录 %s", r.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestReloadAfterCompact(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(2))
+	docs := randomDocs(rng, 30, 8)
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+
+	store, err := Open(path, Options{Metric: Cosine, Index: HNSW})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Upsert(ctx, docs...); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Delete(ctx, "doc-3", "doc-4"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	before, err := store.Search(ctx, docs[7].Vector, SearchOptions{TopK: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 压缩后继续写入，新记录追加在重写后的文件中
+	extra := Document{ID: "extra", Content: "压缩后写入", Vector: randomVector(rng, 8)}
+	if err := store.Upsert(ctx, extra); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("压缩后不应留下临时文件: %v", err)
+	}
+
+	// 不指定度量时沿用文件中的度量
+	store = openStore(t, path, Options{Index: HNSW})
+	if store.opts.Metric != Cosine {
+		t.Fatalf("度量 = %s，期望 %s", store.opts.Metric, Cosine)
+	}
+	if store.Len() != 29 {
+		t.Fatalf("重新打开后 Len = %d，期望 29", store.Len())
+	}
+	if _, ok, _ := store.Get(ctx, "doc-3"); ok {
+		t.Fatal("doc-3 已删除，压缩并重新打开后仍然存在")
+	}
+	if doc, ok, _ := store.Get(ctx, "extra"); !ok || doc.Content != "压缩后写入" {
+		t.Fatalf("extra = %+v, %v", doc, ok)
+	}
+	after, err := store.Search(ctx, docs[7].Vector, SearchOptions{TopK: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) == 0 || after[0].ID != "doc-7" {
+		t.Fatalf("检索结果 = %v，第一条应为 doc-7", resultIDs(after))
+	}
+	// 除新写入的记录外，结果顺序与重新打开前一致
+	var kept []string
+	for _, id := range resultIDs(after) {
+		if id != "extra" {
+			kept = append(kept, id)
+		}
+	}
+	for i, id := range kept {
+		if id != before[i].ID {
+			t.Fatalf("重新打开后检索结果 = %v，之前为 %v", resultIDs(after), resultIDs(before))
+		}
+	}
+
+	if _, err := Open(path, Options{Metric: L2}); err == nil {
+		t.Fatal("度量与文件不一致时应该报错")
+	}
+}
+
+func TestReloadSkipsTruncatedLastLine(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+	store, err := Open(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Upsert(ctx, Document{ID: "a", Vector: []float32{1, 0}}); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// 模拟写到一半时崩溃
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"type":"upsert","document":{"id":"b","vec`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	store, err = Open(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Len() != 1 {
+		t.Fatalf("Len = %d，期望 1", store.Len())
+	}
+	// 崩溃后继续写入的记录不能和写了一半的行连在一起
+	if err := store.Upsert(ctx, Document{ID: "c", Vector: []float32{0, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	store = openStore(t, path, Options{})
+	if _, ok, _ := store.Get(ctx, "c"); !ok || store.Len() != 2 {
+		t.Fatalf("重新打开后 Len = %d，c 存在 = %v", store.Len(), ok)
+	}
+}
+
+func TestDimensionMismatch(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+	store, err := Open(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一批记录的维度也必须一致
+	err = store.Upsert(ctx,
+		Document{ID: "a", Vector: []float32{1, 2, 3}},
+		Document{ID: "b", Vector: []float32{1, 2}},
+	)
+	if !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("期望 ErrDimensionMismatch，得到 %v", err)
+	}
+	if store.Len() != 0 {
+		t.Fatalf("写入失败时不应写入任何记录，Len = %d", store.Len())
+	}
+
+	if err := store.Upsert(ctx, Document{ID: "a", Vector: []float32{1, 2, 3}}); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// 重新打开后维度从文件中恢复
+	store = openStore(t, path, Options{})
+	tests := []struct {
+		name string
+		fn   func() error
+	}{
+		{"写入", func() error { return store.Upsert(ctx, Document{ID: "b", Vector: []float32{1, 2}}) }},
+		{"空向量", func() error { return store.Upsert(ctx, Document{ID: "b"}) }},
+		{"检索", func() error {
+			_, err := store.Search(ctx, []float32{1, 2, 3, 4}, SearchOptions{})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		if err := tt.fn(); !errors.Is(err, ErrDimensionMismatch) {
+			t.Errorf("%s: 期望 ErrDimensionMismatch，得到 %v", tt.name, err)
+		}
+	}
+	if store.Len() != 1 {
+		t.Fatalf("Len = %d，期望 1", store.Len())
+	}
+}
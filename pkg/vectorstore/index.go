@@ -0,0 +1,261 @@
+package vectorstore
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// candidate 是索引内部的检索结果，node 是记录在存储中的槽位
+type candidate struct {
+	node     int
+	distance float64
+}
+
+// index 是检索索引，只保存槽位，向量通过 vector 回调读取
+type index interface {
+	add(node int)
+	remove(node int)
+	// search 返回与 query 最相近的最多 k 个未删除的槽位，按距离从小到大排列
+	search(query []float32, k int) []candidate
+}
+
+func newIndex(typ IndexType, opts Options, vector func(int) []float32, dist distanceFunc) index {
+	if typ == HNSW {
+		return newHNSWIndex(opts, vector, dist)
+	}
+	return &flatIndex{vector: vector, dist: dist, nodes: make(map[int]struct{})}
+}
+
+// flatIndex 逐条计算距离的暴力检索
+type flatIndex struct {
+	vector func(int) []float32
+	dist   distanceFunc
+	nodes  map[int]struct{}
+}
+
+func (f *flatIndex) add(node int)    { f.nodes[node] = struct{}{} }
+func (f *flatIndex) remove(node int) { delete(f.nodes, node) }
+
+func (f *flatIndex) search(query []float32, k int) []candidate {
+	results := make([]candidate, 0, len(f.nodes))
+	for node := range f.nodes {
+		results = append(results, candidate{node: node, distance: f.dist(query, f.vector(node))})
+	}
+	sortCandidates(results)
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+func sortCandidates(c []candidate) {
+	sort.Slice(c, func(i, j int) bool {
+		if c[i].distance != c[j].distance {
+			return c[i].distance < c[j].distance
+		}
+		return c[i].node < c[j].node
+	})
+}
+
+// hnswIndex 是 HNSW 近似最近邻索引（Malkov & Yashunin, 2016）。
+// 删除只做标记，被删除的节点仍参与图的遍历但不会出现在结果中，重建存储时才真正移除。
+type hnswIndex struct {
+	vector         func(int) []float32
+	dist           distanceFunc
+	m, m0          int // 第 1 层及以上、第 0 层的最大邻居数
+	efConstruction int
+	efSearch       int
+	levelMult      float64
+	rng            *rand.Rand
+
+	nodes    map[int]*hnswNode
+	deleted  map[int]bool
+	entry    int
+	maxLevel int
+}
+
+type hnswNode struct {
+	neighbors [][]int // 每层的邻居槽位
+}
+
+func newHNSWIndex(opts Options, vector func(int) []float32, dist distanceFunc) *hnswIndex {
+	return &hnswIndex{
+		vector:         vector,
+		dist:           dist,
+		m:              opts.M,
+		m0:             2 * opts.M,
+		efConstruction: opts.EfConstruction,
+		efSearch:       opts.EfSearch,
+		levelMult:      1 / math.Log(float64(opts.M)),
+		rng:            rand.New(rand.NewSource(1)),
+		nodes:          make(map[int]*hnswNode),
+		deleted:        make(map[int]bool),
+		entry:          -1,
+	}
+}
+
+func (h *hnswIndex) randomLevel() int {
+	return int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelMult))
+}
+
+func (h *hnswIndex) maxNeighbors(level int) int {
+	if level == 0 {
+		return h.m0
+	}
+	return h.m
+}
+
+func (h *hnswIndex) add(node int) {
+	level := h.randomLevel()
+	n := &hnswNode{neighbors: make([][]int, level+1)}
+	h.nodes[node] = n
+	delete(h.deleted, node)
+
+	if h.entry < 0 {
+		h.entry, h.maxLevel = node, level
+		return
+	}
+
+	query := h.vector(node)
+	entry := []candidate{{node: h.entry, distance: h.dist(query, h.vector(h.entry))}}
+	for l := h.maxLevel; l > level; l-- {
+		entry = h.searchLayer(query, entry, 1, l)
+	}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		found := h.searchLayer(query, entry, h.efConstruction, l)
+		neighbors := found
+		if len(neighbors) > h.m {
+			neighbors = neighbors[:h.m]
+		}
+		for _, c := range neighbors {
+			n.neighbors[l] = append(n.neighbors[l], c.node)
+			h.connect(c.node, node, l)
+		}
+		entry = found
+	}
+
+	if level > h.maxLevel {
+		h.entry, h.maxLevel = node, level
+	}
+}
+
+// connect 为 from 增加指向 to 的边，超过上限时只保留最近的邻居
+func (h *hnswIndex) connect(from, to, level int) {
+	n := h.nodes[from]
+	n.neighbors[level] = append(n.neighbors[level], to)
+	limit := h.maxNeighbors(level)
+	if len(n.neighbors[level]) <= limit {
+		return
+	}
+
+	base := h.vector(from)
+	cands := make([]candidate, len(n.neighbors[level]))
+	for i, id := range n.neighbors[level] {
+		cands[i] = candidate{node: id, distance: h.dist(base, h.vector(id))}
+	}
+	sortCandidates(cands)
+	kept := n.neighbors[level][:0]
+	for _, c := range cands[:limit] {
+		kept = append(kept, c.node)
+	}
+	n.neighbors[level] = kept
+}
+
+func (h *hnswIndex) remove(node int) {
+	if _, ok := h.nodes[node]; ok {
+		h.deleted[node] = true
+	}
+}
+
+func (h *hnswIndex) search(query []float32, k int) []candidate {
+	if h.entry < 0 || k <= 0 {
+		return nil
+	}
+
+	entry := []candidate{{node: h.entry, distance: h.dist(query, h.vector(h.entry))}}
+	for l := h.maxLevel; l > 0; l-- {
+		entry = h.searchLayer(query, entry, 1, l)
+	}
+	// 被删除的节点会占用候选名额，按删除比例放大搜索范围
+	ef := max(h.efSearch, k) + len(h.deleted)
+	found := h.searchLayer(query, entry, ef, 0)
+
+	results := make([]candidate, 0, k)
+	for _, c := range found {
+		if h.deleted[c.node] {
+			continue
+		}
+		results = append(results, c)
+		if len(results) >= k {
+			break
+		}
+	}
+	return results
+}
+
+// searchLayer 在第 level 层从 entry 出发做贪心扩展，返回最近的最多 ef 个节点，按距离从小到大排列
+func (h *hnswIndex) searchLayer(query []float32, entry []candidate, ef, level int) []candidate {
+	visited := make(map[int]bool, ef*4)
+	pending := &candidateHeap{}
+	nearest := &candidateHeap{farthestFirst: true}
+	for _, c := range entry {
+		visited[c.node] = true
+		heap.Push(pending, c)
+		heap.Push(nearest, c)
+	}
+	for nearest.Len() > ef {
+		heap.Pop(nearest)
+	}
+
+	for pending.Len() > 0 {
+		current := heap.Pop(pending).(candidate)
+		if nearest.Len() >= ef && current.distance > nearest.items[0].distance {
+			break
+		}
+		n := h.nodes[current.node]
+		if level >= len(n.neighbors) {
+			continue
+		}
+		for _, id := range n.neighbors[level] {
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			c := candidate{node: id, distance: h.dist(query, h.vector(id))}
+			if nearest.Len() < ef || c.distance < nearest.items[0].distance {
+				heap.Push(pending, c)
+				heap.Push(nearest, c)
+				if nearest.Len() > ef {
+					heap.Pop(nearest)
+				}
+			}
+		}
+	}
+
+	results := append([]candidate(nil), nearest.items...)
+	sortCandidates(results)
+	return results
+}
+
+// candidateHeap 默认是按距离从小到大出堆的最小堆，farthestFirst 时为最大堆
+type candidateHeap struct {
+	items         []candidate
+	farthestFirst bool
+}
+
+func (h *candidateHeap) Len() int { return len(h.items) }
+func (h *candidateHeap) Less(i, j int) bool {
+	if h.farthestFirst {
+		return h.items[i].distance > h.items[j].distance
+	}
+	return h.items[i].distance < h.items[j].distance
+}
+func (h *candidateHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *candidateHeap) Push(x interface{}) { h.items = append(h.items, x.(candidate)) }
+func (h *candidateHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
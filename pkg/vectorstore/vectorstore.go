@@ -0,0 +1,153 @@
+// Package vectorstore 提供向量存储接口，以及不依赖外部服务的嵌入式磁盘实现，
+// 支持暴力检索和 HNSW 近似检索，距离可选 L2 或余弦。
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrDimensionMismatch 表示向量维度与存储中已有的维度不一致
+var ErrDimensionMismatch = errors.New("向量维度不一致")
+
+// Metric 是向量距离的度量方式
+type Metric string
+
+const (
+	// L2 是欧氏距离的平方，与 Milvus 的 L2 度量一致，越小越相关
+	L2 Metric = "l2"
+	// Cosine 是余弦距离（1 - 余弦相似度），范围 [0, 2]，越小越相关
+	Cosine Metric = "cosine"
+)
+
+// IndexType 是检索使用的索引类型
+type IndexType string
+
+const (
+	// Flat 逐条计算距离，结果精确，适合小规模数据
+	Flat IndexType = "flat"
+	// HNSW 是分层可导航小世界图索引，检索快但结果是近似的
+	HNSW IndexType = "hnsw"
+)
+
+// ParseMetric 解析配置中的度量名称，空字符串表示 L2
+func ParseMetric(s string) (Metric, error) {
+	switch Metric(s) {
+	case "", L2:
+		return L2, nil
+	case Cosine:
+		return Cosine, nil
+	}
+	return "", fmt.Errorf("不支持的距离度量: %s（可选 l2、cosine）", s)
+}
+
+// ParseIndexType 解析配置中的索引名称，空字符串表示 Flat
+func ParseIndexType(s string) (IndexType, error) {
+	switch IndexType(s) {
+	case "", Flat:
+		return Flat, nil
+	case HNSW:
+		return HNSW, nil
+	}
+	return "", fmt.Errorf("不支持的索引类型: %s（可选 flat、hnsw）", s)
+}
+
+// Document 是存储中的一条记录
+type Document struct {
+	ID       string            `json:"id"`
+	Content  string            `json:"content"`
+	Source   string            `json:"source,omitempty"` // 来源文档，例如入库时的文件路径
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Vector   []float32         `json:"vector"`
+}
+
+// Result 是一条检索结果
+type Result struct {
+	Document
+	Distance float64 // 与查询向量的距离，越小越相关
+	Score    float64 // 归一化的相关性分数，范围 [0, 1]，越大越相关
+}
+
+// SearchOptions 控制检索返回的结果
+type SearchOptions struct {
+	TopK        int               // 最多返回的结果数，不大于 0 时返回 1 条
+	MaxDistance float64           // 距离阈值，超过阈值的结果被丢弃；不大于 0 时不过滤
+	Filters     map[string]string // 元数据过滤条件，所有条件都满足的记录才会返回；键 source 匹配来源文档
+}
+
+// VectorStore 是向量存储的通用接口
+type VectorStore interface {
+	// Upsert 写入记录，ID 已存在时覆盖
+	Upsert(ctx context.Context, docs ...Document) error
+	// Search 返回与 vector 最相近的记录，按距离从小到大排列
+	Search(ctx context.Context, vector []float32, opts SearchOptions) ([]Result, error)
+	// Get 按 ID 读取记录
+	Get(ctx context.Context, id string) (Document, bool, error)
+	// Delete 按 ID 删除记录，返回实际删除的条数
+	Delete(ctx context.Context, ids ...string) (int, error)
+	// DeleteBySource 删除来源为 source 的全部记录，返回删除的条数
+	DeleteBySource(ctx context.Context, source string) (int, error)
+	// Len 返回记录数
+	Len() int
+	// Close 释放存储占用的资源
+	Close() error
+}
+
+// distanceFunc 计算两个等长向量的距离
+type distanceFunc func(a, b []float32) float64
+
+func distanceFor(m Metric) distanceFunc {
+	if m == Cosine {
+		return cosineDistance
+	}
+	return squaredL2
+}
+
+func squaredL2(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		sum += d * d
+	}
+	return sum
+}
+
+func cosineDistance(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 1
+	}
+	// 浮点误差可能使相同方向的向量得到略小于 0 的距离
+	return math.Max(0, 1-dot/(math.Sqrt(na)*math.Sqrt(nb)))
+}
+
+// score 把距离换算为 [0, 1] 的相关性分数
+func score(m Metric, distance float64) float64 {
+	if m == Cosine {
+		return math.Max(0, 1-distance/2)
+	}
+	return 1 / (1 + distance)
+}
+
+// MatchFilters 判断记录是否满足全部过滤条件，键 "source" 匹配来源文档，其余键匹配元数据
+func MatchFilters(doc *Document, filters map[string]string) bool {
+	for key, value := range filters {
+		if key == "source" {
+			if doc.Source != value {
+				return false
+			}
+			continue
+		}
+		if doc.Metadata[key] != value {
+			return false
+		}
+	}
+	return true
+}
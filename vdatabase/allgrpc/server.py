@@ -1,7 +1,7 @@
 import grpc
 from concurrent import futures
 from Log.log import logger
-from allgrpc.allproto import protos_pb2,protos_pb2_grpc
+from allgrpc.allproto import protos_pb2,protos_pb2_grpc,protos_v2_pb2,protos_v2_pb2_grpc
 
 class Getdata(protos_pb2_grpc.DataManagementServicer):
     def __init__(self, milvus, file):
@@ -39,10 +39,67 @@ class Getdata(protos_pb2_grpc.DataManagementServicer):
                 return protos_pb2.Response(answer=f"存储失败，可能文件格式不正确" )
 
 
+class DataV2(protos_v2_pb2_grpc.DataManagementServicer):
+    """v2 接口：支持 top-k、距离阈值、集合和元数据过滤，返回带分数的多条结果"""
+    def __init__(self, milvus):
+        self.milvus = milvus
+
+    def Search(self, request, context):
+        try:
+            hits = self.milvus.search(
+                request.query,
+                top_k=request.top_k,
+                max_distance=request.max_distance,
+                collection=request.collection,
+                filters=dict(request.filters),
+            )
+        except ValueError as e:
+            context.abort(grpc.StatusCode.INVALID_ARGUMENT, str(e))
+        except Exception as e:
+            print(f"Error handling request: {e}")
+            context.abort(grpc.StatusCode.INTERNAL, f"Internal error: {e}")
+        return protos_v2_pb2.SearchResponse(hits=[
+            protos_v2_pb2.Hit(
+                id=hit_id,
+                distance=distance,
+                score=1.0 / (1.0 + distance),
+                source=source,
+                content=content,
+                metadata=metadata,
+            )
+            for hit_id, distance, source, content, metadata in hits
+        ])
+
+    def ListCollections(self, request, context):
+        try:
+            collections = self.milvus.listcollections()
+        except Exception as e:
+            print(f"Error handling request: {e}")
+            context.abort(grpc.StatusCode.INTERNAL, f"Internal error: {e}")
+        return protos_v2_pb2.ListCollectionsResponse(collections=[
+            protos_v2_pb2.Collection(name=name, num_entities=num, description=description)
+            for name, num, description in collections
+        ])
+
+    def DeleteDocuments(self, request, context):
+        try:
+            deleted = self.milvus.deletedocuments(
+                collection=request.collection,
+                ids=list(request.ids),
+                source=request.source,
+            )
+        except ValueError as e:
+            context.abort(grpc.StatusCode.INVALID_ARGUMENT, str(e))
+        except Exception as e:
+            print(f"Error handling request: {e}")
+            context.abort(grpc.StatusCode.INTERNAL, f"Internal error: {e}")
+        return protos_v2_pb2.DeleteDocumentsResponse(deleted=deleted)
+
 
 def server(milvus,file):
     server = grpc.server(futures.ThreadPoolExecutor(max_workers=10))
     protos_pb2_grpc.add_DataManagementServicer_to_server(Getdata(milvus,file), server)
+    protos_v2_pb2_grpc.add_DataManagementServicer_to_server(DataV2(milvus), server)
     server.add_insecure_port('[::]:50051')
     server.start()
     print("服务器启动")
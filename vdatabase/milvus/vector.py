@@ -134,6 +134,95 @@ class Milvus:
         except Exception as e:
             print(f"查询失败: {e}")
             return
+    def _collection(self, name):
+        """返回指定名称的集合并确保已加载，name 为空时使用默认集合"""
+        name = name or self.collection_name
+        if not utility.has_collection(name):
+            raise ValueError(f"集合 '{name}' 不存在")
+        if name == self.collection_name and self.collection is not None:
+            return self.collection
+        collection = Collection(name=name)
+        collection.load()
+        return collection
+
+    @staticmethod
+    def _filter_expr(collection, filters):
+        """把元数据过滤条件转换为 Milvus 布尔表达式，只允许集合中存在的标量字段"""
+        if not filters:
+            return ""
+        fields = {field.name for field in collection.schema.fields}
+        parts = []
+        for key, value in filters.items():
+            if key not in fields:
+                raise ValueError(f"集合 '{collection.name}' 没有字段 '{key}'")
+            escaped = value.replace("\\", "\\\\").replace('"', '\\"')
+            parts.append(f'{key} == "{escaped}"')
+        return " and ".join(parts)
+
+    def search(self, query, top_k=1, max_distance=0.5, collection="", filters=None):
+        """检索最相近的 top_k 条结果，返回 (id, distance, source, content, metadata) 列表。
+        max_distance 不大于 0 时不按距离过滤。"""
+        self.checkconnection()
+        top_k = top_k if top_k > 0 else 1
+        target = self._collection(collection)
+        expr = self._filter_expr(target, filters)
+        query_embedding = self.embeddings.embed_query(query)
+        output_fields = [field.name for field in target.schema.fields
+                         if field.dtype not in (DataType.FLOAT_VECTOR, DataType.BINARY_VECTOR) and not field.is_primary]
+        with self.lock:
+            results = target.search(
+                data=[query_embedding],
+                anns_field="embedding",
+                param={"metric_type": "L2"},
+                limit=top_k,
+                expr=expr or None,
+                output_fields=output_fields
+            )
+        hits = []
+        if not results:
+            return hits
+        for hit in results[0]:
+            if max_distance > 0 and hit.distance > max_distance:
+                continue
+            metadata = {name: str(hit.entity.get(name)) for name in output_fields
+                        if name not in ("output", "source")}
+            hits.append((
+                str(hit.id),
+                hit.distance,
+                (hit.entity.get("source") if "source" in output_fields else None) or target.name,
+                (hit.entity.get("output") if "output" in output_fields else None) or "",
+                metadata,
+            ))
+        return hits
+
+    def listcollections(self):
+        """返回所有集合的 (name, num_entities, description) 列表"""
+        self.checkconnection()
+        with self.lock:
+            result = []
+            for name in utility.list_collections():
+                collection = Collection(name=name)
+                result.append((name, collection.num_entities, collection.description))
+            return result
+
+    def deletedocuments(self, collection="", ids=None, source=""):
+        """按 ID 或来源文档删除数据，返回删除的条数"""
+        self.checkconnection()
+        target = self._collection(collection)
+        if ids:
+            expr = "id in [" + ", ".join(str(int(i)) for i in ids) + "]"
+        elif source:
+            if "source" not in {field.name for field in target.schema.fields}:
+                raise ValueError(f"集合 '{target.name}' 没有 source 字段，无法按来源删除")
+            escaped = source.replace("\\", "\\\\").replace('"', '\\"')
+            expr = f'source == "{escaped}"'
+        else:
+            raise ValueError("必须指定 ids 或 source")
+        with self.lock:
+            result = target.delete(expr)
+            target.flush()
+        return result.delete_count
+
     def storejson(self, json_data):
         with self.lock:
             try: